@@ -0,0 +1,76 @@
+package v1alpha1
+
+import "fmt"
+
+// IsBounded returns true if all of the step's sources are bounded, so that it completes once they are exhausted
+func (in StepSpec) IsBounded() bool {
+	for _, s := range in.Sources {
+		if !s.Bounded {
+			return false
+		}
+	}
+	return len(in.Sources) > 0
+}
+
+// GetSourcesWithoutEnd returns the step's bounded Kafka, S3 and STAN sources that do not yet have where they end recorded
+func (in Step) GetSourcesWithoutEnd() []Source {
+	var sources []Source
+	for _, s := range in.Spec.Sources {
+		if s.Bounded && (s.Kafka != nil || s.S3 != nil || s.STAN != nil) && !in.Status.SourceStatuses.Get(s.Name).HasEnd() {
+			sources = append(sources, s)
+		}
+	}
+	return sources
+}
+
+func (in StepSpec) validateBoundedSources() error {
+	for _, s := range in.Sources {
+		if s.Bounded && s.Kafka == nil && s.S3 == nil && s.STAN == nil {
+			return fmt.Errorf("source %q cannot be bounded, only Kafka, S3 and STAN sources can be", s.Name)
+		}
+	}
+	return nil
+}
+
+// IsExhausted returns true if the step is bounded, and the lead replica has reported that all of its sources are
+// exhausted
+func (in Step) IsExhausted() bool {
+	if !in.Spec.IsBounded() {
+		return false
+	}
+	for _, s := range in.Spec.Sources {
+		if !in.Status.SourceStatuses.Get(s.Name).Exhausted {
+			return false
+		}
+	}
+	return true
+}
+
+// BoundStepSources makes the sources from other steps bounded, once all of those steps are bounded and have succeeded,
+// so that bounded pipelines complete in topological order
+func (in *PipelineSpec) BoundStepSources(succeeded map[string]bool) {
+	bounded := map[string]bool{}
+	for _, step := range in.Steps {
+		bounded[step.Name] = step.IsBounded()
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, step := range in.Steps {
+			if bounded[step.Name] || len(step.Sources) == 0 {
+				continue
+			}
+			ok := true // whether every source is either bounded, or from a complete step
+			for _, s := range step.Sources {
+				ok = ok && (s.Bounded || s.Step != "" && bounded[s.Step] && succeeded[s.Step])
+			}
+			if !ok {
+				continue
+			}
+			for i := range step.Sources {
+				step.Sources[i].Bounded = true
+			}
+			bounded[step.Name] = true
+			changed = true
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStepSpec_IsBounded(t *testing.T) {
+	assert.False(t, StepSpec{}.IsBounded())
+	assert.False(t, StepSpec{Sources: []Source{{Bounded: true}, {}}}.IsBounded())
+	assert.True(t, StepSpec{Sources: []Source{{Bounded: true}, {Bounded: true}}}.IsBounded())
+}
+
+func TestStepSpec_validateBoundedSources(t *testing.T) {
+	assert.NoError(t, StepSpec{Sources: []Source{{Kafka: &KafkaSource{}, Bounded: true}, {S3: &S3Source{}, Bounded: true}, {HTTP: &HTTPSource{}}}}.validateBoundedSources())
+	assert.EqualError(t, StepSpec{Sources: []Source{{Name: "default", HTTP: &HTTPSource{}, Bounded: true}}}.validateBoundedSources(), `source "default" cannot be bounded, only Kafka, S3 and STAN sources can be`)
+}
+
+func TestStep_IsExhausted(t *testing.T) {
+	step := Step{Spec: StepSpec{Sources: []Source{{Name: "a", Bounded: true}, {Name: "b", Bounded: true}}}}
+	assert.False(t, step.IsExhausted())
+	step.Status.SourceStatuses = SourceStatuses{}
+	step.Status.SourceStatuses.SetExhausted("a")
+	assert.False(t, step.IsExhausted())
+	step.Status.SourceStatuses.SetExhausted("b")
+	assert.True(t, step.IsExhausted())
+	step.Spec.Sources[1].Bounded = false
+	assert.False(t, step.IsExhausted(), "unbounded steps are never exhausted")
+}
+
+func TestStep_GetSourcesWithoutEnd(t *testing.T) {
+	step := Step{Spec: StepSpec{Sources: []Source{
+		{Name: "kafka", Kafka: &KafkaSource{}, Bounded: true},
+		{Name: "stan", STAN: &STAN{}, Bounded: true},
+		{Name: "s3", S3: &S3Source{}, Bounded: true},
+		{Name: "unbounded", Kafka: &KafkaSource{}},
+	}}}
+	assert.Len(t, step.GetSourcesWithoutEnd(), 3)
+	step.Status.SourceStatuses = SourceStatuses{}
+	step.Status.SourceStatuses.SetEndOffsets("kafka", map[int32]int64{0: 1})
+	step.Status.SourceStatuses.SetEndSequence("stan", 0)
+	step.Status.SourceStatuses.SetEndTime("s3", metav1.Now())
+	assert.Empty(t, step.GetSourcesWithoutEnd())
+}
+
+func TestPipelineSpec_BoundStepSources(t *testing.T) {
+	newSpec := func() *PipelineSpec {
+		return &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sources: []Source{{Name: "default", Kafka: &KafkaSource{}, Bounded: true}}},
+			{Name: "b", Sources: []Source{{Name: "default", Step: "a"}}},
+			{Name: "c", Sources: []Source{{Name: "default", Step: "b"}}},
+			{Name: "d", Sources: []Source{{Name: "a", Step: "a"}, {Name: "http", HTTP: &HTTPSource{}}}},
+		}}
+	}
+	t.Run("NoneSucceeded", func(t *testing.T) {
+		spec := newSpec()
+		spec.BoundStepSources(map[string]bool{})
+		assert.False(t, spec.Steps[1].IsBounded())
+		assert.False(t, spec.Steps[2].IsBounded())
+	})
+	t.Run("FirstSucceeded", func(t *testing.T) {
+		spec := newSpec()
+		spec.BoundStepSources(map[string]bool{"a": true})
+		assert.True(t, spec.Steps[1].IsBounded())
+		assert.False(t, spec.Steps[2].IsBounded())
+		assert.False(t, spec.Steps[3].Sources[0].Bounded, "steps with unbounded sources are not bounded")
+	})
+	t.Run("SecondSucceeded", func(t *testing.T) {
+		spec := newSpec()
+		spec.BoundStepSources(map[string]bool{"a": true, "b": true})
+		assert.True(t, spec.Steps[1].IsBounded())
+		assert.True(t, spec.Steps[2].IsBounded())
+	})
+}
@@ -0,0 +1,83 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ConnectionSpec is the shared configuration of a broker or database. Exactly one of the fields must be set.
+type ConnectionSpec struct {
+	Kafka *KafkaConfig    `json:"kafka,omitempty" protobuf:"bytes,1,opt,name=kafka"`
+	STAN  *STANConnection `json:"stan,omitempty" protobuf:"bytes,2,opt,name=stan"`
+	S3    *S3Connection   `json:"s3,omitempty" protobuf:"bytes,3,opt,name=s3"`
+	DB    *Database       `json:"db,omitempty" protobuf:"bytes,4,opt,name=db"`
+}
+
+type STANConnection struct {
+	NATSURL           string        `json:"natsUrl" protobuf:"bytes,1,opt,name=natsUrl"`
+	NATSMonitoringURL string        `json:"natsMonitoringUrl,omitempty" protobuf:"bytes,2,opt,name=natsMonitoringUrl"`
+	ClusterID         string        `json:"clusterId" protobuf:"bytes,3,opt,name=clusterId"`
+	SubjectPrefix     SubjectPrefix `json:"subjectPrefix,omitempty" protobuf:"bytes,4,opt,name=subjectPrefix,casttype=SubjectPrefix"`
+	Auth              *STANAuth     `json:"auth,omitempty" protobuf:"bytes,5,opt,name=auth"`
+	MaxInflight       uint32        `json:"maxInflight,omitempty" protobuf:"bytes,6,opt,name=maxInflight"`
+}
+
+type S3Connection struct {
+	Region      string          `json:"region,omitempty" protobuf:"bytes,1,opt,name=region"`
+	Credentials *AWSCredentials `json:"credentials,omitempty" protobuf:"bytes,2,opt,name=credentials"`
+	Endpoint    *AWSEndpoint    `json:"endpoint,omitempty" protobuf:"bytes,3,opt,name=endpoint"`
+}
+
+// Validate returns an error if the spec is not a complete configuration of exactly one type of connection.
+func (in ConnectionSpec) Validate() error {
+	n := 0
+	for _, set := range []bool{in.Kafka != nil, in.STAN != nil, in.S3 != nil, in.DB != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("exactly one of kafka, stan, s3 or db must be specified")
+	}
+	if x := in.Kafka; x != nil && len(x.Brokers) == 0 {
+		return fmt.Errorf("kafka brokers must be specified")
+	}
+	if x := in.STAN; x != nil && (x.NATSURL == "" || x.ClusterID == "") {
+		return fmt.Errorf("stan natsUrl and clusterId must be specified")
+	}
+	if x := in.DB; x != nil && (x.DataSource == nil || x.DataSource.Value == "" && (x.DataSource.ValueFrom == nil || x.DataSource.ValueFrom.SecretKeyRef == nil)) {
+		return fmt.Errorf("db dataSource must be specified")
+	}
+	return nil
+}
+
+// GetSecretKeySelectors returns every secret key that the connection references.
+func (in ConnectionSpec) GetSecretKeySelectors() []corev1.SecretKeySelector {
+	var selectors []corev1.SecretKeySelector
+	add := func(xs ...*corev1.SecretKeySelector) {
+		for _, x := range xs {
+			if x != nil {
+				selectors = append(selectors, *x)
+			}
+		}
+	}
+	if x := in.Kafka; x != nil && x.NET != nil {
+		if t := x.NET.TLS; t != nil {
+			add(t.CACertSecret, t.CertSecret, t.KeySecret)
+		}
+		if s := x.NET.SASL; s != nil {
+			add(s.UserSecret, s.PasswordSecret)
+		}
+	}
+	if x := in.STAN; x != nil && x.Auth != nil {
+		add(x.Auth.Token)
+	}
+	if x := in.S3; x != nil && x.Credentials != nil {
+		add(&x.Credentials.AccessKeyID, &x.Credentials.SecretAccessKey)
+	}
+	if x := in.DB; x != nil && x.DataSource != nil && x.DataSource.ValueFrom != nil {
+		add(x.DataSource.ValueFrom.SecretKeyRef)
+	}
+	return selectors
+}
@@ -0,0 +1,67 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestConnectionSpec_Validate(t *testing.T) {
+	assert.Error(t, ConnectionSpec{}.Validate())
+	assert.Error(t, ConnectionSpec{Kafka: &KafkaConfig{Brokers: []string{"a"}}, S3: &S3Connection{}}.Validate())
+	assert.Error(t, ConnectionSpec{Kafka: &KafkaConfig{}}.Validate())
+	assert.NoError(t, ConnectionSpec{Kafka: &KafkaConfig{Brokers: []string{"a"}}}.Validate())
+	assert.Error(t, ConnectionSpec{STAN: &STANConnection{NATSURL: "nats"}}.Validate())
+	assert.NoError(t, ConnectionSpec{STAN: &STANConnection{NATSURL: "nats", ClusterID: "stan"}}.Validate())
+	assert.NoError(t, ConnectionSpec{S3: &S3Connection{}}.Validate())
+	assert.Error(t, ConnectionSpec{DB: &Database{}}.Validate())
+	assert.Error(t, ConnectionSpec{DB: &Database{DataSource: &DBDataSource{ValueFrom: &DBDataSourceFrom{}}}}.Validate())
+	assert.NoError(t, ConnectionSpec{DB: &Database{DataSource: &DBDataSource{Value: "my-ds"}}}.Validate())
+}
+
+func TestConnectionSpec_GetSecretKeySelectors(t *testing.T) {
+	assert.Empty(t, ConnectionSpec{Kafka: &KafkaConfig{}}.GetSecretKeySelectors())
+	x := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "my-secret"}, Key: "my-key"}
+	assert.Len(t, ConnectionSpec{Kafka: &KafkaConfig{NET: &KafkaNET{TLS: &TLS{CACertSecret: x}, SASL: &SASL{UserSecret: x, PasswordSecret: x}}}}.GetSecretKeySelectors(), 3)
+	assert.Len(t, ConnectionSpec{STAN: &STANConnection{Auth: &STANAuth{Token: x}}}.GetSecretKeySelectors(), 1)
+	assert.Len(t, ConnectionSpec{S3: &S3Connection{Credentials: &AWSCredentials{AccessKeyID: *x, SecretAccessKey: *x}}}.GetSecretKeySelectors(), 2)
+	assert.Equal(t, []corev1.SecretKeySelector{*x}, ConnectionSpec{DB: &Database{DataSource: &DBDataSource{ValueFrom: &DBDataSourceFrom{SecretKeyRef: x}}}}.GetSecretKeySelectors())
+}
+
+func TestApplyConnection(t *testing.T) {
+	t.Run("Kafka", func(t *testing.T) {
+		x := &Kafka{Name: "my-conn", KafkaConfig: KafkaConfig{Version: "1.0.0"}}
+		assert.Error(t, x.ApplyConnection(ConnectionSpec{}))
+		assert.NoError(t, x.ApplyConnection(ConnectionSpec{Kafka: &KafkaConfig{Brokers: []string{"a"}, Version: "2.0.0", NET: &KafkaNET{}}}))
+		assert.Equal(t, []string{"a"}, x.Brokers)
+		assert.Equal(t, "1.0.0", x.Version)
+		assert.NotNil(t, x.NET)
+	})
+	t.Run("STAN", func(t *testing.T) {
+		x := &STAN{Name: "my-conn", ClusterID: "my-stan", MaxInflight: CommitN}
+		assert.Error(t, x.ApplyConnection(ConnectionSpec{}))
+		assert.NoError(t, x.ApplyConnection(ConnectionSpec{STAN: &STANConnection{NATSURL: "nats", ClusterID: "stan", MaxInflight: 5}}))
+		assert.Equal(t, "nats", x.NATSURL)
+		assert.Equal(t, "my-stan", x.ClusterID)
+		assert.Equal(t, uint32(5), x.MaxInflight)
+	})
+	t.Run("S3", func(t *testing.T) {
+		x := &S3{Name: "my-conn"}
+		assert.Error(t, x.ApplyConnection(ConnectionSpec{}))
+		assert.NoError(t, x.ApplyConnection(ConnectionSpec{S3: &S3Connection{Region: "us-west-2", Endpoint: &AWSEndpoint{URL: "http://minio"}}}))
+		assert.Equal(t, "us-west-2", x.Region)
+		assert.Equal(t, "http://minio", x.Endpoint.URL)
+		assert.Nil(t, x.Credentials)
+	})
+	t.Run("DB", func(t *testing.T) {
+		c := ConnectionSpec{DB: &Database{Driver: "mysql", DataSource: &DBDataSource{Value: "my-ds"}}}
+		x := &DBSink{Name: "my-conn"}
+		assert.Error(t, x.ApplyConnection(ConnectionSpec{}))
+		assert.NoError(t, x.ApplyConnection(c))
+		assert.Equal(t, *c.DB, x.Database)
+		y := &DBSink{Name: "my-conn", Database: Database{Driver: "postgres", DataSource: &DBDataSource{Value: "other"}}}
+		assert.NoError(t, y.ApplyConnection(c))
+		assert.Equal(t, "other", y.DataSource.Value)
+	})
+}
@@ -0,0 +1,17 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ConnectionStatus struct {
+	// ObservedGeneration is the generation of the spec that the conditions are for
+	ObservedGeneration int64              `json:"observedGeneration,omitempty" protobuf:"varint,1,opt,name=observedGeneration"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty" protobuf:"bytes,2,rep,name=conditions"`
+}
+
+// IsInvalid returns true if the controller has found the current spec to be invalid
+func (in ConnectionStatus) IsInvalid(generation int64) bool {
+	return in.ObservedGeneration == generation && meta.IsStatusConditionFalse(in.Conditions, ConditionValid)
+}
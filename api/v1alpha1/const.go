@@ -9,35 +9,51 @@ import (
 
 const (
 	// conditions
-	ConditionCompleted    = "Completed"    // the pipeline completed
-	ConditionRunning      = "Running"      // added if any step is currently running
-	ConditionSunkMessages = "SunkMessages" // added if any messages have been written to a sink for any step
-	ConditionTerminating  = "Terminating"  // added if any terminator step terminated
+	ConditionCompleted       = "Completed"       // the pipeline completed
+	ConditionDegraded        = "Degraded"        // added if any step's error ratio is above the threshold
+	ConditionPolicyViolation = "PolicyViolation" // added to a step if it is limited by, or does not comply with, its namespace's policies
+	ConditionRunning         = "Running"         // added if any step is currently running
+	ConditionSunkMessages    = "SunkMessages"    // added if any messages have been written to a sink for any step
+	ConditionTerminating     = "Terminating"     // added if any terminator step terminated
+	ConditionValid           = "Valid"           // whether or not a connection's spec is valid
 	// container names
 	CtrInit    = "init"
 	CtrMain    = "main"
 	CtrSidecar = "sidecar"
 	// env vars
-	EnvClusterName    = "ARGO_DATAFLOW_CLUSTER_NAME"
-	EnvImagePrefix    = "ARGO_DATAFLOW_IMAGE_PREFIX"   // default "quay.io/argoproj"
-	EnvDeletionDelay  = "ARGO_DATAFLOW_DELETION_DELAY" // default "720h" ~= "30d"
-	EnvNamespace      = "ARGO_DATAFLOW_NAMESPACE"
-	EnvPipelineName   = "ARGO_DATAFLOW_PIPELINE_NAME"
-	EnvReplica        = "ARGO_DATAFLOW_REPLICA"
-	EnvStep           = "ARGO_DATAFLOW_STEP"
-	EnvPeekDelay      = "ARGO_DATAFLOW_PEEK_DELAY"      // how long between peeking (default 4m)
-	EnvPullPolicy     = "ARGO_DATAFLOW_PULL_POLICY"     // default ""
-	EnvScalingDelay   = "ARGO_DATAFLOW_SCALING_DELAY"   // how long to wait between any scaling events (including peeking) default "4m"
-	EnvUpdateInterval = "ARGO_DATAFLOW_UPDATE_INTERVAL" // default "1m"
+	EnvAggregateStepStatus = "ARGO_DATAFLOW_AGGREGATE_STEP_STATUS" // the controller gets the status from each sidecar, rather than sidecars patching it, default "false"
+	EnvClusterName         = "ARGO_DATAFLOW_CLUSTER_NAME"
+	EnvImagePrefix         = "ARGO_DATAFLOW_IMAGE_PREFIX"   // default "quay.io/argoproj"
+	EnvDeletionDelay       = "ARGO_DATAFLOW_DELETION_DELAY" // default "720h" ~= "30d"
+	EnvNamespace           = "ARGO_DATAFLOW_NAMESPACE"
+	EnvPipelineName        = "ARGO_DATAFLOW_PIPELINE_NAME"
+	EnvReplica             = "ARGO_DATAFLOW_REPLICA"
+	EnvStep                = "ARGO_DATAFLOW_STEP"
+	EnvPeekDelay           = "ARGO_DATAFLOW_PEEK_DELAY"      // how long between peeking (default 4m)
+	EnvPullPolicy          = "ARGO_DATAFLOW_PULL_POLICY"     // default ""
+	EnvScalingDelay        = "ARGO_DATAFLOW_SCALING_DELAY"   // how long to wait between any scaling events (including peeking) default "4m"
+	EnvUpdateInterval      = "ARGO_DATAFLOW_UPDATE_INTERVAL" // default "1m"
 	// label/annotation keys
+	KeyActivatedAt      = "dataflow.argoproj.io/activated-at"       // step annotation, when the activator scaled the step up from zero (RFC3339)
+	KeyChangeCause      = "kubernetes.io/change-cause"              // pipeline annotation, why the spec was changed, recorded with the revision
+	KeyCronPipelineName = "dataflow.argoproj.io/cron-pipeline-name" // label of pipelines created by a cron pipeline
 	KeyDefaultContainer = "kubectl.kubernetes.io/default-container"
+	KeyDeletionDelay    = "dataflow.argoproj.io/deletion-delay" // namespace annotation, overrides the controller's deletion delay
 	KeyDescription      = "dataflow.argoproj.io/description"
 	KeyFinalizer        = "dataflow.argoproj.io/finalizer"
+	KeyKilledHungAt     = "dataflow.argoproj.io/killed-hung-at" // pod annotation, when the controller killed its hung main container (RFC3339)
+	KeyMaxReplicas      = "dataflow.argoproj.io/max-replicas"   // namespace annotation, the most replicas any step may have
 	KeyOwner            = "dataflow.argoproj.io/owner"
 	KeyPipelineName     = "dataflow.argoproj.io/pipeline-name"
 	KeyReplica          = "dataflow.argoproj.io/replica"
-	KeyStepName         = "dataflow.argoproj.io/step-name" // the step name without pipeline name prefix
-	KeyHash             = "dataflow.argoproj.io/hash"      // hash of the object
+	KeyRevision         = "dataflow.argoproj.io/revision"      // step annotation, the revision of the pipeline's spec it runs
+	KeyRollbackTo       = "dataflow.argoproj.io/rollback-to"   // pipeline annotation, the revision to roll the spec back to
+	KeySkipCleanUp      = "dataflow.argoproj.io/skip-clean-up" // "true" to not delete consumer groups and durables when the pipeline is deleted
+	KeyStepName         = "dataflow.argoproj.io/step-name"     // the step name without pipeline name prefix
+	KeyHash             = "dataflow.argoproj.io/hash"          // hash of the object
+	// the key of the step's secret that has the authorization header needed to get a replica's status from its sidecar,
+	// the secret also has the authorization headers of its HTTP sources
+	KeyStatusAuthorization = "status.authorization"
 	// paths
 	PathAuthorization = "/var/run/argo-dataflow/authorization" // the authorization header which must be used by the main container to speak to the sidecar
 	PathCheckout      = "/var/run/argo-dataflow/checkout"
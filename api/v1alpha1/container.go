@@ -1,15 +1,34 @@
 package v1alpha1
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
 
 type Container struct {
-	Image        string                      `json:"image" protobuf:"bytes,1,opt,name=image"`
-	VolumeMounts []corev1.VolumeMount        `json:"volumeMounts,omitempty" protobuf:"bytes,5,rep,name=volumeMounts"`
-	In           *Interface                  `json:"in,omitempty" protobuf:"bytes,3,opt,name=in"`
-	Command      []string                    `json:"command,omitempty" protobuf:"bytes,6,rep,name=command"`
-	Args         []string                    `json:"args,omitempty" protobuf:"bytes,7,rep,name=args"`
-	Env          []corev1.EnvVar             `json:"env,omitempty" protobuf:"bytes,8,rep,name=env"`
-	Resources    corev1.ResourceRequirements `json:"resources,omitempty" protobuf:"bytes,9,opt,name=resources"`
+	Image          string                      `json:"image" protobuf:"bytes,1,opt,name=image"`
+	VolumeMounts   []corev1.VolumeMount        `json:"volumeMounts,omitempty" protobuf:"bytes,5,rep,name=volumeMounts"`
+	In             *Interface                  `json:"in,omitempty" protobuf:"bytes,3,opt,name=in"`
+	Command        []string                    `json:"command,omitempty" protobuf:"bytes,6,rep,name=command"`
+	Args           []string                    `json:"args,omitempty" protobuf:"bytes,7,rep,name=args"`
+	Env            []corev1.EnvVar             `json:"env,omitempty" protobuf:"bytes,8,rep,name=env"`
+	EnvFrom        []corev1.EnvFromSource      `json:"envFrom,omitempty" protobuf:"bytes,10,rep,name=envFrom"`
+	Resources      corev1.ResourceRequirements `json:"resources,omitempty" protobuf:"bytes,9,opt,name=resources"`
+	Ports          []corev1.ContainerPort      `json:"ports,omitempty" protobuf:"bytes,11,rep,name=ports"`
+	LivenessProbe  *corev1.Probe               `json:"livenessProbe,omitempty" protobuf:"bytes,12,opt,name=livenessProbe"`
+	ReadinessProbe *corev1.Probe               `json:"readinessProbe,omitempty" protobuf:"bytes,13,opt,name=readinessProbe"`
+	StartupProbe   *corev1.Probe               `json:"startupProbe,omitempty" protobuf:"bytes,14,opt,name=startupProbe"`
+	// PostStart is run after the container is created. The pre-stop hook is reserved for Dataflow.
+	PostStart *corev1.Handler `json:"postStart,omitempty" protobuf:"bytes,15,opt,name=postStart"`
+	// Additional containers to run alongside the main container, e.g. a local cache or proxy.
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	Sidecars []corev1.Container `json:"sidecars,omitempty" protobuf:"bytes,16,rep,name=sidecars"`
+	// Additional init containers, these are run after Dataflow's own init container.
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	InitContainers []corev1.Container `json:"initContainers,omitempty" protobuf:"bytes,17,rep,name=initContainers"`
 }
 
 func (in Container) getContainer(req getContainerReq) corev1.Container {
@@ -19,8 +38,12 @@ func (in Container) getContainer(req getContainerReq) corev1.Container {
 		command(in.Command...).
 		args(in.Args...).
 		appendEnv(in.Env...).
+		appendEnvFrom(in.EnvFrom...).
 		appendVolumeMounts(in.VolumeMounts...).
 		resources(in.Resources).
+		appendPorts(in.Ports...).
+		probes(in.LivenessProbe, in.ReadinessProbe, in.StartupProbe).
+		postStart(in.PostStart).
 		build()
 }
 
@@ -30,3 +53,21 @@ func (in Container) GetIn() *Interface {
 	}
 	return DefaultInterface
 }
+
+// getAdditionalContainers returns the init containers and sidecars in a new slice, so it does not write into either
+func (in Container) getAdditionalContainers() []corev1.Container {
+	x := make([]corev1.Container, 0, len(in.InitContainers)+len(in.Sidecars))
+	return append(append(x, in.InitContainers...), in.Sidecars...)
+}
+
+// validate returns an error if any of the additional containers use a reserved name
+func (in Container) validate() error {
+	names := map[string]bool{CtrInit: true, CtrMain: true, CtrSidecar: true}
+	for _, c := range in.getAdditionalContainers() {
+		if names[c.Name] {
+			return fmt.Errorf("container name %q is reserved or duplicated", c.Name)
+		}
+		names[c.Name] = true
+	}
+	return nil
+}
@@ -37,6 +37,11 @@ func (b containerBuilder) appendEnv(x ...corev1.EnvVar) containerBuilder {
 	return b
 }
 
+func (b containerBuilder) appendEnvFrom(x ...corev1.EnvFromSource) containerBuilder {
+	b.EnvFrom = append(b.EnvFrom, x...)
+	return b
+}
+
 func (b containerBuilder) workingDir(x string) containerBuilder {
 	b.WorkingDir = x
 	return b
@@ -61,6 +66,33 @@ func (b containerBuilder) port(n int32) containerBuilder {
 	return b
 }
 
+func (b containerBuilder) appendPorts(x ...corev1.ContainerPort) containerBuilder {
+	b.Ports = append(b.Ports, x...)
+	return b
+}
+
+func (b containerBuilder) probes(liveness, readiness, startup *corev1.Probe) containerBuilder {
+	b.LivenessProbe = liveness
+	b.ReadinessProbe = readiness
+	b.StartupProbe = startup
+	return b
+}
+
+// postStart sets the post-start hook, leaving the reserved pre-stop hook in place
+func (b containerBuilder) postStart(x *corev1.Handler) containerBuilder {
+	if x == nil {
+		return b
+	}
+	if b.Lifecycle != nil {
+		l := *b.Lifecycle
+		b.Lifecycle = &l
+	} else {
+		b.Lifecycle = &corev1.Lifecycle{}
+	}
+	b.Lifecycle.PostStart = x
+	return b
+}
+
 func (b containerBuilder) build() corev1.Container {
 	return corev1.Container(b)
 }
@@ -29,4 +29,45 @@ func TestContainer_getContainer(t *testing.T) {
 	assert.Equal(t, x.Args, c.Args)
 	assert.Equal(t, x.Env, c.Env)
 	assert.Equal(t, corev1.ResourceRequirements{Requests: map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("2")}}, c.Resources)
+	t.Run("Richer", func(t *testing.T) {
+		probe := &corev1.Probe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"}}}
+		postStart := &corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"my-post-start"}}}
+		preStop := &corev1.Handler{Exec: &corev1.ExecAction{Command: []string{PathPreStop}}}
+		x := Container{
+			Image:          "my-image",
+			EnvFrom:        []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "my-cm"}}}},
+			Ports:          []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9090}},
+			LivenessProbe:  probe,
+			ReadinessProbe: probe,
+			StartupProbe:   probe,
+			PostStart:      postStart,
+		}
+		c := x.getContainer(getContainerReq{lifecycle: &corev1.Lifecycle{PreStop: preStop}})
+		assert.Equal(t, x.EnvFrom, c.EnvFrom)
+		assert.Equal(t, x.Ports, c.Ports)
+		assert.Equal(t, probe, c.LivenessProbe)
+		assert.Equal(t, probe, c.ReadinessProbe)
+		assert.Equal(t, probe, c.StartupProbe)
+		if assert.NotNil(t, c.Lifecycle) {
+			assert.Equal(t, postStart, c.Lifecycle.PostStart)
+			assert.Equal(t, preStop, c.Lifecycle.PreStop)
+		}
+	})
+}
+
+func TestContainer_validate(t *testing.T) {
+	assert.NoError(t, Container{Sidecars: []corev1.Container{{Name: "my-cache"}}, InitContainers: []corev1.Container{{Name: "my-init"}}}.validate())
+	for _, name := range []string{CtrInit, CtrMain, CtrSidecar} {
+		assert.Error(t, Container{Sidecars: []corev1.Container{{Name: name}}}.validate())
+		assert.Error(t, Container{InitContainers: []corev1.Container{{Name: name}}}.validate())
+	}
+	assert.Error(t, Container{Sidecars: []corev1.Container{{Name: "my-cache"}, {Name: "my-cache"}}}.validate())
+}
+
+func TestContainer_getAdditionalContainers(t *testing.T) {
+	initContainers := make([]corev1.Container, 1, 2) // spare capacity, so a naive append would write into it
+	initContainers[0] = corev1.Container{Name: "my-init"}
+	x := Container{InitContainers: initContainers, Sidecars: []corev1.Container{{Name: "my-cache"}}}
+	assert.Equal(t, []corev1.Container{{Name: "my-init"}, {Name: "my-cache"}}, x.getAdditionalContainers())
+	assert.Equal(t, corev1.Container{}, initContainers[:2][1], "the init containers' backing array is not written")
 }
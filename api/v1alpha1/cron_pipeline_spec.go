@@ -0,0 +1,54 @@
+package v1alpha1
+
+// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+type ConcurrencyPolicy string
+
+const (
+	ConcurrencyAllow   ConcurrencyPolicy = "Allow"   // create pipelines even if earlier ones are still running
+	ConcurrencyForbid  ConcurrencyPolicy = "Forbid"  // skip the run if an earlier pipeline is still running
+	ConcurrencyReplace ConcurrencyPolicy = "Replace" // delete the running pipelines, and create a new one
+)
+
+type CronPipelineSpec struct {
+	// Schedule is a cron expression, with optional seconds, or a descriptor such as "@daily" or "@every 1h". Prefix
+	// with "CRON_TZ=${timezone} " to use a timezone other than the controller's.
+	Schedule string `json:"schedule" protobuf:"bytes,1,opt,name=schedule"`
+	// +kubebuilder:default=Allow
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty" protobuf:"bytes,2,opt,name=concurrencyPolicy,casttype=ConcurrencyPolicy"`
+	// Suspend stops new pipelines being created, it does not affect pipelines that have already been created.
+	Suspend bool `json:"suspend,omitempty" protobuf:"varint,3,opt,name=suspend"`
+	// SuccessfulPipelinesHistoryLimit is how many succeeded pipelines to keep.
+	// +kubebuilder:default=3
+	SuccessfulPipelinesHistoryLimit *uint32 `json:"successfulPipelinesHistoryLimit,omitempty" protobuf:"varint,4,opt,name=successfulPipelinesHistoryLimit"`
+	// FailedPipelinesHistoryLimit is how many failed pipelines to keep.
+	// +kubebuilder:default=1
+	FailedPipelinesHistoryLimit *uint32 `json:"failedPipelinesHistoryLimit,omitempty" protobuf:"varint,5,opt,name=failedPipelinesHistoryLimit"`
+	// Template is the pipeline to create. Its spec may use a `templateRef`.
+	Template CronPipelineTemplate `json:"template" protobuf:"bytes,6,opt,name=template"`
+}
+
+func (in CronPipelineSpec) GetConcurrencyPolicy() ConcurrencyPolicy {
+	if in.ConcurrencyPolicy == "" {
+		return ConcurrencyAllow
+	}
+	return in.ConcurrencyPolicy
+}
+
+func (in CronPipelineSpec) GetSuccessfulPipelinesHistoryLimit() int {
+	if in.SuccessfulPipelinesHistoryLimit == nil {
+		return 3
+	}
+	return int(*in.SuccessfulPipelinesHistoryLimit)
+}
+
+func (in CronPipelineSpec) GetFailedPipelinesHistoryLimit() int {
+	if in.FailedPipelinesHistoryLimit == nil {
+		return 1
+	}
+	return int(*in.FailedPipelinesHistoryLimit)
+}
+
+type CronPipelineTemplate struct {
+	Metadata *Metadata    `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Spec     PipelineSpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+}
@@ -0,0 +1,23 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronPipelineSpec(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		x := CronPipelineSpec{}
+		assert.Equal(t, ConcurrencyAllow, x.GetConcurrencyPolicy())
+		assert.Equal(t, 3, x.GetSuccessfulPipelinesHistoryLimit())
+		assert.Equal(t, 1, x.GetFailedPipelinesHistoryLimit())
+	})
+	t.Run("Specified", func(t *testing.T) {
+		zero := uint32(0)
+		x := CronPipelineSpec{ConcurrencyPolicy: ConcurrencyForbid, SuccessfulPipelinesHistoryLimit: &zero, FailedPipelinesHistoryLimit: &zero}
+		assert.Equal(t, ConcurrencyForbid, x.GetConcurrencyPolicy())
+		assert.Equal(t, 0, x.GetSuccessfulPipelinesHistoryLimit())
+		assert.Equal(t, 0, x.GetFailedPipelinesHistoryLimit())
+	})
+}
@@ -0,0 +1,16 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type CronPipelineStatus struct {
+	// Active are the names of the pipelines that have not yet completed.
+	Active []string `json:"active,omitempty" protobuf:"bytes,1,rep,name=active"`
+	// LastScheduleTime is the last time a pipeline was scheduled, even if it was skipped.
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty" protobuf:"bytes,2,opt,name=lastScheduleTime"`
+	// LastSuccessfulTime is the last time a pipeline succeeded.
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty" protobuf:"bytes,3,opt,name=lastSuccessfulTime"`
+	// Message is set if the schedule is invalid.
+	Message string `json:"message,omitempty" protobuf:"bytes,4,opt,name=message"`
+}
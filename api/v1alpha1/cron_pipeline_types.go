@@ -0,0 +1,33 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CronPipeline creates pipelines on a schedule, e.g. for nightly batch jobs.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=cpl
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`
+type CronPipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	Spec   CronPipelineSpec   `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+	Status CronPipelineStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// +kubebuilder:object:root=true
+
+type CronPipelineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Items           []CronPipeline `json:"items" protobuf:"bytes,2,rep,name=items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CronPipeline{}, &CronPipelineList{})
+}
@@ -0,0 +1,87 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DataflowPolicySpec struct {
+	// MaxReplicas is the most replicas any step may have, steps with more are limited to this.
+	MaxReplicas *uint32 `json:"maxReplicas,omitempty" protobuf:"varint,1,opt,name=maxReplicas"`
+	// MaxPods is the most pods all the steps in the namespace may have together, steps are not scaled up beyond this.
+	MaxPods *uint32 `json:"maxPods,omitempty" protobuf:"varint,2,opt,name=maxPods"`
+	// AllowedImages are the prefixes of the images that container and Git steps may use, e.g. "quay.io/my-org/".
+	// If empty, any image is allowed.
+	AllowedImages []string `json:"allowedImages,omitempty" protobuf:"bytes,3,rep,name=allowedImages"`
+	// AllowedSources are the types of sources steps may use, e.g. "kafka". If empty, any type is allowed.
+	AllowedSources []string `json:"allowedSources,omitempty" protobuf:"bytes,4,rep,name=allowedSources"`
+	// AllowedSinks are the types of sinks steps may use, e.g. "log". If empty, any type is allowed.
+	AllowedSinks []string `json:"allowedSinks,omitempty" protobuf:"bytes,5,rep,name=allowedSinks"`
+}
+
+// GetViolations returns a message for each way the step does not comply with the policy
+func (in DataflowPolicySpec) GetViolations(step StepSpec) []string {
+	var violations []string
+	if len(in.AllowedImages) > 0 {
+		for _, image := range step.getImages() {
+			if !hasAnyPrefix(image, in.AllowedImages) {
+				violations = append(violations, fmt.Sprintf("image %q is not allowed", image))
+			}
+		}
+	}
+	if len(in.AllowedSources) > 0 {
+		for _, s := range step.Sources {
+			if t := s.getType(); !stringsContain(in.AllowedSources, t) {
+				violations = append(violations, fmt.Sprintf("source %q type %q is not allowed", s.Name, t))
+			}
+		}
+	}
+	if len(in.AllowedSinks) > 0 {
+		for _, s := range step.Sinks {
+			if t := s.getType(); !stringsContain(in.AllowedSinks, t) {
+				violations = append(violations, fmt.Sprintf("sink %q type %q is not allowed", s.Name, t))
+			}
+		}
+	}
+	return violations
+}
+
+// getImages returns the images chosen by the user, rather than the images of the runner and code runtimes.
+// This includes any image set by the pod template, as that may replace the image of any container.
+func (in StepSpec) getImages() []string {
+	var images []string
+	if x := in.Container; x != nil {
+		images = append(images, x.Image)
+		for _, c := range x.getAdditionalContainers() {
+			images = append(images, c.Image)
+		}
+	} else if x := in.Git; x != nil {
+		images = append(images, x.Image)
+	}
+	if x := in.PodTemplate; x != nil {
+		for _, c := range append(x.InitContainers, x.Containers...) {
+			if c.Image != "" {
+				images = append(images, c.Image)
+			}
+		}
+	}
+	return images
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func stringsContain(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
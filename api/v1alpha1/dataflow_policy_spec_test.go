@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDataflowPolicySpec_GetViolations(t *testing.T) {
+	step := StepSpec{
+		Container: &Container{Image: "quay.io/my-org/main", Sidecars: []corev1.Container{{Name: "proxy", Image: "docker.io/proxy"}}},
+		Sources:   []Source{{Name: "default", Kafka: &KafkaSource{}}},
+		Sinks:     []Sink{{Name: "default", Log: &Log{}}, {Name: "http", HTTP: &HTTPSink{}}},
+	}
+	t.Run("Empty", func(t *testing.T) {
+		assert.Empty(t, DataflowPolicySpec{}.GetViolations(step))
+	})
+	t.Run("Allowed", func(t *testing.T) {
+		assert.Empty(t, DataflowPolicySpec{
+			AllowedImages:  []string{"quay.io/my-org/", "docker.io/"},
+			AllowedSources: []string{"kafka"},
+			AllowedSinks:   []string{"log", "http"},
+		}.GetViolations(step))
+	})
+	t.Run("NotAllowed", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`image "docker.io/proxy" is not allowed`,
+			`source "default" type "kafka" is not allowed`,
+			`sink "http" type "http" is not allowed`,
+		}, DataflowPolicySpec{
+			AllowedImages:  []string{"quay.io/my-org/"},
+			AllowedSources: []string{"stan"},
+			AllowedSinks:   []string{"log"},
+		}.GetViolations(step))
+	})
+	t.Run("Git", func(t *testing.T) {
+		assert.Len(t, DataflowPolicySpec{AllowedImages: []string{"quay.io/my-org/"}}.GetViolations(StepSpec{Git: &Git{Image: "golang:1.16"}}), 1)
+	})
+	t.Run("PodTemplate", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`image "docker.io/init" is not allowed`,
+			`image "docker.io/evil" is not allowed`,
+		}, DataflowPolicySpec{AllowedImages: []string{"quay.io/my-org/"}}.GetViolations(StepSpec{
+			Container: &Container{Image: "quay.io/my-org/main"},
+			PodTemplate: &PodTemplate{
+				InitContainers: []corev1.Container{{Name: CtrInit, Image: "docker.io/init"}},
+				Containers:     []corev1.Container{{Name: CtrMain, Image: "docker.io/evil"}, {Name: CtrSidecar}},
+			},
+		}))
+	})
+	t.Run("Code", func(t *testing.T) {
+		assert.Empty(t, DataflowPolicySpec{AllowedImages: []string{"quay.io/my-org/"}}.GetViolations(StepSpec{Code: &Code{Runtime: "go1-16"}}), "runtime images are chosen by the controller")
+	})
+}
@@ -0,0 +1,29 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DataflowPolicy limits the pipelines in its namespace. If a namespace has more than one policy, all of them apply.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=dfpol
+// +kubebuilder:printcolumn:name="Max Replicas",type=integer,JSONPath=`.spec.maxReplicas`
+// +kubebuilder:printcolumn:name="Max Pods",type=integer,JSONPath=`.spec.maxPods`
+type DataflowPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	Spec DataflowPolicySpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+}
+
+// +kubebuilder:object:root=true
+
+type DataflowPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Items           []DataflowPolicy `json:"items" protobuf:"bytes,2,rep,name=items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DataflowPolicy{}, &DataflowPolicyList{})
+}
@@ -1,6 +1,22 @@
 package v1alpha1
 
+import "fmt"
+
 type DBSink struct {
 	Database `json:",inline" protobuf:"bytes,1,opt,name=database"`
 	Actions  []SQLAction `json:"actions,omitempty" protobuf:"bytes,2,rep,name=actions"`
+	// Name is the name of a connection to use, if the data source is not specified.
+	Name string `json:"name,omitempty" protobuf:"bytes,3,opt,name=name"`
+}
+
+// ApplyConnection uses the connection's driver and data source, unless the data source is specified.
+func (in *DBSink) ApplyConnection(c ConnectionSpec) error {
+	x := c.DB
+	if x == nil {
+		return fmt.Errorf("connection %q is not a DB connection", in.Name)
+	}
+	if in.DataSource == nil {
+		in.Database = *x.DeepCopy()
+	}
+	return nil
 }
@@ -0,0 +1,92 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Dependency is another pipeline in the same namespace that must reach a phase, or condition, before this pipeline
+// starts.
+type Dependency struct {
+	Pipeline string `json:"pipeline" protobuf:"bytes,1,opt,name=pipeline"`
+	// Phase the pipeline must reach, by default "Succeeded", unless a condition is specified
+	Phase PipelinePhase `json:"phase,omitempty" protobuf:"bytes,2,opt,name=phase,casttype=PipelinePhase"`
+	// Condition that must be true, e.g. "SunkMessages" or "Completed"
+	Condition string `json:"condition,omitempty" protobuf:"bytes,3,opt,name=condition"`
+}
+
+func (in Dependency) GetPhase() PipelinePhase {
+	if in.Phase == "" && in.Condition == "" {
+		return PipelineSucceeded
+	}
+	return in.Phase
+}
+
+// IsMet returns true if the pipeline has reached the phase, and the condition is true
+func (in Dependency) IsMet(pl Pipeline) bool {
+	if x := in.GetPhase(); x != "" && pl.Status.Phase != x {
+		return false
+	}
+	return in.Condition == "" || meta.IsStatusConditionTrue(pl.Status.Conditions, in.Condition)
+}
+
+func (in Dependency) String() string {
+	if in.Condition == "" {
+		return fmt.Sprintf("%s (%s)", in.Pipeline, in.GetPhase())
+	}
+	if in.Phase == "" {
+		return fmt.Sprintf("%s (%s)", in.Pipeline, in.Condition)
+	}
+	return fmt.Sprintf("%s (%s, %s)", in.Pipeline, in.Phase, in.Condition)
+}
+
+// Trigger configures how the pipeline is started once its dependencies are met.
+type Trigger struct {
+	// Parameters are passed to the pipeline's template as arguments, and take precedence over the template ref's
+	// arguments
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	Parameters []TriggerParameter `json:"parameters,omitempty" protobuf:"bytes,1,rep,name=parameters"`
+}
+
+// TriggerParameter is a value from one of the pipelines this pipeline depends on.
+type TriggerParameter struct {
+	// Name of the template parameter
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Pipeline is the dependency to get the value from
+	Pipeline string `json:"pipeline" protobuf:"bytes,2,opt,name=pipeline"`
+	// JSONPath of the value within the pipeline, e.g. "{.status.message}" or "{.metadata.annotations.output-prefix}"
+	JSONPath string `json:"jsonPath" protobuf:"bytes,3,opt,name=jsonPath"`
+}
+
+// TriggerStatus records when the pipeline's dependencies were met.
+type TriggerStatus struct {
+	TriggeredAt metav1.Time `json:"triggeredAt,omitempty" protobuf:"bytes,1,opt,name=triggeredAt"`
+	// Arguments are the values of the trigger's parameters
+	Arguments map[string]string `json:"arguments,omitempty" protobuf:"bytes,2,rep,name=arguments"`
+}
+
+// ValidateDependencies returns an error if the dependencies or trigger are misconfigured
+func (in PipelineSpec) ValidateDependencies() error {
+	dependencies := map[string]bool{}
+	for _, d := range in.DependsOn {
+		if d.Pipeline == "" {
+			return fmt.Errorf("dependency must have a pipeline")
+		}
+		dependencies[d.Pipeline] = true
+	}
+	if in.Trigger == nil {
+		return nil
+	}
+	if len(in.Trigger.Parameters) > 0 && in.TemplateRef == nil {
+		return fmt.Errorf("trigger parameters are passed to the template, so a templateRef is required")
+	}
+	for _, p := range in.Trigger.Parameters {
+		if !dependencies[p.Pipeline] {
+			return fmt.Errorf("trigger parameter %q is from %q, which is not a dependency", p.Name, p.Pipeline)
+		}
+	}
+	return nil
+}
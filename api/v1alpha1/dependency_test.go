@@ -0,0 +1,41 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDependency(t *testing.T) {
+	succeeded := Pipeline{Status: PipelineStatus{Phase: PipelineSucceeded}}
+	sunk := Pipeline{Status: PipelineStatus{Phase: PipelineRunning, Conditions: []metav1.Condition{{Type: ConditionSunkMessages, Status: metav1.ConditionTrue}}}}
+	t.Run("Default", func(t *testing.T) {
+		d := Dependency{Pipeline: "a"}
+		assert.Equal(t, PipelineSucceeded, d.GetPhase())
+		assert.True(t, d.IsMet(succeeded))
+		assert.False(t, d.IsMet(sunk))
+		assert.Equal(t, "a (Succeeded)", d.String())
+	})
+	t.Run("Condition", func(t *testing.T) {
+		d := Dependency{Pipeline: "a", Condition: ConditionSunkMessages}
+		assert.Empty(t, d.GetPhase())
+		assert.False(t, d.IsMet(succeeded))
+		assert.True(t, d.IsMet(sunk))
+		assert.Equal(t, "a (SunkMessages)", d.String())
+	})
+	t.Run("PhaseAndCondition", func(t *testing.T) {
+		d := Dependency{Pipeline: "a", Phase: PipelineRunning, Condition: ConditionSunkMessages}
+		assert.True(t, d.IsMet(sunk))
+		assert.Equal(t, "a (Running, SunkMessages)", d.String())
+	})
+}
+
+func TestPipelineSpec_ValidateDependencies(t *testing.T) {
+	assert.NoError(t, PipelineSpec{}.ValidateDependencies())
+	assert.EqualError(t, PipelineSpec{DependsOn: []Dependency{{}}}.ValidateDependencies(), "dependency must have a pipeline")
+	trigger := &Trigger{Parameters: []TriggerParameter{{Name: "prefix", Pipeline: "a", JSONPath: "{.status.message}"}}}
+	assert.EqualError(t, PipelineSpec{DependsOn: []Dependency{{Pipeline: "a"}}, Trigger: trigger}.ValidateDependencies(), "trigger parameters are passed to the template, so a templateRef is required")
+	assert.EqualError(t, PipelineSpec{TemplateRef: &TemplateRef{}, Trigger: trigger}.ValidateDependencies(), `trigger parameter "prefix" is from "a", which is not a dependency`)
+	assert.NoError(t, PipelineSpec{DependsOn: []Dependency{{Pipeline: "a"}}, TemplateRef: &TemplateRef{}, Trigger: trigger}.ValidateDependencies())
+}
@@ -0,0 +1,102 @@
+package v1alpha1
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Edge is a flow of messages from a sink of one step to a source of another, inferred from them sharing an endpoint
+// (e.g. the same Kafka topic).
+type Edge struct {
+	From   string `json:"from" protobuf:"bytes,1,opt,name=from"`
+	Sink   string `json:"sink" protobuf:"bytes,2,opt,name=sink"`
+	To     string `json:"to" protobuf:"bytes,3,opt,name=to"`
+	Source string `json:"source" protobuf:"bytes,4,opt,name=source"`
+	// Rate is the current rate of messages per second that the source is receiving
+	Rate resource.Quantity `json:"rate,omitempty" protobuf:"bytes,5,opt,name=rate"`
+	// Pending is the number of messages waiting to be received by the source, if known
+	Pending *uint64 `json:"pending,omitempty" protobuf:"varint,6,opt,name=pending"`
+}
+
+// GetEdges returns the edges between the steps, without their metrics. Only configuration in the spec is considered,
+// so sources and sinks configured by secret may not be matched.
+func (in PipelineSpec) GetEdges(namespace, pipelineName string) []Edge {
+	type endpoint struct{ step, name string }
+	sinks := map[string][]endpoint{}
+	for _, step := range in.Steps {
+		for _, s := range step.Sinks {
+			if k := s.endpoint(namespace, pipelineName); k != "" {
+				sinks[k] = append(sinks[k], endpoint{step.Name, s.Name})
+			}
+		}
+	}
+	var edges []Edge
+	for _, step := range in.Steps {
+		for _, s := range step.Sources {
+			for _, from := range sinks[s.endpoint(namespace, pipelineName, step.Name)] {
+				edges = append(edges, Edge{From: from.step, Sink: from.name, To: step.Name, Source: s.Name})
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Sink != b.Sink {
+			return a.Sink < b.Sink
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Source < b.Source
+	})
+	return edges
+}
+
+// endpoint returns a key that is the same as the endpoint of the sources it sends to, or empty if it has none
+func (in Sink) endpoint(namespace, pipelineName string) string {
+	if x := in.STAN; x != nil {
+		return stanEndpoint(*x, namespace, pipelineName)
+	} else if x := in.Kafka; x != nil {
+		return "kafka/" + x.Name + "/" + x.Topic
+	} else if x := in.S3; x != nil {
+		return "s3/" + x.Name + "/" + x.Bucket
+	} else if x := in.HTTP; x != nil {
+		u, err := url.Parse(x.URL)
+		if err != nil || !strings.HasPrefix(u.Path, "/sources/") {
+			return ""
+		}
+		// the host may be the service's short name, or its fully qualified one
+		return "http/" + strings.Split(u.Hostname(), ".")[0] + "/" + strings.TrimPrefix(u.Path, "/sources/")
+	}
+	return ""
+}
+
+// endpoint returns a key that is the same as the endpoint of the sinks that send to it, or empty if it has none
+func (in Source) endpoint(namespace, pipelineName, stepName string) string {
+	if x := in.STAN; x != nil {
+		return stanEndpoint(*x, namespace, pipelineName)
+	} else if x := in.Kafka; x != nil {
+		return "kafka/" + x.Name + "/" + x.Topic
+	} else if x := in.S3; x != nil {
+		return "s3/" + x.Name + "/" + x.Bucket
+	} else if x := in.HTTP; x != nil {
+		return "http/" + StringOr(x.ServiceName, pipelineName+"-"+stepName) + "/" + in.Name
+	}
+	return ""
+}
+
+func stanEndpoint(x STAN, namespace, pipelineName string) string {
+	subject := x.Subject
+	switch x.SubjectPrefix {
+	case SubjectPrefixNamespaceName:
+		subject = namespace + "." + subject
+	case SubjectPrefixNamespacedPipelineName:
+		subject = namespace + "." + pipelineName + "." + subject
+	}
+	return "stan/" + x.Name + "/" + subject
+}
@@ -0,0 +1,43 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineSpec_GetEdges(t *testing.T) {
+	spec := PipelineSpec{Steps: []StepSpec{
+		{
+			Name: "a",
+			Sinks: []Sink{
+				{Name: "stan", STAN: &STAN{Name: "default", Subject: "x", SubjectPrefix: SubjectPrefixNamespacedPipelineName}},
+				{Name: "kafka", Kafka: &Kafka{Name: "default", Topic: "y"}},
+				{Name: "http", HTTP: &HTTPSink{URL: "https://my-pl-b.my-ns.svc.cluster.local/sources/in"}},
+				{Name: "log", Log: &Log{}},
+			},
+		},
+		{
+			Name: "b",
+			Sources: []Source{
+				{Name: "stan", STAN: &STAN{Name: "default", Subject: "my-ns.my-pl.x"}},
+				{Name: "in", HTTP: &HTTPSource{}},
+			},
+			Sinks: []Sink{{Name: "s3", S3: &S3Sink{S3: S3{Name: "default", Bucket: "z"}}}},
+		},
+		{
+			Name: "c",
+			Sources: []Source{
+				{Name: "kafka", Kafka: &KafkaSource{Kafka: Kafka{Name: "default", Topic: "y"}}},
+				{Name: "other-kafka", Kafka: &KafkaSource{Kafka: Kafka{Name: "other", Topic: "y"}}},
+				{Name: "s3", S3: &S3Source{S3: S3{Name: "default", Bucket: "z"}}},
+			},
+		},
+	}}
+	assert.Equal(t, []Edge{
+		{From: "a", Sink: "http", To: "b", Source: "in"},
+		{From: "a", Sink: "kafka", To: "c", Source: "kafka"},
+		{From: "a", Sink: "stan", To: "b", Source: "stan"},
+		{From: "b", Sink: "s3", To: "c", Source: "s3"},
+	}, spec.GetEdges("my-ns", "my-pl"))
+}
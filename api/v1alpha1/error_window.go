@@ -0,0 +1,59 @@
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrorWindowDuration is how long each window of a source or sink's messages and errors is.
+const ErrorWindowDuration = 5 * time.Minute
+
+// ErrorWindow is a source or sink's messages and errors over the last complete window, rather than its lifetime, so that
+// a spike of errors is seen, and a source or sink that has recovered is not.
+type ErrorWindow struct {
+	// StartedAt is when the current window started, and Total and Errors are the counts then.
+	StartedAt metav1.Time `json:"startedAt" protobuf:"bytes,1,opt,name=startedAt"`
+	Total     uint64      `json:"total,omitempty" protobuf:"varint,2,opt,name=total"`
+	Errors    uint64      `json:"errors,omitempty" protobuf:"varint,3,opt,name=errors"`
+	// LastTotal and LastErrors are how many messages and errors there were during the last complete window.
+	LastTotal  uint64 `json:"lastTotal,omitempty" protobuf:"varint,4,opt,name=lastTotal"`
+	LastErrors uint64 `json:"lastErrors,omitempty" protobuf:"varint,5,opt,name=lastErrors"`
+}
+
+// GetErrorRatio returns the ratio of errors to messages during the last complete window.
+func (in ErrorWindow) GetErrorRatio() float64 {
+	if in.LastTotal > 0 {
+		return float64(in.LastErrors) / float64(in.LastTotal)
+	}
+	return 0
+}
+
+type ErrorWindows map[string]ErrorWindow // key is source or sink name
+
+// Roll returns the windows of the sources or sinks, starting a new window for any whose current window has ended.
+// Counts are reset when a replica restarts, in which case the window's counts are those since the reset.
+func (in ErrorWindows) Roll(statuses SourceStatuses, now metav1.Time) ErrorWindows {
+	if len(statuses) == 0 {
+		return nil
+	}
+	out := ErrorWindows{}
+	for name, s := range statuses {
+		total, errors := s.GetTotal(), s.GetErrors()
+		x, ok := in[name]
+		if !ok {
+			x = ErrorWindow{StartedAt: now, Total: total, Errors: errors}
+		} else if now.Sub(x.StartedAt.Time) >= ErrorWindowDuration {
+			x = ErrorWindow{StartedAt: now, Total: total, Errors: errors, LastTotal: delta(total, x.Total), LastErrors: delta(errors, x.Errors)}
+		}
+		out[name] = x
+	}
+	return out
+}
+
+func delta(now, then uint64) uint64 {
+	if now < then {
+		return now
+	}
+	return now - then
+}
@@ -0,0 +1,31 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestErrorWindows_Roll(t *testing.T) {
+	status := func(total, errors uint64) SourceStatuses {
+		return SourceStatuses{"out": {Metrics: map[string]Metrics{"0": {Total: total, Errors: errors}}}}
+	}
+	t0 := metav1.Now()
+	var w ErrorWindows
+	assert.Nil(t, w.Roll(nil, t0))
+	w = w.Roll(status(100, 50), t0)
+	assert.Zero(t, w["out"].GetErrorRatio(), "errors before the first window are not counted")
+	w = w.Roll(status(110, 55), metav1.NewTime(t0.Add(time.Minute)))
+	assert.Zero(t, w["out"].GetErrorRatio(), "the window is not complete")
+	t1 := metav1.NewTime(t0.Add(ErrorWindowDuration))
+	w = w.Roll(status(120, 60), t1)
+	assert.Equal(t, 0.5, w["out"].GetErrorRatio())
+	assert.Equal(t, t1, w["out"].StartedAt)
+	w = w.Roll(status(130, 60), metav1.NewTime(t1.Add(ErrorWindowDuration)))
+	assert.Zero(t, w["out"].GetErrorRatio(), "recovered")
+	w = w.Roll(status(10, 5), metav1.NewTime(t1.Add(2*ErrorWindowDuration)))
+	assert.Equal(t, 0.5, w["out"].GetErrorRatio(), "counts reset")
+	assert.Nil(t, w.Roll(nil, t1), "removed")
+}
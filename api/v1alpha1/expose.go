@@ -0,0 +1,73 @@
+package v1alpha1
+
+import (
+	"fmt"
+)
+
+// +kubebuilder:validation:Enum=Ingress;HTTPRoute
+type ExposeKind string
+
+const (
+	ExposeIngress   ExposeKind = "Ingress"
+	ExposeHTTPRoute ExposeKind = "HTTPRoute" // a Gateway API HTTP route
+)
+
+type ExposeTLS struct {
+	// SecretName is the name of the secret containing the certificate, only used for ingresses, gateways have their own
+	// TLS configuration.
+	SecretName string `json:"secretName,omitempty" protobuf:"bytes,1,opt,name=secretName"`
+}
+
+type GatewayReference struct {
+	Name      string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	Namespace string `json:"namespace,omitempty" protobuf:"bytes,2,opt,name=namespace"` // defaults to the pipeline's namespace
+}
+
+// Expose describes how to expose an HTTP source outside of the cluster.
+type Expose struct {
+	// +kubebuilder:default=Ingress
+	Kind ExposeKind `json:"kind,omitempty" protobuf:"bytes,1,opt,name=kind,casttype=ExposeKind"`
+	Host string     `json:"host" protobuf:"bytes,2,opt,name=host"`
+	// Path is the path prefix to route, defaults to `/sources/${sourceName}`. Ingresses need a controller-specific
+	// annotation to rewrite any other path.
+	Path        string            `json:"path,omitempty" protobuf:"bytes,3,opt,name=path"`
+	TLS         *ExposeTLS        `json:"tls,omitempty" protobuf:"bytes,4,opt,name=tls"` // if specified, the URL is HTTPS
+	Annotations map[string]string `json:"annotations,omitempty" protobuf:"bytes,5,rep,name=annotations"`
+	// IngressClassName is the class of the ingress, only used for ingresses.
+	IngressClassName string `json:"ingressClassName,omitempty" protobuf:"bytes,6,opt,name=ingressClassName"`
+	// Gateway is the parent of the HTTP route, only used for HTTP routes.
+	Gateway *GatewayReference `json:"gateway,omitempty" protobuf:"bytes,7,opt,name=gateway"`
+}
+
+func (in Expose) GetKind() ExposeKind {
+	if in.Kind != "" {
+		return in.Kind
+	}
+	return ExposeIngress
+}
+
+func (in Expose) GetPath(sourceName string) string {
+	if in.Path != "" {
+		return in.Path
+	}
+	return "/sources/" + sourceName
+}
+
+// GetURL returns the external URL of the source.
+func (in Expose) GetURL(sourceName string) string {
+	scheme := "http"
+	if in.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, in.Host, in.GetPath(sourceName))
+}
+
+func (in Expose) Validate() error {
+	if in.Host == "" {
+		return fmt.Errorf("expose must have a host")
+	}
+	if in.GetKind() == ExposeHTTPRoute && (in.Gateway == nil || in.Gateway.Name == "") {
+		return fmt.Errorf("expose of kind %q must have a gateway", ExposeHTTPRoute)
+	}
+	return nil
+}
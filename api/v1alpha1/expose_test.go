@@ -0,0 +1,24 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpose_GetKind(t *testing.T) {
+	assert.Equal(t, ExposeIngress, Expose{}.GetKind())
+	assert.Equal(t, ExposeHTTPRoute, Expose{Kind: ExposeHTTPRoute}.GetKind())
+}
+
+func TestExpose_GetURL(t *testing.T) {
+	assert.Equal(t, "http://example.com/sources/default", Expose{Host: "example.com"}.GetURL("default"))
+	assert.Equal(t, "https://example.com/in", Expose{Host: "example.com", Path: "/in", TLS: &ExposeTLS{}}.GetURL("default"))
+}
+
+func TestExpose_Validate(t *testing.T) {
+	assert.EqualError(t, Expose{}.Validate(), "expose must have a host")
+	assert.NoError(t, Expose{Host: "example.com"}.Validate())
+	assert.EqualError(t, Expose{Kind: ExposeHTTPRoute, Host: "example.com"}.Validate(), `expose of kind "HTTPRoute" must have a gateway`)
+	assert.NoError(t, Expose{Kind: ExposeHTTPRoute, Host: "example.com", Gateway: &GatewayReference{Name: "my-gateway"}}.Validate())
+}
@@ -34,6 +34,7 @@ var (
 	// AddToScheme adds the types in this group-version to the given scheme.
 	AddToScheme = SchemeBuilder.AddToScheme
 
+	CronPipelineGroupVersionKind = GroupVersion.WithKind("CronPipeline")
 	PipelineGroupVersionResource = GroupVersion.WithResource("pipelines")
 	PipelineGroupVersionKind     = GroupVersion.WithKind("Pipeline")
 	StepGroupVersionKind         = GroupVersion.WithKind("Step")
@@ -2,4 +2,6 @@ package v1alpha1
 
 type HTTPSource struct {
 	ServiceName string `json:"serviceName,omitempty" protobuf:"bytes,1,opt,name=serviceName"` // the service name to create, defaults to `${pipelineName}-${stepName}`.
+	// Expose creates an ingress or HTTP route for the source, so that it can be used from outside the cluster.
+	Expose *Expose `json:"expose,omitempty" protobuf:"bytes,2,opt,name=expose"`
 }
@@ -1,5 +1,7 @@
 package v1alpha1
 
+import "fmt"
+
 type KafkaNET struct {
 	TLS  *TLS  `json:"tls,omitempty" protobuf:"bytes,1,opt,name=tls"`
 	SASL *SASL `json:"sasl,omitempty" protobuf:"bytes,2,opt,name=sasl"`
@@ -17,3 +19,17 @@ type Kafka struct {
 	KafkaConfig `json:",inline" protobuf:"bytes,4,opt,name=kafkaConfig"`
 	Topic       string `json:"topic" protobuf:"bytes,3,opt,name=topic"`
 }
+
+// ApplyConnection fills in any unset fields from the connection.
+func (in *Kafka) ApplyConnection(c ConnectionSpec) error {
+	x := c.Kafka
+	if x == nil {
+		return fmt.Errorf("connection %q is not a Kafka connection", in.Name)
+	}
+	in.Brokers = StringsOr(in.Brokers, x.Brokers)
+	in.Version = StringOr(in.Version, x.Version)
+	if in.NET == nil {
+		in.NET = x.NET.DeepCopy()
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package v1alpha1
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// NetworkPolicy creates a network policy for each step, which only allows the traffic the step needs. Endpoints
+// configured by secret (rather than in the pipeline), and hosts outside the cluster, must be allowed using `egress`.
+type NetworkPolicy struct {
+	// Ingress are the peers, in addition to the pipeline's own steps and the controller, allowed to send messages to
+	// HTTP and S3 sources, e.g. your ingress controller.
+	Ingress []networkingv1.NetworkPolicyPeer `json:"ingress,omitempty" protobuf:"bytes,1,rep,name=ingress"`
+	// Prometheus are the peers allowed to scrape metrics.
+	Prometheus []networkingv1.NetworkPolicyPeer `json:"prometheus,omitempty" protobuf:"bytes,2,rep,name=prometheus"`
+	// Egress are additional rules, e.g. to allow databases, brokers configured by secret, or hosts outside the cluster.
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty" protobuf:"bytes,3,rep,name=egress"`
+}
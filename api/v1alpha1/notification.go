@@ -0,0 +1,97 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Notification is sent once each time the pipeline enters one of the phases, or one of the conditions becomes true.
+type Notification struct {
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Phases that trigger the notification, e.g. "Failed"
+	Phases []PipelinePhase `json:"phases,omitempty" protobuf:"bytes,2,rep,name=phases,casttype=PipelinePhase"`
+	// Conditions that trigger the notification, e.g. "Completed", "Terminating" or "Degraded"
+	Conditions []string `json:"conditions,omitempty" protobuf:"bytes,3,rep,name=conditions"`
+	// Body is a Go template for the message, e.g. `{"text": "{{ .Pipeline.Name }} {{ .Trigger }}"}`. Sprig functions
+	// are available. By default, it is a JSON object with the pipeline's namespace, name, phase and message, and the
+	// trigger.
+	Body  string    `json:"body,omitempty" protobuf:"bytes,4,opt,name=body"`
+	HTTP  *HTTPSink `json:"http,omitempty" protobuf:"bytes,5,opt,name=http"`
+	Kafka *Kafka    `json:"kafka,omitempty" protobuf:"bytes,6,opt,name=kafka"`
+	STAN  *STAN     `json:"stan,omitempty" protobuf:"bytes,7,opt,name=stan"`
+}
+
+func (in Notification) Validate() error {
+	if in.Name == "" {
+		return fmt.Errorf("notification must have a name")
+	}
+	if len(in.Phases) == 0 && len(in.Conditions) == 0 {
+		return fmt.Errorf("notification %q must have at least one phase or condition", in.Name)
+	}
+	n := 0
+	for _, x := range []bool{in.HTTP != nil, in.Kafka != nil, in.STAN != nil} {
+		if x {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("notification %q must have exactly one of http, kafka or stan", in.Name)
+	}
+	return nil
+}
+
+// GetTrigger returns the phase or condition that triggers the notification, or "" if nothing does
+func (in Notification) GetTrigger(phaseChanged bool, phase PipelinePhase, conditions []string) string {
+	if phaseChanged {
+		for _, p := range in.Phases {
+			if p == phase {
+				return string(p)
+			}
+		}
+	}
+	for _, c := range conditions {
+		for _, x := range in.Conditions {
+			if x == c {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+type NotificationStatus struct {
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Trigger is the phase or condition that last triggered the notification
+	Trigger     string      `json:"trigger,omitempty" protobuf:"bytes,2,opt,name=trigger"`
+	TriggeredAt metav1.Time `json:"triggeredAt,omitempty" protobuf:"bytes,3,opt,name=triggeredAt"`
+	Delivered   bool        `json:"delivered,omitempty" protobuf:"varint,4,opt,name=delivered"`
+	// Attempts is how many times delivery has been attempted since the notification was triggered
+	Attempts uint32 `json:"attempts,omitempty" protobuf:"varint,5,opt,name=attempts"`
+	// Message is the error from the last attempt, if it failed
+	Message string `json:"message,omitempty" protobuf:"bytes,6,opt,name=message"`
+}
+
+type NotificationStatuses []NotificationStatus
+
+// Trigger marks the notification to be sent
+func (in *NotificationStatuses) Trigger(name, trigger string, now metav1.Time) {
+	x := NotificationStatus{Name: name, Trigger: trigger, TriggeredAt: now}
+	for i, s := range *in {
+		if s.Name == name {
+			(*in)[i] = x
+			return
+		}
+	}
+	*in = append(*in, x)
+}
+
+// AnyUndelivered returns true if any notification has not been delivered, and has not yet run out of attempts
+func (in NotificationStatuses) AnyUndelivered(maxAttempts uint32) bool {
+	for _, s := range in {
+		if !s.Delivered && s.Attempts < maxAttempts {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNotification_Validate(t *testing.T) {
+	assert.EqualError(t, Notification{}.Validate(), "notification must have a name")
+	assert.EqualError(t, Notification{Name: "n", HTTP: &HTTPSink{}}.Validate(), `notification "n" must have at least one phase or condition`)
+	assert.EqualError(t, Notification{Name: "n", Phases: []PipelinePhase{PipelineFailed}}.Validate(), `notification "n" must have exactly one of http, kafka or stan`)
+	assert.EqualError(t, Notification{Name: "n", Phases: []PipelinePhase{PipelineFailed}, HTTP: &HTTPSink{}, Kafka: &Kafka{}}.Validate(), `notification "n" must have exactly one of http, kafka or stan`)
+	assert.NoError(t, Notification{Name: "n", Conditions: []string{ConditionDegraded}, STAN: &STAN{}}.Validate())
+}
+
+func TestNotification_GetTrigger(t *testing.T) {
+	n := Notification{Phases: []PipelinePhase{PipelineFailed}, Conditions: []string{ConditionDegraded}}
+	assert.Equal(t, "Failed", n.GetTrigger(true, PipelineFailed, nil))
+	assert.Empty(t, n.GetTrigger(false, PipelineFailed, nil), "phase did not change")
+	assert.Empty(t, n.GetTrigger(true, PipelineRunning, []string{ConditionRunning}))
+	assert.Equal(t, "Degraded", n.GetTrigger(false, PipelineRunning, []string{ConditionRunning, ConditionDegraded}))
+}
+
+func TestNotificationStatuses(t *testing.T) {
+	var x NotificationStatuses
+	assert.False(t, x.AnyUndelivered(5))
+	x.Trigger("n", "Failed", metav1.Time{})
+	assert.Equal(t, NotificationStatuses{{Name: "n", Trigger: "Failed"}}, x)
+	assert.True(t, x.AnyUndelivered(5))
+	x[0].Attempts = 5
+	assert.False(t, x.AnyUndelivered(5), "out of attempts")
+	x.Trigger("n", "Degraded", metav1.Time{})
+	assert.Equal(t, NotificationStatuses{{Name: "n", Trigger: "Degraded"}}, x, "re-triggering resets the status")
+	x[0].Delivered = true
+	assert.False(t, x.AnyUndelivered(5))
+}
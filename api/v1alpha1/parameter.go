@@ -0,0 +1,44 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// +kubebuilder:validation:Enum=string;integer;boolean
+type ParameterType string
+
+const (
+	ParameterTypeString  ParameterType = "string"
+	ParameterTypeInteger ParameterType = "integer"
+	ParameterTypeBoolean ParameterType = "boolean"
+)
+
+type Parameter struct {
+	Name        string  `json:"name" protobuf:"bytes,1,opt,name=name"`
+	Description string  `json:"description,omitempty" protobuf:"bytes,2,opt,name=description"`
+	Default     *string `json:"default,omitempty" protobuf:"bytes,3,opt,name=default"` // if not specified, an argument must be provided
+	// The type of the value when a parameter is the whole of a string, e.g. `replicas: "{{parameters.replicas}}"`.
+	// +kubebuilder:default=string
+	Type ParameterType `json:"type,omitempty" protobuf:"bytes,4,opt,name=type,casttype=ParameterType"`
+}
+
+// typedValue returns the value as the parameter's type
+func (in Parameter) typedValue(v string) (interface{}, error) {
+	switch in.Type {
+	case ParameterTypeInteger:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q must be an integer: %w", in.Name, err)
+		}
+		return i, nil
+	case ParameterTypeBoolean:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q must be a boolean: %w", in.Name, err)
+		}
+		return b, nil
+	default:
+		return v, nil
+	}
+}
@@ -4,6 +4,34 @@ type PipelineSpec struct {
 	// +patchStrategy=merge
 	// +patchMergeKey=name
 	Steps []StepSpec `json:"steps,omitempty" protobuf:"bytes,1,rep,name=steps"`
+	// TTLStrategy overrides how long the pipeline is kept after it completes, by default this is the controller's
+	// deletion delay
+	TTLStrategy *TTLStrategy `json:"ttlStrategy,omitempty" protobuf:"bytes,2,opt,name=ttlStrategy"`
+	// TemplateRef renders the spec from a pipeline template, rather than specifying the steps
+	TemplateRef *TemplateRef `json:"templateRef,omitempty" protobuf:"bytes,3,opt,name=templateRef"`
+	// Transport is how messages are sent between steps wired together using `step:`, by default STAN
+	Transport *StepTransport `json:"transport,omitempty" protobuf:"bytes,4,opt,name=transport"`
+	// NetworkPolicy creates network policies for the steps, so they only allow the traffic they need
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty" protobuf:"bytes,5,opt,name=networkPolicy"`
+	// RevisionHistoryLimit is how many previous revisions of the spec to keep, so that you can roll back to them,
+	// default 10
+	RevisionHistoryLimit *uint32 `json:"revisionHistoryLimit,omitempty" protobuf:"varint,6,opt,name=revisionHistoryLimit"`
+	// Notifications are sent when the pipeline changes phase, or its conditions change
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	Notifications []Notification `json:"notifications,omitempty" protobuf:"bytes,7,rep,name=notifications"`
+	// DependsOn are other pipelines that must reach a phase or condition before this pipeline starts, until then it
+	// is pending
+	DependsOn []Dependency `json:"dependsOn,omitempty" protobuf:"bytes,8,rep,name=dependsOn"`
+	// Trigger configures how the pipeline is started once its dependencies are met
+	Trigger *Trigger `json:"trigger,omitempty" protobuf:"bytes,9,opt,name=trigger"`
+}
+
+func (in PipelineSpec) GetRevisionHistoryLimit() int {
+	if in.RevisionHistoryLimit == nil {
+		return 10
+	}
+	return int(*in.RevisionHistoryLimit)
 }
 
 func (in *PipelineSpec) HasStep(name string) bool {
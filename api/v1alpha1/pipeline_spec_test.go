@@ -0,0 +1,13 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineSpec_GetRevisionHistoryLimit(t *testing.T) {
+	assert.Equal(t, 10, PipelineSpec{}.GetRevisionHistoryLimit())
+	zero := uint32(0)
+	assert.Equal(t, 0, PipelineSpec{RevisionHistoryLimit: &zero}.GetRevisionHistoryLimit())
+}
@@ -7,4 +7,15 @@ type PipelineStatus struct {
 	Message     string             `json:"message,omitempty" protobuf:"bytes,2,opt,name=message"`
 	Conditions  []metav1.Condition `json:"conditions,omitempty" protobuf:"bytes,3,rep,name=conditions"`
 	LastUpdated metav1.Time        `json:"lastUpdated,omitempty" protobuf:"bytes,4,opt,name=lastUpdated"`
+	Template    *TemplateStatus    `json:"template,omitempty" protobuf:"bytes,5,opt,name=template"`
+	// Edges are the flows of messages between the steps
+	Edges []Edge `json:"edges,omitempty" protobuf:"bytes,6,rep,name=edges"`
+	// Revision is the revision of the spec that the steps are running
+	Revision int64 `json:"revision,omitempty" protobuf:"varint,7,opt,name=revision"`
+	// ChangeCause is the `kubernetes.io/change-cause` annotation of the revision
+	ChangeCause string `json:"changeCause,omitempty" protobuf:"bytes,8,opt,name=changeCause"`
+	// Notifications records the delivery of each notification
+	Notifications NotificationStatuses `json:"notifications,omitempty" protobuf:"bytes,9,rep,name=notifications"`
+	// Trigger is set once the pipeline's dependencies have been met
+	Trigger *TriggerStatus `json:"trigger,omitempty" protobuf:"bytes,10,opt,name=trigger"`
 }
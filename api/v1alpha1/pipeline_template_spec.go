@@ -0,0 +1,100 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type PipelineTemplateSpec struct {
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	Parameters []Parameter `json:"parameters,omitempty" protobuf:"bytes,1,rep,name=parameters"`
+	// Template is a pipeline spec. Any string within it may contain `{{parameters.name}}`, which is replaced by the
+	// parameter's value.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template runtime.RawExtension `json:"template" protobuf:"bytes,2,opt,name=template"`
+}
+
+var parameterRegexp = regexp.MustCompile(`{{\s*parameters\.([a-zA-Z0-9_-]+)\s*}}`)
+
+// Render returns the pipeline spec with the parameters replaced by the arguments, or their defaults.
+func (in PipelineTemplateSpec) Render(arguments map[string]string) (PipelineSpec, error) {
+	spec := PipelineSpec{}
+	parameters := map[string]Parameter{}
+	values := map[string]string{}
+	for _, p := range in.Parameters {
+		parameters[p.Name] = p
+		if v, ok := arguments[p.Name]; ok {
+			values[p.Name] = v
+		} else if p.Default != nil {
+			values[p.Name] = *p.Default
+		} else {
+			return spec, fmt.Errorf("parameter %q has no argument or default", p.Name)
+		}
+	}
+	for name := range arguments {
+		if _, ok := parameters[name]; !ok {
+			return spec, fmt.Errorf("argument %q is not a parameter of the template", name)
+		}
+	}
+	var x interface{}
+	if err := json.Unmarshal(in.Template.Raw, &x); err != nil {
+		return spec, fmt.Errorf("failed to unmarshal template: %w", err)
+	}
+	y, err := renderValue(x, parameters, values)
+	if err != nil {
+		return spec, err
+	}
+	data, err := json.Marshal(y)
+	if err != nil {
+		return spec, err
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return spec, fmt.Errorf("failed to unmarshal rendered template: %w", err)
+	}
+	if spec.TemplateRef != nil {
+		return spec, fmt.Errorf("template must not reference another template")
+	}
+	return spec, nil
+}
+
+func renderValue(x interface{}, parameters map[string]Parameter, values map[string]string) (interface{}, error) {
+	switch v := x.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			y, err := renderValue(e, parameters, values)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = y
+		}
+		return v, nil
+	case []interface{}:
+		for i, e := range v {
+			y, err := renderValue(e, parameters, values)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = y
+		}
+		return v, nil
+	case string:
+		for _, m := range parameterRegexp.FindAllStringSubmatch(v, -1) {
+			if _, ok := parameters[m[1]]; !ok {
+				return nil, fmt.Errorf("parameter %q is not declared", m[1])
+			}
+		}
+		// if the parameter is the whole of the string, we use its type, so it can be used for non-string fields
+		if m := parameterRegexp.FindStringSubmatch(v); m != nil && m[0] == v {
+			return parameters[m[1]].typedValue(values[m[1]])
+		}
+		return parameterRegexp.ReplaceAllStringFunc(v, func(s string) string {
+			return values[parameterRegexp.FindStringSubmatch(s)[1]]
+		}), nil
+	default:
+		return v, nil
+	}
+}
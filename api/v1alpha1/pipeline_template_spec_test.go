@@ -0,0 +1,49 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+)
+
+func TestPipelineTemplateSpec_Render(t *testing.T) {
+	x := PipelineTemplateSpec{
+		Parameters: []Parameter{
+			{Name: "tenant"},
+			{Name: "replicas", Type: ParameterTypeInteger, Default: pointer.StringPtr("1")},
+		},
+		Template: runtime.RawExtension{Raw: []byte(`{"steps":[{"name":"main","cat":{},"replicas":"{{parameters.replicas}}","sources":[{"kafka":{"topic":"{{ parameters.tenant }}-input"}}]}]}`)},
+	}
+	t.Run("Defaults", func(t *testing.T) {
+		spec, err := x.Render(map[string]string{"tenant": "foo"})
+		assert.NoError(t, err)
+		if assert.Len(t, spec.Steps, 1) {
+			assert.Equal(t, uint32(1), spec.Steps[0].Replicas)
+			assert.Equal(t, "foo-input", spec.Steps[0].Sources[0].Kafka.Topic)
+		}
+	})
+	t.Run("Arguments", func(t *testing.T) {
+		spec, err := x.Render(map[string]string{"tenant": "bar", "replicas": "3"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(3), spec.Steps[0].Replicas)
+		assert.Equal(t, "bar-input", spec.Steps[0].Sources[0].Kafka.Topic)
+	})
+	t.Run("MissingArgument", func(t *testing.T) {
+		_, err := x.Render(nil)
+		assert.EqualError(t, err, `parameter "tenant" has no argument or default`)
+	})
+	t.Run("UnknownArgument", func(t *testing.T) {
+		_, err := x.Render(map[string]string{"tenant": "foo", "bar": "baz"})
+		assert.EqualError(t, err, `argument "bar" is not a parameter of the template`)
+	})
+	t.Run("InvalidType", func(t *testing.T) {
+		_, err := x.Render(map[string]string{"tenant": "foo", "replicas": "many"})
+		assert.Error(t, err)
+	})
+	t.Run("Undeclared", func(t *testing.T) {
+		_, err := PipelineTemplateSpec{Template: runtime.RawExtension{Raw: []byte(`{"steps":[{"name":"{{parameters.foo}}"}]}`)}}.Render(nil)
+		assert.EqualError(t, err, `parameter "foo" is not declared`)
+	})
+}
@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// PodTemplate is strategic-merged over the step's generated pod spec. The field names are the same as the pod spec's.
+// Containers are merged by name, so you can change the `init`, `sidecar` or `main` containers (e.g. their resources).
+type PodTemplate struct {
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	ImagePullSecrets          []corev1.LocalObjectReference     `json:"imagePullSecrets,omitempty" protobuf:"bytes,1,rep,name=imagePullSecrets"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty" protobuf:"bytes,2,opt,name=priorityClassName"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty" protobuf:"bytes,3,rep,name=topologySpreadConstraints"`
+	// +patchStrategy=merge
+	// +patchMergeKey=ip
+	HostAliases      []corev1.HostAlias         `json:"hostAliases,omitempty" protobuf:"bytes,4,rep,name=hostAliases"`
+	DNSPolicy        corev1.DNSPolicy           `json:"dnsPolicy,omitempty" protobuf:"bytes,5,opt,name=dnsPolicy,casttype=k8s.io/api/core/v1.DNSPolicy"`
+	DNSConfig        *corev1.PodDNSConfig       `json:"dnsConfig,omitempty" protobuf:"bytes,6,opt,name=dnsConfig"`
+	RuntimeClassName *string                    `json:"runtimeClassName,omitempty" protobuf:"bytes,7,opt,name=runtimeClassName"`
+	SecurityContext  *corev1.PodSecurityContext `json:"securityContext,omitempty" protobuf:"bytes,8,opt,name=securityContext"`
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	InitContainers []corev1.Container `json:"initContainers,omitempty" protobuf:"bytes,9,rep,name=initContainers"`
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	Containers []corev1.Container `json:"containers,omitempty" protobuf:"bytes,10,rep,name=containers"`
+}
+
+func (in *PodTemplate) applyTo(podSpec corev1.PodSpec) (corev1.PodSpec, error) {
+	if in == nil {
+		return podSpec, nil
+	}
+	original, err := json.Marshal(podSpec)
+	if err != nil {
+		return podSpec, err
+	}
+	patch, err := json.Marshal(in)
+	if err != nil {
+		return podSpec, err
+	}
+	data, err := strategicpatch.StrategicMergePatch(original, patch, corev1.PodSpec{})
+	if err != nil {
+		return podSpec, fmt.Errorf("failed to apply pod template: %w", err)
+	}
+	x := corev1.PodSpec{}
+	if err := json.Unmarshal(data, &x); err != nil {
+		return podSpec, fmt.Errorf("failed to apply pod template: %w", err)
+	}
+	return x, nil
+}
@@ -0,0 +1,47 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPodTemplate_applyTo(t *testing.T) {
+	podSpec := corev1.PodSpec{
+		ServiceAccountName: "pipeline",
+		Containers: []corev1.Container{
+			{Name: CtrSidecar, Image: "my-runner", Resources: standardResources},
+			{Name: CtrMain, Image: "my-image"},
+		},
+	}
+	t.Run("Nil", func(t *testing.T) {
+		var x *PodTemplate
+		y, err := x.applyTo(podSpec)
+		assert.NoError(t, err)
+		assert.Equal(t, podSpec, y)
+	})
+	t.Run("Overlay", func(t *testing.T) {
+		memory := resource.MustParse("1Gi")
+		x := &PodTemplate{
+			ImagePullSecrets:  []corev1.LocalObjectReference{{Name: "my-secret"}},
+			PriorityClassName: "high",
+			Containers: []corev1.Container{
+				{Name: CtrSidecar, Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: memory}}},
+			},
+		}
+		y, err := x.applyTo(podSpec)
+		assert.NoError(t, err)
+		assert.Equal(t, "pipeline", y.ServiceAccountName)
+		assert.Equal(t, "high", y.PriorityClassName)
+		assert.Equal(t, []corev1.LocalObjectReference{{Name: "my-secret"}}, y.ImagePullSecrets)
+		if assert.Len(t, y.Containers, 2) {
+			sidecar := y.Containers[0]
+			assert.Equal(t, "my-runner", sidecar.Image)
+			assert.Equal(t, "1Gi", sidecar.Resources.Limits.Memory().String())
+			assert.Equal(t, "500m", sidecar.Resources.Limits.Cpu().String())
+			assert.Equal(t, "my-image", y.Containers[1].Image)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaxRecentErrors is the number of distinct errors kept for each source or sink.
+const MaxRecentErrors = 5
+
+// RecentError is an error recently returned when processing a message.
+type RecentError struct {
+	Message string      `json:"message" protobuf:"bytes,1,opt,name=message"`
+	Time    metav1.Time `json:"time" protobuf:"bytes,2,opt,name=time"`
+	Replica uint32      `json:"replica" protobuf:"varint,3,opt,name=replica"`
+}
+
+// RecentErrors are ordered oldest first.
+type RecentErrors []RecentError
+
+// Add returns the errors with x added as the most recent, removing any older error with the same message, and
+// keeping at most MaxRecentErrors.
+func (in RecentErrors) Add(x RecentError) RecentErrors {
+	x.Message = truncN(x.Message, 256)
+	out := RecentErrors{}
+	for _, e := range in {
+		if e.Message != x.Message {
+			out = append(out, e)
+		}
+	}
+	out = append(out, x)
+	if len(out) > MaxRecentErrors {
+		out = out[len(out)-MaxRecentErrors:]
+	}
+	return out
+}
+
+// Merge returns the errors of other replicas from in, with the errors of this replica from mine.
+func (in RecentErrors) Merge(replica int, mine RecentErrors) RecentErrors {
+	var all RecentErrors
+	for _, e := range in {
+		if e.Replica != uint32(replica) {
+			all = append(all, e)
+		}
+	}
+	for _, e := range mine {
+		if e.Replica == uint32(replica) {
+			all = append(all, e)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Time.Before(&all[j].Time) })
+	var out RecentErrors
+	for _, e := range all {
+		out = out.Add(e)
+	}
+	return out
+}
+
+// Last returns the most recent error, or nil.
+func (in RecentErrors) Last() *RecentError {
+	if len(in) == 0 {
+		return nil
+	}
+	return &in[len(in)-1]
+}
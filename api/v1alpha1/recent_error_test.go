@@ -0,0 +1,48 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecentErrors_Add(t *testing.T) {
+	t.Run("Distinct", func(t *testing.T) {
+		x := RecentErrors{}.Add(RecentError{Message: "a"}).Add(RecentError{Message: "b"}).Add(RecentError{Message: "a", Replica: 1})
+		assert.Equal(t, RecentErrors{{Message: "b"}, {Message: "a", Replica: 1}}, x)
+	})
+	t.Run("Max", func(t *testing.T) {
+		var x RecentErrors
+		for _, m := range []string{"a", "b", "c", "d", "e", "f"} {
+			x = x.Add(RecentError{Message: m})
+		}
+		assert.Len(t, x, MaxRecentErrors)
+		assert.Equal(t, "b", x[0].Message)
+		assert.Equal(t, "f", x.Last().Message)
+	})
+	t.Run("Truncated", func(t *testing.T) {
+		x := RecentErrors{}.Add(RecentError{Message: strings.Repeat("x", 1000)})
+		assert.Len(t, x[0].Message, 256)
+	})
+}
+
+func TestRecentErrors_Merge(t *testing.T) {
+	t0 := metav1.NewTime(time.Now())
+	t1 := metav1.NewTime(t0.Add(time.Second))
+	t2 := metav1.NewTime(t0.Add(2 * time.Second))
+	theirs := RecentErrors{{Message: "a", Time: t0, Replica: 1}, {Message: "stale", Time: t0, Replica: 0}, {Message: "c", Time: t2, Replica: 1}}
+	mine := RecentErrors{{Message: "b", Time: t1, Replica: 0}}
+	assert.Equal(t, RecentErrors{
+		{Message: "a", Time: t0, Replica: 1},
+		{Message: "b", Time: t1, Replica: 0},
+		{Message: "c", Time: t2, Replica: 1},
+	}, theirs.Merge(0, mine))
+}
+
+func TestRecentErrors_Last(t *testing.T) {
+	assert.Nil(t, RecentErrors{}.Last())
+	assert.Equal(t, "b", RecentErrors{{Message: "a"}, {Message: "b"}}.Last().Message)
+}
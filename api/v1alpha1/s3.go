@@ -1,5 +1,7 @@
 package v1alpha1
 
+import "fmt"
+
 type S3 struct {
 	// +kubebuilder:default=default
 	Name        string          `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
@@ -8,3 +10,19 @@ type S3 struct {
 	Credentials *AWSCredentials `json:"credentials,omitempty" protobuf:"bytes,4,opt,name=credentials"`
 	Endpoint    *AWSEndpoint    `json:"endpoint,omitempty" protobuf:"bytes,5,opt,name=endpoint"`
 }
+
+// ApplyConnection fills in any unset fields from the connection.
+func (in *S3) ApplyConnection(c ConnectionSpec) error {
+	x := c.S3
+	if x == nil {
+		return fmt.Errorf("connection %q is not an S3 connection", in.Name)
+	}
+	in.Region = StringOr(in.Region, x.Region)
+	if in.Credentials == nil {
+		in.Credentials = x.Credentials.DeepCopy()
+	}
+	if in.Endpoint == nil {
+		in.Endpoint = x.Endpoint.DeepCopy()
+	}
+	return nil
+}
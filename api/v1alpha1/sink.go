@@ -9,4 +9,27 @@ type Sink struct {
 	HTTP  *HTTPSink `json:"http,omitempty" protobuf:"bytes,5,opt,name=http"`
 	S3    *S3Sink   `json:"s3,omitempty" protobuf:"bytes,6,opt,name=s3"`
 	DB    *DBSink   `json:"db,omitempty" protobuf:"bytes,7,opt,name=db"`
+	// Step is the name of a step in the pipeline to send messages to, using the pipeline's transport
+	Step string `json:"step,omitempty" protobuf:"bytes,8,opt,name=step"`
+}
+
+// getType returns the type of the sink, e.g. "kafka", as used by policies
+func (in Sink) getType() string {
+	switch {
+	case in.STAN != nil:
+		return "stan"
+	case in.Kafka != nil:
+		return "kafka"
+	case in.Log != nil:
+		return "log"
+	case in.HTTP != nil:
+		return "http"
+	case in.S3 != nil:
+		return "s3"
+	case in.DB != nil:
+		return "db"
+	case in.Step != "":
+		return "step"
+	}
+	return ""
 }
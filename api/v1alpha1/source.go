@@ -8,6 +8,32 @@ type Source struct {
 	Kafka *KafkaSource `json:"kafka,omitempty" protobuf:"bytes,4,opt,name=kafka"`
 	HTTP  *HTTPSource  `json:"http,omitempty" protobuf:"bytes,5,opt,name=http"`
 	S3    *S3Source    `json:"s3,omitempty" protobuf:"bytes,8,opt,name=s3"`
+	// Step is the name of a step in the pipeline to receive messages from, using the pipeline's transport
+	Step string `json:"step,omitempty" protobuf:"bytes,9,opt,name=step"`
 	// +kubebuilder:default={duration: "100ms", steps: 20, factorPercentage: 200, jitterPercentage: 10}
 	Retry Backoff `json:"retry,omitempty" protobuf:"bytes,7,opt,name=retry"`
+	// Bounded sources end, rather than streaming forever, and the step completes once all of its sources are exhausted.
+	// Kafka sources read up to the end offsets when they start, S3 sources process the objects in the bucket when they
+	// start, and STAN sources end once they have no pending messages. Sources from other steps are bounded
+	// automatically, once those steps have completed.
+	Bounded bool `json:"bounded,omitempty" protobuf:"varint,10,opt,name=bounded"`
+}
+
+// getType returns the type of the source, e.g. "kafka", as used by policies
+func (in Source) getType() string {
+	switch {
+	case in.Cron != nil:
+		return "cron"
+	case in.STAN != nil:
+		return "stan"
+	case in.Kafka != nil:
+		return "kafka"
+	case in.HTTP != nil:
+		return "http"
+	case in.S3 != nil:
+		return "s3"
+	case in.Step != "":
+		return "step"
+	}
+	return ""
 }
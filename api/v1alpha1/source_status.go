@@ -1,8 +1,33 @@
 package v1alpha1
 
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 type SourceStatus struct {
 	Pending *uint64            `json:"pending,omitempty" protobuf:"varint,3,opt,name=pending"`
 	Metrics map[string]Metrics `json:"metrics,omitempty" protobuf:"bytes,4,rep,name=metrics"`
+	// RecentErrors are the most recent distinct errors, of any replica
+	RecentErrors RecentErrors `json:"recentErrors,omitempty" protobuf:"bytes,5,rep,name=recentErrors"`
+	// URL is the external URL of an exposed HTTP source
+	URL string `json:"url,omitempty" protobuf:"bytes,6,opt,name=url"`
+	// Exhausted is true once a bounded source has processed all of its messages
+	Exhausted bool `json:"exhausted,omitempty" protobuf:"varint,7,opt,name=exhausted"`
+	// EndOffsets are the offsets of each partition that a bounded Kafka source reads up to. They are recorded by the
+	// controller once for the step, so that every replica, including restarted ones, reads up to the same offsets.
+	EndOffsets map[int32]int64 `json:"endOffsets,omitempty" protobuf:"bytes,8,rep,name=endOffsets"`
+	// EndSequence is the sequence of the last message that a bounded STAN source reads. It is recorded by the
+	// controller once for the step, so that every replica, including restarted ones, reads up to the same message.
+	EndSequence *uint64 `json:"endSequence,omitempty" protobuf:"varint,9,opt,name=endSequence"`
+	// EndTime is when a bounded S3 source started, it only processes objects last modified before then. It is recorded
+	// by the controller once for the step, so that a restarted replica does not process objects added since.
+	EndTime *metav1.Time `json:"endTime,omitempty" protobuf:"bytes,10,opt,name=endTime"`
+}
+
+// HasEnd returns true if where the bounded source ends has been recorded
+func (in SourceStatus) HasEnd() bool {
+	return in.EndOffsets != nil || in.EndSequence != nil || in.EndTime != nil
 }
 
 // GetPending returns pending counts
@@ -41,3 +66,12 @@ func (in SourceStatus) GetRetries() uint64 {
 	}
 	return x
 }
+
+// GetRate returns the sum of the rates of all replicas
+func (in SourceStatus) GetRate() resource.Quantity {
+	x := resource.Quantity{}
+	for _, m := range in.Metrics {
+		x.Add(m.Rate)
+	}
+	return x
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestSourceStatus_GetTotal(t *testing.T) {
@@ -44,3 +45,18 @@ func TestSourceStatus_GetRetries(t *testing.T) {
 		assert.Equal(t, uint64(2), x.GetRetries())
 	})
 }
+
+func TestSourceStatus_GetRate(t *testing.T) {
+	t.Run("None", func(t *testing.T) {
+		x := SourceStatus{}
+		rate := x.GetRate()
+		assert.True(t, rate.IsZero())
+	})
+	t.Run("Two", func(t *testing.T) {
+		x := SourceStatus{
+			Metrics: map[string]Metrics{"0": {Rate: resource.MustParse("1.5")}, "1": {Rate: resource.MustParse("2")}},
+		}
+		rate := x.GetRate()
+		assert.Equal(t, "3500m", rate.String())
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type SourceStatuses map[string]SourceStatus // key is source name
@@ -38,12 +39,46 @@ func (in SourceStatuses) IncrErrors(name string, replica int) {
 	in[name] = x
 }
 
+// RecordError adds the error to the source's recent errors
+func (in SourceStatuses) RecordError(name string, replica int, err error) {
+	x := in[name]
+	x.RecentErrors = x.RecentErrors.Add(RecentError{Message: err.Error(), Time: metav1.Now(), Replica: uint32(replica)})
+	in[name] = x
+}
+
 func (in SourceStatuses) SetPending(name string, pending uint64) {
 	x := in[name]
 	x.Pending = &pending
 	in[name] = x
 }
 
+func (in SourceStatuses) SetExhausted(name string) {
+	x := in[name]
+	x.Exhausted = true
+	in[name] = x
+}
+
+// SetEndOffsets records the end offsets of a bounded Kafka source
+func (in SourceStatuses) SetEndOffsets(name string, endOffsets map[int32]int64) {
+	x := in[name]
+	x.EndOffsets = endOffsets
+	in[name] = x
+}
+
+// SetEndSequence records the end sequence of a bounded STAN source
+func (in SourceStatuses) SetEndSequence(name string, endSequence uint64) {
+	x := in[name]
+	x.EndSequence = &endSequence
+	in[name] = x
+}
+
+// SetEndTime records when a bounded S3 source started
+func (in SourceStatuses) SetEndTime(name string, endTime metav1.Time) {
+	x := in[name]
+	x.EndTime = &endTime
+	in[name] = x
+}
+
 func (in SourceStatuses) GetPending() uint64 {
 	var v uint64
 	for _, s := range in {
@@ -92,3 +127,35 @@ func (in SourceStatuses) IncrRetries(name string, replica int) {
 	x.Metrics[strconv.Itoa(replica)] = m
 	in[name] = x
 }
+
+// GetRate returns the sum of the rates of all sources
+func (in SourceStatuses) GetRate() resource.Quantity {
+	x := resource.Quantity{}
+	for _, s := range in {
+		x.Add(s.GetRate())
+	}
+	return x
+}
+
+// MergeReplica copies the metrics and recent errors of the replica from x. Only the lead replica (replica 0) reports
+// pending and exhausted, so only its are copied.
+func (in SourceStatuses) MergeReplica(replica int, x SourceStatuses) {
+	r := strconv.Itoa(replica)
+	for name, s := range x {
+		y := in[name]
+		if y.Metrics == nil {
+			y.Metrics = map[string]Metrics{}
+		}
+		if m, ok := s.Metrics[r]; ok {
+			y.Metrics[r] = m
+		}
+		y.RecentErrors = y.RecentErrors.Merge(replica, s.RecentErrors)
+		if replica == 0 && s.Pending != nil {
+			y.Pending = s.Pending
+		}
+		if replica == 0 {
+			y.Exhausted = s.Exhausted
+		}
+		in[name] = y
+	}
+}
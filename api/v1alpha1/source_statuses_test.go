@@ -100,3 +100,19 @@ func TestSourceStatuses_IncrRetries(t *testing.T) {
 	assert.Equal(t, uint64(3), sources.Get("one").GetRetries())
 	assert.Equal(t, uint64(1), sources.Get("two").GetRetries())
 }
+
+func TestSourceStatuses_MergeReplica(t *testing.T) {
+	pending := uint64(3)
+	ss := SourceStatuses{"foo": {Metrics: map[string]Metrics{"0": {Total: 1}, "1": {Total: 2}}}}
+	ss.MergeReplica(1, SourceStatuses{"foo": {Pending: &pending, Metrics: map[string]Metrics{"0": {Total: 99}, "1": {Total: 3}}}})
+	assert.Equal(t, uint64(1), ss["foo"].Metrics["0"].Total, "other replicas are not changed")
+	assert.Equal(t, uint64(3), ss["foo"].Metrics["1"].Total)
+	assert.Nil(t, ss["foo"].Pending, "only the lead replica reports pending")
+	ss.MergeReplica(1, SourceStatuses{"foo": {Exhausted: true}})
+	assert.False(t, ss["foo"].Exhausted, "only the lead replica reports exhausted")
+	ss.MergeReplica(0, SourceStatuses{"bar": {Pending: &pending, Exhausted: true, Metrics: map[string]Metrics{"0": {Total: 4}}, RecentErrors: RecentErrors{{Message: "oops"}}}})
+	assert.Equal(t, uint64(4), ss["bar"].Metrics["0"].Total)
+	assert.Equal(t, uint64(3), ss["bar"].GetPending())
+	assert.Len(t, ss["bar"].RecentErrors, 1)
+	assert.True(t, ss["bar"].Exhausted)
+}
@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"fmt"
+
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -45,3 +47,23 @@ func (s *STAN) GetMaxInflight() int {
 	}
 	return int(s.MaxInflight)
 }
+
+// ApplyConnection fills in any unset fields from the connection.
+func (s *STAN) ApplyConnection(c ConnectionSpec) error {
+	x := c.STAN
+	if x == nil {
+		return fmt.Errorf("connection %q is not a STAN connection", s.Name)
+	}
+	s.NATSURL = StringOr(s.NATSURL, x.NATSURL)
+	s.NATSMonitoringURL = StringOr(s.NATSMonitoringURL, x.NATSMonitoringURL)
+	s.ClusterID = StringOr(s.ClusterID, x.ClusterID)
+	s.SubjectPrefix = SubjectPrefixOr(s.SubjectPrefix, x.SubjectPrefix)
+	if s.Auth == nil {
+		s.Auth = x.Auth.DeepCopy()
+	}
+	// the API server defaults this to CommitN, so we cannot tell that apart from the user choosing it
+	if x.MaxInflight > 0 && (s.MaxInflight < 1 || s.MaxInflight == CommitN) {
+		s.MaxInflight = x.MaxInflight
+	}
+	return nil
+}
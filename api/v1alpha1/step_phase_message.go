@@ -8,15 +8,15 @@ import (
 type StepPhaseMessage string
 
 func (m StepPhaseMessage) GetPhase() StepPhase {
-	return StepPhase(strings.Split(string(m), "/")[0])
+	return StepPhase(strings.SplitN(string(m), "/", 3)[0])
 }
 
 func (m StepPhaseMessage) GetReason() string {
-	return strings.Split(string(m), "/")[1]
+	return strings.SplitN(string(m), "/", 3)[1]
 }
 
 func (m StepPhaseMessage) GetMessage() string {
-	return strings.Split(string(m), "/")[2]
+	return strings.SplitN(string(m), "/", 3)[2]
 }
 
 func NewStepPhaseMessage(phase StepPhase, reason, message string) StepPhaseMessage {
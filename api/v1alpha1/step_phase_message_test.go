@@ -12,3 +12,10 @@ func TestMinStepPhaseMessage(t *testing.T) {
 	assert.Equal(t, "baz", x.GetReason())
 	assert.Equal(t, "foo", x.GetMessage())
 }
+
+func TestStepPhaseMessage_GetMessage(t *testing.T) {
+	x := NewStepPhaseMessage(StepFailed, "baz", `image "quay.io/foo/bar" is not allowed`)
+	assert.Equal(t, StepFailed, x.GetPhase())
+	assert.Equal(t, "baz", x.GetReason())
+	assert.Equal(t, `image "quay.io/foo/bar" is not allowed`, x.GetMessage())
+}
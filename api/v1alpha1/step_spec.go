@@ -4,6 +4,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
 )
 
 type StepSpec struct {
@@ -42,6 +43,14 @@ type StepSpec struct {
 	NodeSelector       map[string]string   `json:"nodeSelector,omitempty" protobuf:"bytes,17,rep,name=nodeSelector"`
 	Affinity           *corev1.Affinity    `json:"affinity,omitempty" protobuf:"bytes,18,opt,name=affinity"`
 	Tolerations        []corev1.Toleration `json:"tolerations,omitempty" protobuf:"bytes,19,rep,name=tolerations"`
+	PodTemplate        *PodTemplate        `json:"podTemplate,omitempty" protobuf:"bytes,28,opt,name=podTemplate"` // strategic-merged over the generated pod spec
+	// VolumeClaimTemplates are used to create a persistent volume claim for each replica.
+	// +patchStrategy=merge
+	// +patchMergeKey=name
+	VolumeClaimTemplates                 []VolumeClaimTemplate                 `json:"volumeClaimTemplates,omitempty" protobuf:"bytes,29,rep,name=volumeClaimTemplates"`
+	PersistentVolumeClaimRetentionPolicy *PersistentVolumeClaimRetentionPolicy `json:"persistentVolumeClaimRetentionPolicy,omitempty" protobuf:"bytes,30,opt,name=persistentVolumeClaimRetentionPolicy"`
+	// Watchdog restarts the main container if it hangs.
+	Watchdog *Watchdog `json:"watchdog,omitempty" protobuf:"bytes,31,opt,name=watchdog"`
 }
 
 type GetPodSpecReq struct {
@@ -54,6 +63,29 @@ type GetPodSpecReq struct {
 	PullPolicy     corev1.PullPolicy `protobuf:"bytes,6,opt,name=pullPolicy,casttype=k8s.io/api/core/v1.PullPolicy"`
 	UpdateInterval time.Duration     `protobuf:"varint,7,opt,name=updateInterval,casttype=time.Duration"`
 	StepStatus     StepStatus        `protobuf:"bytes,8,opt,name=stepStatus"`
+	// defaults from the controller's configuration
+	SidecarResources    corev1.ResourceRequirements `protobuf:"bytes,10,opt,name=sidecarResources"` // resources for the `init` and `sidecar` containers
+	PodSecurityContext  *corev1.PodSecurityContext  `protobuf:"bytes,11,opt,name=podSecurityContext"`
+	NodeSelector        map[string]string           `protobuf:"bytes,12,rep,name=nodeSelector"`         // merged with the step's node selector, the step's takes precedence
+	Tolerations         []corev1.Toleration         `protobuf:"bytes,13,rep,name=tolerations"`          // appended to the step's tolerations
+	AggregateStepStatus bool                        `protobuf:"varint,14,opt,name=aggregateStepStatus"` // the controller gets the status from each sidecar
+}
+
+func (in GetPodSpecReq) getSidecarResources() corev1.ResourceRequirements {
+	if in.SidecarResources.Limits != nil || in.SidecarResources.Requests != nil {
+		return in.SidecarResources
+	}
+	return standardResources
+}
+
+func (in GetPodSpecReq) getPodSecurityContext() *corev1.PodSecurityContext {
+	if in.PodSecurityContext != nil {
+		return in.PodSecurityContext
+	}
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: pointer.BoolPtr(true),
+		RunAsUser:    pointer.Int64Ptr(9653),
+	}
 }
 
 func (in StepSpec) GetIn() *Interface {
@@ -95,3 +127,39 @@ func (in StepSpec) CalculateReplicas(pending int) int {
 	}
 	return in.Scale.Calculate(pending)
 }
+
+func (in StepSpec) hasSinkToStep(name string) bool {
+	for _, s := range in.Sinks {
+		if s.Step == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (in StepSpec) hasSinkNamed(name string) bool {
+	for _, s := range in.Sinks {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (in StepSpec) hasSourceFromStep(name string) bool {
+	for _, s := range in.Sources {
+		if s.Step == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (in StepSpec) hasSourceNamed(name string) bool {
+	for _, s := range in.Sources {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
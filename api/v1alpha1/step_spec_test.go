@@ -0,0 +1,22 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/pointer"
+)
+
+func TestGetPodSpecReq_getSidecarResources(t *testing.T) {
+	assert.Equal(t, standardResources, GetPodSpecReq{}.getSidecarResources())
+	x := corev1.ResourceRequirements{Limits: corev1.ResourceList{"memory": resource.MustParse("1Gi")}}
+	assert.Equal(t, x, GetPodSpecReq{SidecarResources: x}.getSidecarResources())
+}
+
+func TestGetPodSpecReq_getPodSecurityContext(t *testing.T) {
+	assert.Equal(t, pointer.Int64Ptr(9653), GetPodSpecReq{}.getPodSecurityContext().RunAsUser)
+	x := &corev1.PodSecurityContext{RunAsUser: pointer.Int64Ptr(1000)}
+	assert.Equal(t, x, GetPodSpecReq{PodSecurityContext: x}.getPodSecurityContext())
+}
@@ -5,14 +5,20 @@ import (
 )
 
 type StepStatus struct {
-	Phase          StepPhase      `json:"phase" protobuf:"bytes,1,opt,name=phase,casttype=StepPhase"`
-	Reason         string         `json:"reason,omitempty" protobuf:"bytes,8,opt,name=reason"`
-	Message        string         `json:"message,omitempty" protobuf:"bytes,2,opt,name=message"`
-	Replicas       uint32         `json:"replicas" protobuf:"varint,5,opt,name=replicas"`
-	Selector       string         `json:"selector,omitempty" protobuf:"bytes,7,opt,name=selector"`
-	LastScaledAt   metav1.Time    `json:"lastScaledAt,omitempty" protobuf:"bytes,6,opt,name=lastScaledAt"`
-	SourceStatuses SourceStatuses `json:"sourceStatuses,omitempty" protobuf:"bytes,3,rep,name=sourceStatuses"`
-	SinkStatues    SourceStatuses `json:"sinkStatuses,omitempty" protobuf:"bytes,4,rep,name=sinkStatuses"`
+	Phase          StepPhase          `json:"phase" protobuf:"bytes,1,opt,name=phase,casttype=StepPhase"`
+	Reason         string             `json:"reason,omitempty" protobuf:"bytes,8,opt,name=reason"`
+	Message        string             `json:"message,omitempty" protobuf:"bytes,2,opt,name=message"`
+	Replicas       uint32             `json:"replicas" protobuf:"varint,5,opt,name=replicas"`
+	Selector       string             `json:"selector,omitempty" protobuf:"bytes,7,opt,name=selector"`
+	LastScaledAt   metav1.Time        `json:"lastScaledAt,omitempty" protobuf:"bytes,6,opt,name=lastScaledAt"`
+	SourceStatuses SourceStatuses     `json:"sourceStatuses,omitempty" protobuf:"bytes,3,rep,name=sourceStatuses"`
+	SinkStatues    SourceStatuses     `json:"sinkStatuses,omitempty" protobuf:"bytes,4,rep,name=sinkStatuses"`
+	Conditions     []metav1.Condition `json:"conditions,omitempty" protobuf:"bytes,9,rep,name=conditions"`
+	// Watchdog records, for each replica, why the watchdog last found the main container hung.
+	Watchdog WatchdogStatuses `json:"watchdog,omitempty" protobuf:"bytes,10,rep,name=watchdog"`
+	// SourceErrorWindows and SinkErrorWindows are each source and sink's errors over the last complete window.
+	SourceErrorWindows ErrorWindows `json:"sourceErrorWindows,omitempty" protobuf:"bytes,11,rep,name=sourceErrorWindows"`
+	SinkErrorWindows   ErrorWindows `json:"sinkErrorWindows,omitempty" protobuf:"bytes,12,rep,name=sinkErrorWindows"`
 }
 
 func (m StepStatus) GetReplicas() int {
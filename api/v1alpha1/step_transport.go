@@ -0,0 +1,190 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:validation:Enum=STAN;Kafka
+type StepTransportType string
+
+const (
+	StepTransportSTAN  StepTransportType = "STAN"
+	StepTransportKafka StepTransportType = "Kafka"
+)
+
+// StepTransport is how messages are sent between steps that are wired together using `step:`. There is no HTTP
+// transport, as HTTP does not store messages while the receiving step is unavailable.
+type StepTransport struct {
+	// +kubebuilder:default=STAN
+	Type StepTransportType `json:"type,omitempty" protobuf:"bytes,1,opt,name=type,casttype=StepTransportType"`
+	// Name is the name of the connection, or secret, to use
+	// +kubebuilder:default=default
+	Name string `json:"name,omitempty" protobuf:"bytes,2,opt,name=name"`
+}
+
+func (in *StepTransport) getType() StepTransportType {
+	if in == nil || in.Type == "" {
+		return StepTransportSTAN
+	}
+	return in.Type
+}
+
+func (in *StepTransport) getName() string {
+	if in == nil || in.Name == "" {
+		return "default"
+	}
+	return in.Name
+}
+
+// the same as the API server's default for a source
+var defaultRetry = Backoff{
+	Duration:         metav1.Duration{Duration: 100 * time.Millisecond},
+	FactorPercentage: 200,
+	Steps:            20,
+	JitterPercentage: 10,
+}
+
+type stepEdge struct{ from, to string }
+
+// WireSteps resolves the sources and sinks that name another step, to sinks and sources using the transport. An edge
+// only needs to be declared on one of its steps, the other side is added if missing.
+func (in *PipelineSpec) WireSteps(namespace, pipelineName string) error {
+	var edges []stepEdge
+	seen := map[stepEdge]bool{}
+	add := func(e stepEdge) error {
+		if !in.HasStep(e.from) {
+			return fmt.Errorf("step %q is wired to step %q, which does not exist", e.to, e.from)
+		}
+		if !in.HasStep(e.to) {
+			return fmt.Errorf("step %q is wired to step %q, which does not exist", e.from, e.to)
+		}
+		if e.from == e.to {
+			return fmt.Errorf("step %q cannot be wired to itself", e.from)
+		}
+		for _, name := range []string{e.from, e.to} {
+			if strings.Contains(name, ".") {
+				return fmt.Errorf("step %q cannot be wired to another step, because its name contains \".\"", name)
+			}
+		}
+		if !seen[e] {
+			edges = append(edges, e)
+			seen[e] = true
+		}
+		return nil
+	}
+	if err := in.nameStepSinksAndSources(); err != nil {
+		return err
+	}
+	for _, step := range in.Steps {
+		for _, s := range step.Sinks {
+			if s.Step != "" {
+				if err := add(stepEdge{step.Name, s.Step}); err != nil {
+					return err
+				}
+			}
+		}
+		for _, s := range step.Sources {
+			if s.Step != "" {
+				if err := add(stepEdge{s.Step, step.Name}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, e := range edges {
+		for i, step := range in.Steps {
+			switch step.Name {
+			case e.from:
+				if !step.hasSinkToStep(e.to) {
+					if step.hasSinkNamed(e.to) {
+						return fmt.Errorf("step %q already has a sink named %q", step.Name, e.to)
+					}
+					in.Steps[i].Sinks = append(in.Steps[i].Sinks, Sink{Name: e.to, Step: e.to})
+				}
+			case e.to:
+				if !step.hasSourceFromStep(e.from) {
+					if step.hasSourceNamed(e.from) {
+						return fmt.Errorf("step %q already has a source named %q", step.Name, e.from)
+					}
+					in.Steps[i].Sources = append(in.Steps[i].Sources, Source{Name: e.from, Step: e.from, Retry: defaultRetry})
+				}
+			}
+		}
+	}
+	for j := range in.Steps {
+		step := in.Steps[j]
+		for i, s := range step.Sinks {
+			if s.Step == "" {
+				continue
+			}
+			switch in.Transport.getType() {
+			case StepTransportKafka:
+				step.Sinks[i].Kafka = &Kafka{Name: in.Transport.getName(), Topic: stepTopic(namespace, pipelineName, step.Name, s.Step)}
+			default:
+				step.Sinks[i].STAN = &STAN{Name: in.Transport.getName(), Subject: stepSubject(step.Name, s.Step), SubjectPrefix: SubjectPrefixNamespacedPipelineName}
+			}
+		}
+		for i, s := range step.Sources {
+			if s.Step == "" {
+				continue
+			}
+			switch in.Transport.getType() {
+			case StepTransportKafka:
+				step.Sources[i].Kafka = &KafkaSource{Kafka: Kafka{Name: in.Transport.getName(), Topic: stepTopic(namespace, pipelineName, s.Step, step.Name)}}
+			default:
+				step.Sources[i].STAN = &STAN{Name: in.Transport.getName(), Subject: stepSubject(s.Step, step.Name), SubjectPrefix: SubjectPrefixNamespacedPipelineName}
+			}
+		}
+	}
+	return nil
+}
+
+// nameStepSinksAndSources names each sink and source that names another step, but is unnamed (or has the default
+// name), after that step, so that a step can have more than one, and returns an error if any is also another type
+func (in *PipelineSpec) nameStepSinksAndSources() error {
+	for i, step := range in.Steps {
+		for j, s := range step.Sinks {
+			if s.Step == "" {
+				continue
+			}
+			if t := s.getType(); t != "step" {
+				return fmt.Errorf("step %q sink %q cannot be both a step and %s sink", step.Name, s.Name, t)
+			}
+			if (s.Name == "" || s.Name == "default") && s.Name != s.Step {
+				if step.hasSinkNamed(s.Step) {
+					return fmt.Errorf("step %q already has a sink named %q", step.Name, s.Step)
+				}
+				in.Steps[i].Sinks[j].Name = s.Step
+			}
+		}
+		for j, s := range step.Sources {
+			if s.Step == "" {
+				continue
+			}
+			if t := s.getType(); t != "step" {
+				return fmt.Errorf("step %q source %q cannot be both a step and %s source", step.Name, s.Name, t)
+			}
+			if (s.Name == "" || s.Name == "default") && s.Name != s.Step {
+				if step.hasSourceNamed(s.Step) {
+					return fmt.Errorf("step %q already has a source named %q", step.Name, s.Step)
+				}
+				in.Steps[i].Sources[j].Name = s.Step
+			}
+		}
+	}
+	return nil
+}
+
+// step names are joined with ".", which wired steps' names cannot contain, so that each edge has its own subject or
+// topic, e.g. a -> b-c and a-b -> c
+func stepSubject(from, to string) string {
+	return fmt.Sprintf("%s.%s", from, to)
+}
+
+func stepTopic(namespace, pipelineName, from, to string) string {
+	return fmt.Sprintf("dataflow.%s.%s.%s.%s", namespace, pipelineName, from, to)
+}
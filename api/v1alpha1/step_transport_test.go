@@ -0,0 +1,131 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineSpec_WireSteps(t *testing.T) {
+	t.Run("DeclaredOnSink", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sinks: []Sink{{Name: "default", Step: "b"}}},
+			{Name: "b"},
+		}}
+		assert.NoError(t, spec.WireSteps("my-ns", "my-pl"))
+		if assert.NotNil(t, spec.Steps[0].Sinks[0].STAN) {
+			assert.Equal(t, "a.b", spec.Steps[0].Sinks[0].STAN.Subject)
+			assert.Equal(t, SubjectPrefixNamespacedPipelineName, spec.Steps[0].Sinks[0].STAN.SubjectPrefix)
+		}
+		if assert.Len(t, spec.Steps[1].Sources, 1) {
+			s := spec.Steps[1].Sources[0]
+			assert.Equal(t, "a", s.Name)
+			assert.Equal(t, "a", s.Step)
+			assert.Equal(t, defaultRetry, s.Retry)
+			if assert.NotNil(t, s.STAN) {
+				assert.Equal(t, "a.b", s.STAN.Subject)
+			}
+		}
+	})
+	t.Run("DeclaredOnBoth", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sinks: []Sink{{Name: "default", Step: "b"}}},
+			{Name: "b", Sources: []Source{{Name: "default", Step: "a"}}},
+		}}
+		assert.NoError(t, spec.WireSteps("my-ns", "my-pl"))
+		assert.Len(t, spec.Steps[0].Sinks, 1)
+		assert.Len(t, spec.Steps[1].Sources, 1)
+	})
+	t.Run("Kafka", func(t *testing.T) {
+		spec := &PipelineSpec{
+			Transport: &StepTransport{Type: StepTransportKafka, Name: "my-kafka"},
+			Steps: []StepSpec{
+				{Name: "a"},
+				{Name: "b", Sources: []Source{{Name: "default", Step: "a"}}},
+			},
+		}
+		assert.NoError(t, spec.WireSteps("my-ns", "my-pl"))
+		if assert.Len(t, spec.Steps[0].Sinks, 1) && assert.NotNil(t, spec.Steps[0].Sinks[0].Kafka) {
+			assert.Equal(t, "b", spec.Steps[0].Sinks[0].Name)
+			assert.Equal(t, "my-kafka", spec.Steps[0].Sinks[0].Kafka.Name)
+			assert.Equal(t, "dataflow.my-ns.my-pl.a.b", spec.Steps[0].Sinks[0].Kafka.Topic)
+		}
+		if assert.NotNil(t, spec.Steps[1].Sources[0].Kafka) {
+			assert.Equal(t, "dataflow.my-ns.my-pl.a.b", spec.Steps[1].Sources[0].Kafka.Topic)
+		}
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{{Name: "a", Sinks: []Sink{{Step: "b"}}}}}
+		assert.EqualError(t, spec.WireSteps("my-ns", "my-pl"), `step "a" is wired to step "b", which does not exist`)
+	})
+	t.Run("SourceNotFound", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{{Name: "b", Sources: []Source{{Step: "a"}}}}}
+		assert.EqualError(t, spec.WireSteps("my-ns", "my-pl"), `step "b" is wired to step "a", which does not exist`)
+	})
+	t.Run("Self", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{{Name: "a", Sinks: []Sink{{Step: "a"}}}}}
+		assert.Error(t, spec.WireSteps("my-ns", "my-pl"))
+	})
+	t.Run("Hyphenated", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sinks: []Sink{{Step: "b-c"}}},
+			{Name: "a-b", Sinks: []Sink{{Step: "c"}}},
+			{Name: "b-c"},
+			{Name: "c"},
+		}}
+		assert.NoError(t, spec.WireSteps("my-ns", "my-pl"))
+		assert.Equal(t, "a.b-c", spec.Steps[0].Sinks[0].STAN.Subject)
+		assert.Equal(t, "a-b.c", spec.Steps[1].Sinks[0].STAN.Subject)
+	})
+	t.Run("Dotted", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a.b", Sinks: []Sink{{Step: "c"}}},
+			{Name: "c"},
+		}}
+		assert.EqualError(t, spec.WireSteps("my-ns", "my-pl"), `step "a.b" cannot be wired to another step, because its name contains "."`)
+	})
+	t.Run("Unnamed", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sinks: []Sink{{Name: "default", Step: "b"}, {Step: "c"}}},
+			{Name: "b"},
+			{Name: "c", Sources: []Source{{Name: "default", Step: "b"}}},
+		}}
+		assert.NoError(t, spec.WireSteps("my-ns", "my-pl"))
+		if assert.Len(t, spec.Steps[0].Sinks, 2) {
+			assert.Equal(t, "b", spec.Steps[0].Sinks[0].Name)
+			assert.Equal(t, "c", spec.Steps[0].Sinks[1].Name)
+		}
+		if assert.Len(t, spec.Steps[2].Sources, 2) {
+			assert.Equal(t, "b", spec.Steps[2].Sources[0].Name)
+			assert.Equal(t, "a", spec.Steps[2].Sources[1].Name)
+		}
+	})
+	t.Run("UnnamedClash", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sinks: []Sink{{Name: "b", Log: &Log{}}, {Step: "b"}}},
+			{Name: "b"},
+		}}
+		assert.EqualError(t, spec.WireSteps("my-ns", "my-pl"), `step "a" already has a sink named "b"`)
+	})
+	t.Run("SinkWithOtherType", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sinks: []Sink{{Name: "default", Step: "b", Log: &Log{}}}},
+			{Name: "b"},
+		}}
+		assert.EqualError(t, spec.WireSteps("my-ns", "my-pl"), `step "a" sink "default" cannot be both a step and log sink`)
+	})
+	t.Run("SourceWithOtherType", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a"},
+			{Name: "b", Sources: []Source{{Name: "default", Step: "a", Cron: &Cron{}}}},
+		}}
+		assert.EqualError(t, spec.WireSteps("my-ns", "my-pl"), `step "b" source "default" cannot be both a step and cron source`)
+	})
+	t.Run("NameClash", func(t *testing.T) {
+		spec := &PipelineSpec{Steps: []StepSpec{
+			{Name: "a", Sinks: []Sink{{Name: "default", Step: "b"}}},
+			{Name: "b", Sources: []Source{{Name: "a", Cron: &Cron{}}}},
+		}}
+		assert.EqualError(t, spec.WireSteps("my-ns", "my-pl"), `step "b" already has a source named "a"`)
+	})
+}
@@ -45,7 +45,7 @@ type Step struct {
 	Status StepStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
 }
 
-func (in Step) GetPodSpec(req GetPodSpecReq) corev1.PodSpec {
+func (in Step) GetPodSpec(req GetPodSpecReq) (corev1.PodSpec, error) {
 	volume := corev1.Volume{
 		Name:         "var-run-argo-dataflow",
 		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
@@ -62,14 +62,31 @@ func (in Step) GetPodSpec(req GetPodSpecReq) corev1.PodSpec {
 		{Name: EnvUpdateInterval, Value: req.UpdateInterval.String()},
 		{Name: "GODEBUG", Value: os.Getenv("GODEBUG")},
 	}
+	if req.AggregateStepStatus {
+		envVars = append(envVars, corev1.EnvVar{Name: EnvAggregateStepStatus, Value: "true"})
+	}
+	sidecarResources := req.getSidecarResources()
 	dropAll := &corev1.SecurityContext{
 		Capabilities: &corev1.Capabilities{
 			Drop: []corev1.Capability{"all"},
 		},
 		AllowPrivilegeEscalation: pointer.BoolPtr(false),
 	}
-	return corev1.PodSpec{
-		Volumes: append(in.Spec.Volumes, volume, corev1.Volume{
+	var initContainers, sidecars []corev1.Container
+	if x := in.Spec.Container; x != nil {
+		if err := x.validate(); err != nil {
+			return corev1.PodSpec{}, err
+		}
+		initContainers, sidecars = x.InitContainers, x.Sidecars
+	}
+	if err := in.Spec.validateVolumeClaimTemplates(); err != nil {
+		return corev1.PodSpec{}, err
+	}
+	if err := in.Spec.validateBoundedSources(); err != nil {
+		return corev1.PodSpec{}, err
+	}
+	return in.Spec.PodTemplate.applyTo(corev1.PodSpec{
+		Volumes: append(in.getVolumes(int(req.Replica)), volume, corev1.Volume{
 			Name: "ssh",
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
@@ -79,15 +96,12 @@ func (in Step) GetPodSpec(req GetPodSpecReq) corev1.PodSpec {
 			},
 		}),
 		RestartPolicy:      in.Spec.RestartPolicy,
-		NodeSelector:       in.Spec.NodeSelector,
+		NodeSelector:       in.getNodeSelector(req.NodeSelector),
 		ServiceAccountName: in.Spec.ServiceAccountName,
-		SecurityContext: &corev1.PodSecurityContext{
-			RunAsNonRoot: pointer.BoolPtr(true),
-			RunAsUser:    pointer.Int64Ptr(9653),
-		},
-		Affinity:    in.Spec.Affinity,
-		Tolerations: in.Spec.Tolerations,
-		InitContainers: []corev1.Container{
+		SecurityContext:    req.getPodSecurityContext(),
+		Affinity:           in.Spec.Affinity,
+		Tolerations:        in.getTolerations(req.Tolerations),
+		InitContainers: append([]corev1.Container{
 			{
 				Name:            CtrInit,
 				Image:           req.RunnerImage,
@@ -99,11 +113,11 @@ func (in Step) GetPodSpec(req GetPodSpecReq) corev1.PodSpec {
 					ReadOnly:  true,
 					MountPath: "/.ssh",
 				}),
-				Resources:       standardResources,
+				Resources:       sidecarResources,
 				SecurityContext: dropAll,
 			},
-		},
-		Containers: []corev1.Container{
+		}, initContainers...),
+		Containers: append([]corev1.Container{
 			{
 				Name:            CtrSidecar,
 				Image:           req.RunnerImage,
@@ -111,7 +125,7 @@ func (in Step) GetPodSpec(req GetPodSpecReq) corev1.PodSpec {
 				Args:            []string{"sidecar"},
 				Env:             envVars,
 				VolumeMounts:    volumeMounts,
-				Resources:       standardResources,
+				Resources:       sidecarResources,
 				Ports: []corev1.ContainerPort{
 					{ContainerPort: 3570},
 				},
@@ -145,8 +159,28 @@ func (in Step) GetPodSpec(req GetPodSpecReq) corev1.PodSpec {
 				securityContext: dropAll,
 				volumeMount:     corev1.VolumeMount{Name: "var-run-argo-dataflow", MountPath: "/var/run/argo-dataflow"},
 			}),
-		},
+		}, sidecars...),
+	})
+}
+
+func (in Step) getNodeSelector(defaults map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return in.Spec.NodeSelector
+	}
+	x := map[string]string{}
+	for k, v := range defaults {
+		x[k] = v
 	}
+	for k, v := range in.Spec.NodeSelector {
+		x[k] = v
+	}
+	return x
+}
+
+// getTolerations returns a new slice, so appending the defaults cannot write into the spec's tolerations
+func (in Step) getTolerations(defaults []corev1.Toleration) []corev1.Toleration {
+	x := make([]corev1.Toleration, 0, len(in.Spec.Tolerations)+len(defaults))
+	return append(append(x, in.Spec.Tolerations...), defaults...)
 }
 
 func (in Step) withoutManagedFields() Step {
@@ -155,7 +189,22 @@ func (in Step) withoutManagedFields() Step {
 	return y
 }
 
+// IsActivated returns true if the activator scaled the step up from zero within the scaling delay
+func (in Step) IsActivated(scalingDelay time.Duration) bool {
+	activatedAt, err := time.Parse(time.RFC3339, in.GetAnnotations()[KeyActivatedAt])
+	return err == nil && time.Since(activatedAt) < scalingDelay
+}
+
 func (in Step) GetTargetReplicas(scalingDelay, peekDelay time.Duration) int {
+	targetReplicas := in.getTargetReplicas(scalingDelay, peekDelay)
+	// an activated step must keep a replica to serve the requests the activator is holding
+	if targetReplicas < 1 && in.IsActivated(scalingDelay) {
+		return 1
+	}
+	return targetReplicas
+}
+
+func (in Step) getTargetReplicas(scalingDelay, peekDelay time.Duration) int {
 	currentReplicas := int(in.Status.Replicas)
 	lastScaledAt := in.Status.LastScaledAt.Time
 
@@ -134,7 +134,9 @@ func TestStep_GetPodSpec(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a, _ := json.MarshalIndent(tt.step.GetPodSpec(tt.req), "", "  ")
+			podSpec, err := tt.step.GetPodSpec(tt.req)
+			assert.NoError(t, err)
+			a, _ := json.MarshalIndent(podSpec, "", "  ")
 			b, _ := json.MarshalIndent(tt.want, "", "  ")
 			assert.Equal(t, string(b), string(a))
 		})
@@ -214,3 +216,44 @@ func TestStep_GetTargetReplicas(t *testing.T) {
 		})
 	})
 }
+
+func TestStep_GetTargetReplicas_Activated(t *testing.T) {
+	scalingDelay := time.Minute
+	peekDelay := 4 * time.Minute
+	activated := func(at time.Time) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Annotations: map[string]string{KeyActivatedAt: at.Format(time.RFC3339)}}
+	}
+	t.Run("Activated", func(t *testing.T) {
+		s := &Step{ObjectMeta: activated(time.Now()), Spec: StepSpec{Scale: &Scale{}, Replicas: 1}, Status: StepStatus{LastScaledAt: metav1.Now()}}
+		assert.True(t, s.IsActivated(scalingDelay))
+		assert.Equal(t, 1, s.GetTargetReplicas(scalingDelay, peekDelay))
+	})
+	t.Run("Expired", func(t *testing.T) {
+		s := &Step{ObjectMeta: activated(time.Now().Add(-2 * time.Minute)), Spec: StepSpec{Scale: &Scale{}}, Status: StepStatus{LastScaledAt: metav1.Now(), Replicas: 1}}
+		assert.False(t, s.IsActivated(scalingDelay))
+		assert.Equal(t, 1, s.GetTargetReplicas(scalingDelay, peekDelay))
+		s.Status.LastScaledAt = metav1.Time{}
+		assert.Equal(t, 0, s.GetTargetReplicas(scalingDelay, peekDelay))
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		s := &Step{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{KeyActivatedAt: "foo"}}}
+		assert.False(t, s.IsActivated(scalingDelay))
+	})
+}
+
+func TestStep_getTolerations(t *testing.T) {
+	tolerations := make([]corev1.Toleration, 1, 2) // spare capacity, so a naive append would write into it
+	tolerations[0] = corev1.Toleration{Key: "a"}
+	s := Step{Spec: StepSpec{Tolerations: tolerations}}
+	b := s.getTolerations([]corev1.Toleration{{Key: "b"}})
+	c := s.getTolerations([]corev1.Toleration{{Key: "c"}})
+	assert.Equal(t, []corev1.Toleration{{Key: "a"}, {Key: "b"}}, b)
+	assert.Equal(t, []corev1.Toleration{{Key: "a"}, {Key: "c"}}, c)
+	assert.Equal(t, corev1.Toleration{}, tolerations[:2][1], "the spec's backing array is not written")
+}
+
+func TestStep_getNodeSelector(t *testing.T) {
+	s := Step{Spec: StepSpec{NodeSelector: map[string]string{"a": "1"}}}
+	assert.Equal(t, map[string]string{"a": "1"}, s.getNodeSelector(nil))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, s.getNodeSelector(map[string]string{"a": "0", "b": "2"}))
+}
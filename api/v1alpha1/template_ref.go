@@ -0,0 +1,7 @@
+package v1alpha1
+
+// TemplateRef references a pipeline template in the same namespace.
+type TemplateRef struct {
+	Name      string            `json:"name" protobuf:"bytes,1,opt,name=name"`
+	Arguments map[string]string `json:"arguments,omitempty" protobuf:"bytes,2,rep,name=arguments"` // parameter values, keyed by name
+}
@@ -0,0 +1,12 @@
+package v1alpha1
+
+// TemplateStatus records the template a pipeline's spec was rendered from.
+type TemplateStatus struct {
+	Name       string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	Generation int64  `json:"generation" protobuf:"varint,2,opt,name=generation"`
+	// The spec rendered from the template.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec PipelineSpec `json:"spec" protobuf:"bytes,3,opt,name=spec"`
+}
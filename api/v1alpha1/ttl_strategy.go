@@ -0,0 +1,27 @@
+package v1alpha1
+
+import "time"
+
+// TTLStrategy is how long to keep a pipeline after it completes, before it is deleted.
+type TTLStrategy struct {
+	SecondsAfterSuccess *int32 `json:"secondsAfterSuccess,omitempty" protobuf:"varint,1,opt,name=secondsAfterSuccess"`
+	SecondsAfterFailure *int32 `json:"secondsAfterFailure,omitempty" protobuf:"varint,2,opt,name=secondsAfterFailure"`
+}
+
+// GetDeletionDelay returns how long to keep a pipeline in the phase, or the default if not specified.
+func (in *TTLStrategy) GetDeletionDelay(phase PipelinePhase, def time.Duration) time.Duration {
+	if in == nil {
+		return def
+	}
+	var x *int32
+	switch phase {
+	case PipelineSucceeded:
+		x = in.SecondsAfterSuccess
+	case PipelineFailed:
+		x = in.SecondsAfterFailure
+	}
+	if x == nil {
+		return def
+	}
+	return time.Duration(*x) * time.Second
+}
@@ -0,0 +1,19 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/pointer"
+)
+
+func TestTTLStrategy_GetDeletionDelay(t *testing.T) {
+	var x *TTLStrategy
+	assert.Equal(t, time.Hour, x.GetDeletionDelay(PipelineSucceeded, time.Hour))
+	x = &TTLStrategy{SecondsAfterSuccess: pointer.Int32Ptr(10)}
+	assert.Equal(t, 10*time.Second, x.GetDeletionDelay(PipelineSucceeded, time.Hour))
+	assert.Equal(t, time.Hour, x.GetDeletionDelay(PipelineFailed, time.Hour))
+	x = &TTLStrategy{SecondsAfterFailure: pointer.Int32Ptr(0)}
+	assert.Equal(t, time.Duration(0), x.GetDeletionDelay(PipelineFailed, time.Hour))
+}
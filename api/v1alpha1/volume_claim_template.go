@@ -0,0 +1,81 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// VolumeClaimTemplate is used to create a persistent volume claim for each replica, named
+// `${claimName}-${stepName}-${replica}`, which is kept when the replica's pod is replaced. The claim is available to
+// the pod as a volume with the template's name.
+type VolumeClaimTemplate struct {
+	Name     string                           `json:"name" protobuf:"bytes,1,opt,name=name"`
+	Metadata *Metadata                        `json:"metadata,omitempty" protobuf:"bytes,2,opt,name=metadata"`
+	Spec     corev1.PersistentVolumeClaimSpec `json:"spec" protobuf:"bytes,3,opt,name=spec"`
+}
+
+// +kubebuilder:validation:Enum=Retain;Delete
+type PersistentVolumeClaimRetentionPolicyType string
+
+const (
+	RetainPersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Retain"
+	DeletePersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Delete"
+)
+
+// PersistentVolumeClaimRetentionPolicy describes when the claims created from the volume claim templates are deleted.
+type PersistentVolumeClaimRetentionPolicy struct {
+	// WhenDeleted is what happens to the claims when the step is deleted (e.g. because the pipeline was deleted).
+	// +kubebuilder:default=Retain
+	WhenDeleted PersistentVolumeClaimRetentionPolicyType `json:"whenDeleted,omitempty" protobuf:"bytes,1,opt,name=whenDeleted,casttype=PersistentVolumeClaimRetentionPolicyType"`
+	// WhenScaled is what happens to the claims of the removed replicas when the step is scaled down.
+	// +kubebuilder:default=Retain
+	WhenScaled PersistentVolumeClaimRetentionPolicyType `json:"whenScaled,omitempty" protobuf:"bytes,2,opt,name=whenScaled,casttype=PersistentVolumeClaimRetentionPolicyType"`
+}
+
+func (in *PersistentVolumeClaimRetentionPolicy) DeleteWhenDeleted() bool {
+	return in != nil && in.WhenDeleted == DeletePersistentVolumeClaimRetentionPolicyType
+}
+
+func (in *PersistentVolumeClaimRetentionPolicy) DeleteWhenScaled() bool {
+	return in != nil && in.WhenScaled == DeletePersistentVolumeClaimRetentionPolicyType
+}
+
+// GetClaimName returns the name of the claim for the template and replica.
+func (in Step) GetClaimName(template string, replica int) string {
+	return fmt.Sprintf("%s-%s-%d", template, in.Name, replica)
+}
+
+// getVolumes returns the spec's volumes and a volume for each claim template, in a new slice, so appending to it does
+// not write into the spec's volumes
+func (in Step) getVolumes(replica int) []corev1.Volume {
+	volumes := make([]corev1.Volume, 0, len(in.Spec.Volumes)+len(in.Spec.VolumeClaimTemplates))
+	volumes = append(volumes, in.Spec.Volumes...)
+	for _, t := range in.Spec.VolumeClaimTemplates {
+		volumes = append(volumes, corev1.Volume{
+			Name: t.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: in.GetClaimName(t.Name, replica)},
+			},
+		})
+	}
+	return volumes
+}
+
+// validateVolumeClaimTemplates returns an error if a template is unnamed, or has the same name as another volume
+func (in StepSpec) validateVolumeClaimTemplates() error {
+	names := map[string]bool{}
+	for _, v := range in.Volumes {
+		names[v.Name] = true
+	}
+	for _, t := range in.VolumeClaimTemplates {
+		if t.Name == "" {
+			return fmt.Errorf("volume claim template must have a name")
+		}
+		if names[t.Name] {
+			return fmt.Errorf("volume claim template %q has the same name as another volume", t.Name)
+		}
+		names[t.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStep_GetPodSpec_VolumeClaimTemplates(t *testing.T) {
+	step := Step{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-pl-main"},
+		Spec: StepSpec{
+			Name:                 "main",
+			Cat:                  &Cat{},
+			VolumeClaimTemplates: []VolumeClaimTemplate{{Name: "state"}},
+		},
+	}
+	podSpec, err := step.GetPodSpec(GetPodSpecReq{Replica: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, podSpec.Volumes, 3) {
+		assert.Equal(t, corev1.Volume{
+			Name: "state",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "state-my-pl-main-2"},
+			},
+		}, podSpec.Volumes[0])
+	}
+}
+
+func TestStep_getVolumes(t *testing.T) {
+	volumes := make([]corev1.Volume, 1, 2) // spare capacity, so a naive append would write into it
+	volumes[0] = corev1.Volume{Name: "my-vol"}
+	s := Step{ObjectMeta: metav1.ObjectMeta{Name: "my-pl-main"}, Spec: StepSpec{Volumes: volumes, VolumeClaimTemplates: []VolumeClaimTemplate{{Name: "state"}}}}
+	x := s.getVolumes(1)
+	if assert.Len(t, x, 2) {
+		assert.Equal(t, "my-vol", x[0].Name)
+		assert.Equal(t, "state-my-pl-main-1", x[1].PersistentVolumeClaim.ClaimName)
+	}
+	assert.Equal(t, corev1.Volume{}, volumes[:2][1], "the spec's backing array is not written")
+}
+
+func TestStepSpec_validateVolumeClaimTemplates(t *testing.T) {
+	assert.NoError(t, StepSpec{}.validateVolumeClaimTemplates())
+	assert.NoError(t, StepSpec{VolumeClaimTemplates: []VolumeClaimTemplate{{Name: "a"}, {Name: "b"}}}.validateVolumeClaimTemplates())
+	assert.EqualError(t, StepSpec{VolumeClaimTemplates: []VolumeClaimTemplate{{}}}.validateVolumeClaimTemplates(), "volume claim template must have a name")
+	assert.Error(t, StepSpec{
+		Volumes:              []corev1.Volume{{Name: "a"}},
+		VolumeClaimTemplates: []VolumeClaimTemplate{{Name: "a"}},
+	}.validateVolumeClaimTemplates())
+}
+
+func TestPersistentVolumeClaimRetentionPolicy(t *testing.T) {
+	var x *PersistentVolumeClaimRetentionPolicy
+	assert.False(t, x.DeleteWhenDeleted())
+	assert.False(t, x.DeleteWhenScaled())
+	x = &PersistentVolumeClaimRetentionPolicy{WhenDeleted: DeletePersistentVolumeClaimRetentionPolicyType, WhenScaled: RetainPersistentVolumeClaimRetentionPolicyType}
+	assert.True(t, x.DeleteWhenDeleted())
+	assert.False(t, x.DeleteWhenScaled())
+}
@@ -0,0 +1,52 @@
+package v1alpha1
+
+import (
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Watchdog is run by the sidecar to detect a hung main container, which is then restarted.
+type Watchdog struct {
+	// Timeout is how long the main container may be un-ready, or have messages in-flight without any completing,
+	// before it is considered hung.
+	// +kubebuilder:default="5m"
+	Timeout metav1.Duration `json:"timeout,omitempty" protobuf:"bytes,1,opt,name=timeout"`
+}
+
+func (in Watchdog) GetTimeout() time.Duration {
+	if in.Timeout.Duration > 0 {
+		return in.Timeout.Duration
+	}
+	return 5 * time.Minute
+}
+
+const (
+	WatchdogReasonUnready = "Unready" // the main container did not respond to `/ready`
+	WatchdogReasonStuck   = "Stuck"   // the main container has messages in-flight, but none have completed
+)
+
+type WatchdogStatus struct {
+	Reason     string      `json:"reason" protobuf:"bytes,1,opt,name=reason"`
+	Message    string      `json:"message,omitempty" protobuf:"bytes,2,opt,name=message"`
+	DetectedAt metav1.Time `json:"detectedAt" protobuf:"bytes,3,opt,name=detectedAt"`
+}
+
+type WatchdogStatuses map[string]WatchdogStatus // key is replica
+
+func (in WatchdogStatuses) Get(replica int) (WatchdogStatus, bool) {
+	x, ok := in[strconv.Itoa(replica)]
+	return x, ok
+}
+
+func (in WatchdogStatuses) Set(replica int, reason, message string, now metav1.Time) {
+	in[strconv.Itoa(replica)] = WatchdogStatus{Reason: reason, Message: message, DetectedAt: now}
+}
+
+// MergeReplica copies the replica's status from the other statuses
+func (in WatchdogStatuses) MergeReplica(replica int, from WatchdogStatuses) {
+	if x, ok := from.Get(replica); ok {
+		in[strconv.Itoa(replica)] = x
+	}
+}
@@ -0,0 +1,36 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchdog_GetTimeout(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, Watchdog{}.GetTimeout())
+	assert.Equal(t, time.Minute, Watchdog{Timeout: metav1.Duration{Duration: time.Minute}}.GetTimeout())
+}
+
+func TestWatchdogStatuses(t *testing.T) {
+	now := metav1.Now()
+	x := WatchdogStatuses{}
+	_, ok := x.Get(1)
+	assert.False(t, ok)
+	x.Set(1, WatchdogReasonStuck, "foo", now)
+	s, ok := x.Get(1)
+	if assert.True(t, ok) {
+		assert.Equal(t, WatchdogReasonStuck, s.Reason)
+		assert.Equal(t, "foo", s.Message)
+		assert.Equal(t, now, s.DetectedAt)
+	}
+	t.Run("MergeReplica", func(t *testing.T) {
+		y := WatchdogStatuses{"0": {Reason: WatchdogReasonUnready}}
+		y.MergeReplica(1, x)
+		y.MergeReplica(2, x)
+		assert.Len(t, y, 2)
+		assert.Equal(t, WatchdogReasonUnready, y["0"].Reason)
+		assert.Equal(t, WatchdogReasonStuck, y["1"].Reason)
+	})
+}
@@ -21,9 +21,10 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -90,6 +91,65 @@ func (in *Cat) DeepCopy() *Cat {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterConnection) DeepCopyInto(out *ClusterConnection) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterConnection.
+func (in *ClusterConnection) DeepCopy() *ClusterConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterConnection) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterConnectionList) DeepCopyInto(out *ClusterConnectionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterConnection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterConnectionList.
+func (in *ClusterConnectionList) DeepCopy() *ClusterConnectionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterConnectionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterConnectionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Code) DeepCopyInto(out *Code) {
 	*out = *in
@@ -105,12 +165,128 @@ func (in *Code) DeepCopy() *Code {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Connection) DeepCopyInto(out *Connection) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Connection.
+func (in *Connection) DeepCopy() *Connection {
+	if in == nil {
+		return nil
+	}
+	out := new(Connection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Connection) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionList) DeepCopyInto(out *ConnectionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Connection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionList.
+func (in *ConnectionList) DeepCopy() *ConnectionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConnectionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionSpec) DeepCopyInto(out *ConnectionSpec) {
+	*out = *in
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.STAN != nil {
+		in, out := &in.STAN, &out.STAN
+		*out = new(STANConnection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3Connection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DB != nil {
+		in, out := &in.DB, &out.DB
+		*out = new(Database)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionSpec.
+func (in *ConnectionSpec) DeepCopy() *ConnectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionStatus) DeepCopyInto(out *ConnectionStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionStatus.
+func (in *ConnectionStatus) DeepCopy() *ConnectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Container) DeepCopyInto(out *Container) {
 	*out = *in
 	if in.VolumeMounts != nil {
 		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
+		*out = make([]corev1.VolumeMount, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -132,602 +308,1460 @@ func (in *Container) DeepCopyInto(out *Container) {
 	}
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]corev1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]corev1.ContainerPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(corev1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostStart != nil {
+		in, out := &in.PostStart, &out.PostStart
+		*out = new(corev1.Handler)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Container.
+func (in *Container) DeepCopy() *Container {
+	if in == nil {
+		return nil
+	}
+	out := new(Container)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Cron) DeepCopyInto(out *Cron) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cron.
+func (in *Cron) DeepCopy() *Cron {
+	if in == nil {
+		return nil
+	}
+	out := new(Cron)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronPipeline) DeepCopyInto(out *CronPipeline) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronPipeline.
+func (in *CronPipeline) DeepCopy() *CronPipeline {
+	if in == nil {
+		return nil
+	}
+	out := new(CronPipeline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CronPipeline) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronPipelineList) DeepCopyInto(out *CronPipelineList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CronPipeline, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	in.Resources.DeepCopyInto(&out.Resources)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Container.
-func (in *Container) DeepCopy() *Container {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronPipelineList.
+func (in *CronPipelineList) DeepCopy() *CronPipelineList {
+	if in == nil {
+		return nil
+	}
+	out := new(CronPipelineList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CronPipelineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronPipelineSpec) DeepCopyInto(out *CronPipelineSpec) {
+	*out = *in
+	if in.SuccessfulPipelinesHistoryLimit != nil {
+		in, out := &in.SuccessfulPipelinesHistoryLimit, &out.SuccessfulPipelinesHistoryLimit
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.FailedPipelinesHistoryLimit != nil {
+		in, out := &in.FailedPipelinesHistoryLimit, &out.FailedPipelinesHistoryLimit
+		*out = new(uint32)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronPipelineSpec.
+func (in *CronPipelineSpec) DeepCopy() *CronPipelineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CronPipelineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronPipelineStatus) DeepCopyInto(out *CronPipelineStatus) {
+	*out = *in
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronPipelineStatus.
+func (in *CronPipelineStatus) DeepCopy() *CronPipelineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CronPipelineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronPipelineTemplate) DeepCopyInto(out *CronPipelineTemplate) {
+	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = new(Metadata)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronPipelineTemplate.
+func (in *CronPipelineTemplate) DeepCopy() *CronPipelineTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(CronPipelineTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DBDataSource) DeepCopyInto(out *DBDataSource) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(DBDataSourceFrom)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DBDataSource.
+func (in *DBDataSource) DeepCopy() *DBDataSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DBDataSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DBDataSourceFrom) DeepCopyInto(out *DBDataSourceFrom) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DBDataSourceFrom.
+func (in *DBDataSourceFrom) DeepCopy() *DBDataSourceFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(DBDataSourceFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DBSink) DeepCopyInto(out *DBSink) {
+	*out = *in
+	in.Database.DeepCopyInto(&out.Database)
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]SQLAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DBSink.
+func (in *DBSink) DeepCopy() *DBSink {
+	if in == nil {
+		return nil
+	}
+	out := new(DBSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Database) DeepCopyInto(out *Database) {
+	*out = *in
+	if in.DataSource != nil {
+		in, out := &in.DataSource, &out.DataSource
+		*out = new(DBDataSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Database.
+func (in *Database) DeepCopy() *Database {
+	if in == nil {
+		return nil
+	}
+	out := new(Database)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataflowPolicy) DeepCopyInto(out *DataflowPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataflowPolicy.
+func (in *DataflowPolicy) DeepCopy() *DataflowPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DataflowPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataflowPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataflowPolicyList) DeepCopyInto(out *DataflowPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DataflowPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataflowPolicyList.
+func (in *DataflowPolicyList) DeepCopy() *DataflowPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(DataflowPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataflowPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataflowPolicySpec) DeepCopyInto(out *DataflowPolicySpec) {
+	*out = *in
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.MaxPods != nil {
+		in, out := &in.MaxPods, &out.MaxPods
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.AllowedImages != nil {
+		in, out := &in.AllowedImages, &out.AllowedImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedSources != nil {
+		in, out := &in.AllowedSources, &out.AllowedSources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedSinks != nil {
+		in, out := &in.AllowedSinks, &out.AllowedSinks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataflowPolicySpec.
+func (in *DataflowPolicySpec) DeepCopy() *DataflowPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DataflowPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Dedupe) DeepCopyInto(out *Dedupe) {
+	*out = *in
+	out.MaxSize = in.MaxSize.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Dedupe.
+func (in *Dedupe) DeepCopy() *Dedupe {
+	if in == nil {
+		return nil
+	}
+	out := new(Dedupe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Dependency) DeepCopyInto(out *Dependency) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Dependency.
+func (in *Dependency) DeepCopy() *Dependency {
+	if in == nil {
+		return nil
+	}
+	out := new(Dependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Edge) DeepCopyInto(out *Edge) {
+	*out = *in
+	out.Rate = in.Rate.DeepCopy()
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = new(uint64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Edge.
+func (in *Edge) DeepCopy() *Edge {
+	if in == nil {
+		return nil
+	}
+	out := new(Edge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErrorWindow) DeepCopyInto(out *ErrorWindow) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrorWindow.
+func (in *ErrorWindow) DeepCopy() *ErrorWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrorWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ErrorWindows) DeepCopyInto(out *ErrorWindows) {
+	{
+		in := &in
+		*out = make(ErrorWindows, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrorWindows.
+func (in ErrorWindows) DeepCopy() ErrorWindows {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrorWindows)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Expand) DeepCopyInto(out *Expand) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Expand.
+func (in *Expand) DeepCopy() *Expand {
+	if in == nil {
+		return nil
+	}
+	out := new(Expand)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Expose) DeepCopyInto(out *Expose) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ExposeTLS)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Expose.
+func (in *Expose) DeepCopy() *Expose {
+	if in == nil {
+		return nil
+	}
+	out := new(Expose)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposeTLS) DeepCopyInto(out *ExposeTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeTLS.
+func (in *ExposeTLS) DeepCopy() *ExposeTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposeTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Flatten) DeepCopyInto(out *Flatten) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Flatten.
+func (in *Flatten) DeepCopy() *Flatten {
+	if in == nil {
+		return nil
+	}
+	out := new(Flatten)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayReference) DeepCopyInto(out *GatewayReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayReference.
+func (in *GatewayReference) DeepCopy() *GatewayReference {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetPodSpecReq) DeepCopyInto(out *GetPodSpecReq) {
+	*out = *in
+	in.StepStatus.DeepCopyInto(&out.StepStatus)
+	in.SidecarResources.DeepCopyInto(&out.SidecarResources)
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetPodSpecReq.
+func (in *GetPodSpecReq) DeepCopy() *GetPodSpecReq {
+	if in == nil {
+		return nil
+	}
+	out := new(GetPodSpecReq)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Git) DeepCopyInto(out *Git) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UsernameSecret != nil {
+		in, out := &in.UsernameSecret, &out.UsernameSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PasswordSecret != nil {
+		in, out := &in.PasswordSecret, &out.PasswordSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSHPrivateKeySecret != nil {
+		in, out := &in.SSHPrivateKeySecret, &out.SSHPrivateKeySecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Git.
+func (in *Git) DeepCopy() *Git {
+	if in == nil {
+		return nil
+	}
+	out := new(Git)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Group) DeepCopyInto(out *Group) {
+	*out = *in
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Group.
+func (in *Group) DeepCopy() *Group {
 	if in == nil {
 		return nil
 	}
-	out := new(Container)
+	out := new(Group)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Cron) DeepCopyInto(out *Cron) {
+func (in *HTTP) DeepCopyInto(out *HTTP) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cron.
-func (in *Cron) DeepCopy() *Cron {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTP.
+func (in *HTTP) DeepCopy() *HTTP {
 	if in == nil {
 		return nil
 	}
-	out := new(Cron)
+	out := new(HTTP)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DBDataSource) DeepCopyInto(out *DBDataSource) {
+func (in *HTTPHeader) DeepCopyInto(out *HTTPHeader) {
 	*out = *in
 	if in.ValueFrom != nil {
 		in, out := &in.ValueFrom, &out.ValueFrom
-		*out = new(DBDataSourceFrom)
+		*out = new(HTTPHeaderSource)
 		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DBDataSource.
-func (in *DBDataSource) DeepCopy() *DBDataSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeader.
+func (in *HTTPHeader) DeepCopy() *HTTPHeader {
 	if in == nil {
 		return nil
 	}
-	out := new(DBDataSource)
+	out := new(HTTPHeader)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DBDataSourceFrom) DeepCopyInto(out *DBDataSourceFrom) {
+func (in *HTTPHeaderSource) DeepCopyInto(out *HTTPHeaderSource) {
 	*out = *in
-	if in.SecretKeyRef != nil {
-		in, out := &in.SecretKeyRef, &out.SecretKeyRef
-		*out = new(v1.SecretKeySelector)
-		(*in).DeepCopyInto(*out)
-	}
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DBDataSourceFrom.
-func (in *DBDataSourceFrom) DeepCopy() *DBDataSourceFrom {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeaderSource.
+func (in *HTTPHeaderSource) DeepCopy() *HTTPHeaderSource {
 	if in == nil {
 		return nil
 	}
-	out := new(DBDataSourceFrom)
+	out := new(HTTPHeaderSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DBSink) DeepCopyInto(out *DBSink) {
+func (in *HTTPSink) DeepCopyInto(out *HTTPSink) {
 	*out = *in
-	in.Database.DeepCopyInto(&out.Database)
-	if in.Actions != nil {
-		in, out := &in.Actions, &out.Actions
-		*out = make([]SQLAction, len(*in))
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HTTPHeader, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DBSink.
-func (in *DBSink) DeepCopy() *DBSink {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSink.
+func (in *HTTPSink) DeepCopy() *HTTPSink {
 	if in == nil {
 		return nil
 	}
-	out := new(DBSink)
+	out := new(HTTPSink)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Database) DeepCopyInto(out *Database) {
+func (in *HTTPSource) DeepCopyInto(out *HTTPSource) {
 	*out = *in
-	if in.DataSource != nil {
-		in, out := &in.DataSource, &out.DataSource
-		*out = new(DBDataSource)
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(Expose)
 		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Database.
-func (in *Database) DeepCopy() *Database {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSource.
+func (in *HTTPSource) DeepCopy() *HTTPSource {
 	if in == nil {
 		return nil
 	}
-	out := new(Database)
+	out := new(HTTPSource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Dedupe) DeepCopyInto(out *Dedupe) {
+func (in *Interface) DeepCopyInto(out *Interface) {
 	*out = *in
-	out.MaxSize = in.MaxSize.DeepCopy()
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTP)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Dedupe.
-func (in *Dedupe) DeepCopy() *Dedupe {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Interface.
+func (in *Interface) DeepCopy() *Interface {
 	if in == nil {
 		return nil
 	}
-	out := new(Dedupe)
+	out := new(Interface)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Expand) DeepCopyInto(out *Expand) {
+func (in *Kafka) DeepCopyInto(out *Kafka) {
 	*out = *in
+	in.KafkaConfig.DeepCopyInto(&out.KafkaConfig)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Expand.
-func (in *Expand) DeepCopy() *Expand {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kafka.
+func (in *Kafka) DeepCopy() *Kafka {
 	if in == nil {
 		return nil
 	}
-	out := new(Expand)
+	out := new(Kafka)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Flatten) DeepCopyInto(out *Flatten) {
+func (in *KafkaConfig) DeepCopyInto(out *KafkaConfig) {
 	*out = *in
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NET != nil {
+		in, out := &in.NET, &out.NET
+		*out = new(KafkaNET)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Flatten.
-func (in *Flatten) DeepCopy() *Flatten {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConfig.
+func (in *KafkaConfig) DeepCopy() *KafkaConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(Flatten)
+	out := new(KafkaConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GetPodSpecReq) DeepCopyInto(out *GetPodSpecReq) {
+func (in *KafkaNET) DeepCopyInto(out *KafkaNET) {
 	*out = *in
-	in.StepStatus.DeepCopyInto(&out.StepStatus)
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SASL != nil {
+		in, out := &in.SASL, &out.SASL
+		*out = new(SASL)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetPodSpecReq.
-func (in *GetPodSpecReq) DeepCopy() *GetPodSpecReq {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaNET.
+func (in *KafkaNET) DeepCopy() *KafkaNET {
 	if in == nil {
 		return nil
 	}
-	out := new(GetPodSpecReq)
+	out := new(KafkaNET)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Git) DeepCopyInto(out *Git) {
+func (in *KafkaSource) DeepCopyInto(out *KafkaSource) {
 	*out = *in
-	if in.Command != nil {
-		in, out := &in.Command, &out.Command
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.UsernameSecret != nil {
-		in, out := &in.UsernameSecret, &out.UsernameSecret
-		*out = new(v1.SecretKeySelector)
-		(*in).DeepCopyInto(*out)
+	in.Kafka.DeepCopyInto(&out.Kafka)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaSource.
+func (in *KafkaSource) DeepCopy() *KafkaSource {
+	if in == nil {
+		return nil
 	}
-	if in.PasswordSecret != nil {
-		in, out := &in.PasswordSecret, &out.PasswordSecret
-		*out = new(v1.SecretKeySelector)
-		(*in).DeepCopyInto(*out)
+	out := new(KafkaSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Log) DeepCopyInto(out *Log) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Log.
+func (in *Log) DeepCopy() *Log {
+	if in == nil {
+		return nil
 	}
-	if in.SSHPrivateKeySecret != nil {
-		in, out := &in.SSHPrivateKeySecret, &out.SSHPrivateKeySecret
-		*out = new(v1.SecretKeySelector)
-		(*in).DeepCopyInto(*out)
+	out := new(Log)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metadata) DeepCopyInto(out *Metadata) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
-	if in.Env != nil {
-		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Git.
-func (in *Git) DeepCopy() *Git {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata.
+func (in *Metadata) DeepCopy() *Metadata {
 	if in == nil {
 		return nil
 	}
-	out := new(Git)
+	out := new(Metadata)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Group) DeepCopyInto(out *Group) {
+func (in *Metrics) DeepCopyInto(out *Metrics) {
 	*out = *in
-	if in.Storage != nil {
-		in, out := &in.Storage, &out.Storage
-		*out = new(Storage)
-		**out = **in
-	}
+	out.Rate = in.Rate.DeepCopy()
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Group.
-func (in *Group) DeepCopy() *Group {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metrics.
+func (in *Metrics) DeepCopy() *Metrics {
 	if in == nil {
 		return nil
 	}
-	out := new(Group)
+	out := new(Metrics)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTP) DeepCopyInto(out *HTTP) {
+func (in *NetworkPolicy) DeepCopyInto(out *NetworkPolicy) {
 	*out = *in
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]networkingv1.NetworkPolicyEgressRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTP.
-func (in *HTTP) DeepCopy() *HTTP {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicy.
+func (in *NetworkPolicy) DeepCopy() *NetworkPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(HTTP)
+	out := new(NetworkPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTPHeader) DeepCopyInto(out *HTTPHeader) {
+func (in *Notification) DeepCopyInto(out *Notification) {
 	*out = *in
-	if in.ValueFrom != nil {
-		in, out := &in.ValueFrom, &out.ValueFrom
-		*out = new(HTTPHeaderSource)
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]PipelinePhase, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(Kafka)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.STAN != nil {
+		in, out := &in.STAN, &out.STAN
+		*out = new(STAN)
 		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeader.
-func (in *HTTPHeader) DeepCopy() *HTTPHeader {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Notification.
+func (in *Notification) DeepCopy() *Notification {
 	if in == nil {
 		return nil
 	}
-	out := new(HTTPHeader)
+	out := new(Notification)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTPHeaderSource) DeepCopyInto(out *HTTPHeaderSource) {
+func (in *NotificationStatus) DeepCopyInto(out *NotificationStatus) {
 	*out = *in
-	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+	in.TriggeredAt.DeepCopyInto(&out.TriggeredAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeaderSource.
-func (in *HTTPHeaderSource) DeepCopy() *HTTPHeaderSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationStatus.
+func (in *NotificationStatus) DeepCopy() *NotificationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HTTPHeaderSource)
+	out := new(NotificationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTPSink) DeepCopyInto(out *HTTPSink) {
-	*out = *in
-	if in.Headers != nil {
-		in, out := &in.Headers, &out.Headers
-		*out = make([]HTTPHeader, len(*in))
+func (in NotificationStatuses) DeepCopyInto(out *NotificationStatuses) {
+	{
+		in := &in
+		*out = make(NotificationStatuses, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSink.
-func (in *HTTPSink) DeepCopy() *HTTPSink {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationStatuses.
+func (in NotificationStatuses) DeepCopy() NotificationStatuses {
 	if in == nil {
 		return nil
 	}
-	out := new(HTTPSink)
+	out := new(NotificationStatuses)
 	in.DeepCopyInto(out)
-	return out
+	return *out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HTTPSource) DeepCopyInto(out *HTTPSource) {
+func (in *Parameter) DeepCopyInto(out *Parameter) {
 	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSource.
-func (in *HTTPSource) DeepCopy() *HTTPSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Parameter.
+func (in *Parameter) DeepCopy() *Parameter {
 	if in == nil {
 		return nil
 	}
-	out := new(HTTPSource)
+	out := new(Parameter)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Interface) DeepCopyInto(out *Interface) {
+func (in *PersistentVolumeClaimRetentionPolicy) DeepCopyInto(out *PersistentVolumeClaimRetentionPolicy) {
 	*out = *in
-	if in.HTTP != nil {
-		in, out := &in.HTTP, &out.HTTP
-		*out = new(HTTP)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Interface.
-func (in *Interface) DeepCopy() *Interface {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeClaimRetentionPolicy.
+func (in *PersistentVolumeClaimRetentionPolicy) DeepCopy() *PersistentVolumeClaimRetentionPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(Interface)
+	out := new(PersistentVolumeClaimRetentionPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Kafka) DeepCopyInto(out *Kafka) {
+func (in *Pipeline) DeepCopyInto(out *Pipeline) {
 	*out = *in
-	in.KafkaConfig.DeepCopyInto(&out.KafkaConfig)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kafka.
-func (in *Kafka) DeepCopy() *Kafka {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pipeline.
+func (in *Pipeline) DeepCopy() *Pipeline {
 	if in == nil {
 		return nil
 	}
-	out := new(Kafka)
+	out := new(Pipeline)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Pipeline) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KafkaConfig) DeepCopyInto(out *KafkaConfig) {
+func (in *PipelineList) DeepCopyInto(out *PipelineList) {
 	*out = *in
-	if in.Brokers != nil {
-		in, out := &in.Brokers, &out.Brokers
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.NET != nil {
-		in, out := &in.NET, &out.NET
-		*out = new(KafkaNET)
-		(*in).DeepCopyInto(*out)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Pipeline, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConfig.
-func (in *KafkaConfig) DeepCopy() *KafkaConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineList.
+func (in *PipelineList) DeepCopy() *PipelineList {
 	if in == nil {
 		return nil
 	}
-	out := new(KafkaConfig)
+	out := new(PipelineList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KafkaNET) DeepCopyInto(out *KafkaNET) {
+func (in *PipelineSpec) DeepCopyInto(out *PipelineSpec) {
 	*out = *in
-	if in.TLS != nil {
-		in, out := &in.TLS, &out.TLS
-		*out = new(TLS)
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]StepSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TTLStrategy != nil {
+		in, out := &in.TTLStrategy, &out.TTLStrategy
+		*out = new(TTLStrategy)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.SASL != nil {
-		in, out := &in.SASL, &out.SASL
-		*out = new(SASL)
+	if in.TemplateRef != nil {
+		in, out := &in.TemplateRef, &out.TemplateRef
+		*out = new(TemplateRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transport != nil {
+		in, out := &in.Transport, &out.Transport
+		*out = new(StepTransport)
+		**out = **in
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make([]Notification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]Dependency, len(*in))
+		copy(*out, *in)
+	}
+	if in.Trigger != nil {
+		in, out := &in.Trigger, &out.Trigger
+		*out = new(Trigger)
 		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaNET.
-func (in *KafkaNET) DeepCopy() *KafkaNET {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineSpec.
+func (in *PipelineSpec) DeepCopy() *PipelineSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KafkaNET)
+	out := new(PipelineSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KafkaSource) DeepCopyInto(out *KafkaSource) {
+func (in *PipelineStatus) DeepCopyInto(out *PipelineStatus) {
 	*out = *in
-	in.Kafka.DeepCopyInto(&out.Kafka)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(TemplateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Edges != nil {
+		in, out := &in.Edges, &out.Edges
+		*out = make([]Edge, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = make(NotificationStatuses, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Trigger != nil {
+		in, out := &in.Trigger, &out.Trigger
+		*out = new(TriggerStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaSource.
-func (in *KafkaSource) DeepCopy() *KafkaSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStatus.
+func (in *PipelineStatus) DeepCopy() *PipelineStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KafkaSource)
+	out := new(PipelineStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Log) DeepCopyInto(out *Log) {
+func (in *PipelineTemplate) DeepCopyInto(out *PipelineTemplate) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Log.
-func (in *Log) DeepCopy() *Log {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTemplate.
+func (in *PipelineTemplate) DeepCopy() *PipelineTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(Log)
+	out := new(PipelineTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Metadata) DeepCopyInto(out *Metadata) {
+func (in *PipelineTemplateList) DeepCopyInto(out *PipelineTemplateList) {
 	*out = *in
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PipelineTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata.
-func (in *Metadata) DeepCopy() *Metadata {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTemplateList.
+func (in *PipelineTemplateList) DeepCopy() *PipelineTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(Metadata)
+	out := new(PipelineTemplateList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PipelineTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Metrics) DeepCopyInto(out *Metrics) {
+func (in *PipelineTemplateSpec) DeepCopyInto(out *PipelineTemplateSpec) {
 	*out = *in
-	out.Rate = in.Rate.DeepCopy()
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]Parameter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Template.DeepCopyInto(&out.Template)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metrics.
-func (in *Metrics) DeepCopy() *Metrics {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTemplateSpec.
+func (in *PipelineTemplateSpec) DeepCopy() *PipelineTemplateSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(Metrics)
+	out := new(PipelineTemplateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Pipeline) DeepCopyInto(out *Pipeline) {
+func (in *PodTemplate) DeepCopyInto(out *PodTemplate) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HostAliases != nil {
+		in, out := &in.HostAliases, &out.HostAliases
+		*out = make([]corev1.HostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DNSConfig != nil {
+		in, out := &in.DNSConfig, &out.DNSConfig
+		*out = new(corev1.PodDNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pipeline.
-func (in *Pipeline) DeepCopy() *Pipeline {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplate.
+func (in *PodTemplate) DeepCopy() *PodTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(Pipeline)
+	out := new(PodTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Pipeline) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PipelineList) DeepCopyInto(out *PipelineList) {
+func (in *RecentError) DeepCopyInto(out *RecentError) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Pipeline, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.Time.DeepCopyInto(&out.Time)
 }
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineList.
-func (in *PipelineList) DeepCopy() *PipelineList {
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecentError.
+func (in *RecentError) DeepCopy() *RecentError {
 	if in == nil {
 		return nil
 	}
-	out := new(PipelineList)
+	out := new(RecentError)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PipelineList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PipelineSpec) DeepCopyInto(out *PipelineSpec) {
-	*out = *in
-	if in.Steps != nil {
-		in, out := &in.Steps, &out.Steps
-		*out = make([]StepSpec, len(*in))
+func (in RecentErrors) DeepCopyInto(out *RecentErrors) {
+	{
+		in := &in
+		*out = make(RecentErrors, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineSpec.
-func (in *PipelineSpec) DeepCopy() *PipelineSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecentErrors.
+func (in RecentErrors) DeepCopy() RecentErrors {
 	if in == nil {
 		return nil
 	}
-	out := new(PipelineSpec)
+	out := new(RecentErrors)
 	in.DeepCopyInto(out)
-	return out
+	return *out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PipelineStatus) DeepCopyInto(out *PipelineStatus) {
+func (in *S3) DeepCopyInto(out *S3) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(AWSCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(AWSEndpoint)
+		**out = **in
 	}
-	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStatus.
-func (in *PipelineStatus) DeepCopy() *PipelineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3.
+func (in *S3) DeepCopy() *S3 {
 	if in == nil {
 		return nil
 	}
-	out := new(PipelineStatus)
+	out := new(S3)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *S3) DeepCopyInto(out *S3) {
+func (in *S3Connection) DeepCopyInto(out *S3Connection) {
 	*out = *in
 	if in.Credentials != nil {
 		in, out := &in.Credentials, &out.Credentials
@@ -741,12 +1775,12 @@ func (in *S3) DeepCopyInto(out *S3) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3.
-func (in *S3) DeepCopy() *S3 {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Connection.
+func (in *S3Connection) DeepCopy() *S3Connection {
 	if in == nil {
 		return nil
 	}
-	out := new(S3)
+	out := new(S3Connection)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -789,12 +1823,12 @@ func (in *SASL) DeepCopyInto(out *SASL) {
 	*out = *in
 	if in.UserSecret != nil {
 		in, out := &in.UserSecret, &out.UserSecret
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.PasswordSecret != nil {
 		in, out := &in.PasswordSecret, &out.PasswordSecret
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -880,7 +1914,7 @@ func (in *STANAuth) DeepCopyInto(out *STANAuth) {
 	*out = *in
 	if in.Token != nil {
 		in, out := &in.Token, &out.Token
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -895,6 +1929,26 @@ func (in *STANAuth) DeepCopy() *STANAuth {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *STANConnection) DeepCopyInto(out *STANConnection) {
+	*out = *in
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(STANAuth)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new STANConnection.
+func (in *STANConnection) DeepCopy() *STANConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(STANConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Scale) DeepCopyInto(out *Scale) {
 	*out = *in
@@ -981,7 +2035,7 @@ func (in *Source) DeepCopyInto(out *Source) {
 	if in.HTTP != nil {
 		in, out := &in.HTTP, &out.HTTP
 		*out = new(HTTPSource)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.S3 != nil {
 		in, out := &in.S3, &out.S3
@@ -1016,6 +2070,29 @@ func (in *SourceStatus) DeepCopyInto(out *SourceStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.RecentErrors != nil {
+		in, out := &in.RecentErrors, &out.RecentErrors
+		*out = make(RecentErrors, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EndOffsets != nil {
+		in, out := &in.EndOffsets, &out.EndOffsets
+		*out = make(map[int32]int64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EndSequence != nil {
+		in, out := &in.EndSequence, &out.EndSequence
+		*out = new(uint64)
+		**out = **in
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceStatus.
@@ -1193,7 +2270,7 @@ func (in *StepSpec) DeepCopyInto(out *StepSpec) {
 	}
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
+		*out = make([]corev1.Volume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1212,16 +2289,38 @@ func (in *StepSpec) DeepCopyInto(out *StepSpec) {
 	}
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
+		*out = new(corev1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(PodTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeClaimTemplates != nil {
+		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
+		*out = make([]VolumeClaimTemplate, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PersistentVolumeClaimRetentionPolicy != nil {
+		in, out := &in.PersistentVolumeClaimRetentionPolicy, &out.PersistentVolumeClaimRetentionPolicy
+		*out = new(PersistentVolumeClaimRetentionPolicy)
+		**out = **in
+	}
+	if in.Watchdog != nil {
+		in, out := &in.Watchdog, &out.Watchdog
+		*out = new(Watchdog)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepSpec.
@@ -1252,6 +2351,34 @@ func (in *StepStatus) DeepCopyInto(out *StepStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Watchdog != nil {
+		in, out := &in.Watchdog, &out.Watchdog
+		*out = make(WatchdogStatuses, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.SourceErrorWindows != nil {
+		in, out := &in.SourceErrorWindows, &out.SourceErrorWindows
+		*out = make(ErrorWindows, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.SinkErrorWindows != nil {
+		in, out := &in.SinkErrorWindows, &out.SinkErrorWindows
+		*out = make(ErrorWindows, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepStatus.
@@ -1264,6 +2391,21 @@ func (in *StepStatus) DeepCopy() *StepStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepTransport) DeepCopyInto(out *StepTransport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepTransport.
+func (in *StepTransport) DeepCopy() *StepTransport {
+	if in == nil {
+		return nil
+	}
+	out := new(StepTransport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Storage) DeepCopyInto(out *Storage) {
 	*out = *in
@@ -1284,17 +2426,17 @@ func (in *TLS) DeepCopyInto(out *TLS) {
 	*out = *in
 	if in.CACertSecret != nil {
 		in, out := &in.CACertSecret, &out.CACertSecret
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.CertSecret != nil {
 		in, out := &in.CertSecret, &out.CertSecret
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.KeySecret != nil {
 		in, out := &in.KeySecret, &out.KeySecret
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -1308,3 +2450,198 @@ func (in *TLS) DeepCopy() *TLS {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TTLStrategy) DeepCopyInto(out *TTLStrategy) {
+	*out = *in
+	if in.SecondsAfterSuccess != nil {
+		in, out := &in.SecondsAfterSuccess, &out.SecondsAfterSuccess
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SecondsAfterFailure != nil {
+		in, out := &in.SecondsAfterFailure, &out.SecondsAfterFailure
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TTLStrategy.
+func (in *TTLStrategy) DeepCopy() *TTLStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(TTLStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateRef) DeepCopyInto(out *TemplateRef) {
+	*out = *in
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateRef.
+func (in *TemplateRef) DeepCopy() *TemplateRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateStatus) DeepCopyInto(out *TemplateStatus) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateStatus.
+func (in *TemplateStatus) DeepCopy() *TemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Trigger) DeepCopyInto(out *Trigger) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]TriggerParameter, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Trigger.
+func (in *Trigger) DeepCopy() *Trigger {
+	if in == nil {
+		return nil
+	}
+	out := new(Trigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TriggerParameter) DeepCopyInto(out *TriggerParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerParameter.
+func (in *TriggerParameter) DeepCopy() *TriggerParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(TriggerParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TriggerStatus) DeepCopyInto(out *TriggerStatus) {
+	*out = *in
+	in.TriggeredAt.DeepCopyInto(&out.TriggeredAt)
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerStatus.
+func (in *TriggerStatus) DeepCopy() *TriggerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TriggerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeClaimTemplate) DeepCopyInto(out *VolumeClaimTemplate) {
+	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = new(Metadata)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeClaimTemplate.
+func (in *VolumeClaimTemplate) DeepCopy() *VolumeClaimTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeClaimTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Watchdog) DeepCopyInto(out *Watchdog) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Watchdog.
+func (in *Watchdog) DeepCopy() *Watchdog {
+	if in == nil {
+		return nil
+	}
+	out := new(Watchdog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WatchdogStatus) DeepCopyInto(out *WatchdogStatus) {
+	*out = *in
+	in.DetectedAt.DeepCopyInto(&out.DetectedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WatchdogStatus.
+func (in *WatchdogStatus) DeepCopy() *WatchdogStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WatchdogStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in WatchdogStatuses) DeepCopyInto(out *WatchdogStatuses) {
+	{
+		in := &in
+		*out = make(WatchdogStatuses, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WatchdogStatuses.
+func (in WatchdogStatuses) DeepCopy() WatchdogStatuses {
+	if in == nil {
+		return nil
+	}
+	out := new(WatchdogStatuses)
+	in.DeepCopyInto(out)
+	return *out
+}
@@ -0,0 +1,256 @@
+package activator
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+var logger = util.NewLogger()
+
+// the largest request body we hold, larger requests get a 413
+const maxBodySize = 10 << 20
+
+// hopHeaders are the headers that apply to a single connection, so are not forwarded, as in net/http/httputil
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Activator fronts the HTTP source services of steps that have been scaled to zero. It holds each request, scales the
+// step up, and then replays the request to the step's sidecar once it is ready.
+type Activator struct {
+	client.Client
+	Addr       string
+	Namespace  string        // the namespace of the services we front, requests for other namespaces are rejected
+	Timeout    time.Duration // how long to hold a request waiting for the step to become ready
+	pending    chan struct{} // bounds the number of requests we hold
+	httpClient *http.Client
+}
+
+func New(c client.Client, addr, namespace string, maxPending int, timeout time.Duration) *Activator {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &Activator{
+		Client:     c,
+		Addr:       addr,
+		Namespace:  namespace,
+		Timeout:    timeout,
+		pending:    make(chan struct{}, maxPending),
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: t},
+	}
+}
+
+func (a *Activator) Start(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "activator")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := util.GenerateCert(certFile, keyFile); err != nil {
+		return fmt.Errorf("failed to generate cert: %w", err)
+	}
+	server := &http.Server{Addr: a.Addr, Handler: a, TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+	go func() {
+		defer runtimeutil.HandleCrash()
+		<-ctx.Done()
+		logger.Info("closing activator")
+		_ = server.Shutdown(context.Background())
+	}()
+	logger.Info("starting activator", "addr", a.Addr)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection is false because requests may be routed to any replica of the manager.
+func (a *Activator) NeedLeaderElection() bool {
+	return false
+}
+
+func (a *Activator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/sources/") {
+		w.WriteHeader(404)
+		return
+	}
+	serviceName, err := parseHost(r.Host, a.Namespace)
+	if err != nil {
+		w.WriteHeader(403)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	select {
+	case a.pending <- struct{}{}:
+		defer func() { <-a.pending }()
+	default:
+		w.WriteHeader(503)
+		_, _ = w.Write([]byte("too many pending requests"))
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		w.WriteHeader(413)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), a.Timeout)
+	defer cancel()
+	resp, err := a.activate(ctx, serviceName, r, body)
+	if err != nil {
+		logger.Error(err, "failed to activate", "host", r.Host, "path", r.URL.Path)
+		w.WriteHeader(503)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	copyHeader(w.Header(), resp.Header)
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func (a *Activator) activate(ctx context.Context, serviceName string, r *http.Request, body []byte) (*http.Response, error) {
+	namespace := a.Namespace
+	service := &corev1.Service{}
+	if err := a.Get(ctx, client.ObjectKey{Namespace: namespace, Name: serviceName}, service); err != nil {
+		return nil, fmt.Errorf("failed to get service %q: %w", serviceName, err)
+	}
+	pipelineName, stepName := service.Labels[dfv1.KeyPipelineName], service.Labels[dfv1.KeyStepName]
+	if pipelineName == "" || stepName == "" {
+		return nil, fmt.Errorf("service %q is not a step's service", serviceName)
+	}
+	if err := a.scaleUp(ctx, namespace, pipelineName+"-"+stepName); err != nil {
+		return nil, err
+	}
+	podIP, err := a.waitForReadyPod(ctx, namespace, pipelineName, stepName)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("replaying request", "service", serviceName, "path", r.URL.Path, "podIP", podIP)
+	return a.replay(ctx, "https://"+net.JoinHostPort(podIP, "3570"), r, body)
+}
+
+// replay sends the held request to the step's sidecar at the URL, with the same path and headers
+func (a *Activator) replay(ctx context.Context, url string, r *http.Request, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url+r.URL.Path, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	copyHeader(req.Header, r.Header)
+	return a.httpClient.Do(req)
+}
+
+// copyHeader copies the headers from src to dst, except the hop-by-hop ones, including those named by "Connection"
+func copyHeader(dst, src http.Header) {
+	skip := map[string]bool{}
+	for _, k := range hopHeaders {
+		skip[k] = true
+	}
+	for _, v := range src.Values("Connection") {
+		for _, k := range strings.Split(v, ",") {
+			skip[http.CanonicalHeaderKey(strings.TrimSpace(k))] = true
+		}
+	}
+	for k, vs := range src {
+		if skip[k] {
+			continue
+		}
+		for _, v := range vs {
+			dst.Add(k, v)
+		}
+	}
+}
+
+// scaleUp scales the step up to one replica, and marks it as activated, so the step reconciler does not scale it back
+// down to zero (because it has no pending messages) before it can serve the requests we are holding
+func (a *Activator) scaleUp(ctx context.Context, namespace, name string) error {
+	step := &dfv1.Step{}
+	if err := a.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, step); err != nil {
+		return fmt.Errorf("failed to get step %q: %w", name, err)
+	}
+	if step.Spec.Replicas > 0 {
+		return nil
+	}
+	logger.Info("activating step", "step", name)
+	patch := util.MustJSON(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{dfv1.KeyActivatedAt: time.Now().Format(time.RFC3339)},
+		},
+		"spec": map[string]interface{}{"replicas": 1},
+	})
+	if err := a.Patch(ctx, step, client.RawPatch(types.MergePatchType, []byte(patch))); err != nil {
+		return fmt.Errorf("failed to scale step %q: %w", name, err)
+	}
+	return nil
+}
+
+func (a *Activator) waitForReadyPod(ctx context.Context, namespace, pipelineName, stepName string) (string, error) {
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipelineName + "," + dfv1.KeyStepName + "=" + stepName)
+	for {
+		pods := &corev1.PodList{}
+		if err := a.List(ctx, pods, &client.ListOptions{Namespace: namespace, LabelSelector: selector}); err != nil {
+			return "", fmt.Errorf("failed to list pods: %w", err)
+		}
+		if podIP := readyPodIP(pods.Items); podIP != "" {
+			return podIP, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for step to be ready: %w", ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func readyPodIP(pods []corev1.Pod) string {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+			continue
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+				return pod.Status.PodIP
+			}
+		}
+	}
+	return ""
+}
+
+// parseHost returns the service name from a host such as "my-svc", "my-svc.my-ns" or
+// "my-svc.my-ns.svc.cluster.local:443", and an error if the host is in a namespace other than the given one
+func parseHost(host, namespace string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) > 1 && parts[1] != namespace {
+		return "", fmt.Errorf("host %q is not in namespace %q", host, namespace)
+	}
+	return parts[0], nil
+}
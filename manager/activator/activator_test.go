@@ -0,0 +1,123 @@
+package activator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/manager/controllers"
+)
+
+func Test_parseHost(t *testing.T) {
+	for _, host := range []string{"my-svc", "my-svc:443", "my-svc.my-ns", "my-svc.my-ns.svc.cluster.local:443"} {
+		t.Run(host, func(t *testing.T) {
+			serviceName, err := parseHost(host, "my-ns")
+			assert.NoError(t, err)
+			assert.Equal(t, "my-svc", serviceName)
+		})
+	}
+	t.Run("OtherNamespace", func(t *testing.T) {
+		_, err := parseHost("my-svc.other-ns.svc.cluster.local", "my-ns")
+		assert.Error(t, err)
+	})
+}
+
+func TestActivator_ServeHTTP(t *testing.T) {
+	a := New(nil, ":0", "my-ns", 1, time.Minute)
+	t.Run("OtherNamespace", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest("POST", "https://my-svc.other-ns/sources/default", nil))
+		assert.Equal(t, 403, w.Code)
+	})
+	t.Run("TooLarge", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest("POST", "https://my-svc.my-ns/sources/default", bytes.NewReader(make([]byte, maxBodySize+1))))
+		assert.Equal(t, 413, w.Code)
+	})
+}
+
+func TestActivator_replay(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sources/default", r.URL.Path)
+		assert.Equal(t, "my-authorization", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Empty(t, r.Header.Get("X-Hop"), "named by the Connection header")
+		assert.Empty(t, r.Header.Get("Proxy-Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "my-msg", string(body))
+		w.Header().Set("X-Request-Id", "my-id")
+		w.Header().Set("Keep-Alive", "timeout=5")
+		w.WriteHeader(201)
+	}))
+	defer server.Close()
+	a := New(nil, ":0", "my-ns", 1, time.Minute)
+	r := httptest.NewRequest("POST", "https://my-svc/sources/default", nil)
+	r.Header.Set("Authorization", "my-authorization")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Connection", "X-Hop")
+	r.Header.Set("X-Hop", "hop")
+	r.Header.Set("Proxy-Authorization", "my-proxy-authorization")
+	resp, err := a.replay(context.Background(), server.URL, r, []byte("my-msg"))
+	assert.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, 201, resp.StatusCode)
+	w := http.Header{}
+	copyHeader(w, resp.Header)
+	assert.Equal(t, "my-id", w.Get("X-Request-Id"))
+	assert.Empty(t, w.Get("Keep-Alive"))
+}
+
+func Test_readyPodIP(t *testing.T) {
+	assert.Empty(t, readyPodIP(nil))
+	assert.Empty(t, readyPodIP([]corev1.Pod{{Status: corev1.PodStatus{PodIP: "1.2.3.4"}}}))
+	assert.Equal(t, "1.2.3.4", readyPodIP([]corev1.Pod{{
+		Status: corev1.PodStatus{
+			PodIP:      "1.2.3.4",
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}}))
+}
+
+func TestActivator_scaleUp(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	step := &dfv1.Step{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main", Labels: map[string]string{dfv1.KeyPipelineName: "my-pl"}},
+		Spec:       dfv1.StepSpec{Name: "main", Cat: &dfv1.Cat{}, Scale: &dfv1.Scale{}},
+		Status:     dfv1.StepStatus{LastScaledAt: metav1.Now()}, // recently scaled to zero
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(step).Build()
+	a := New(c, ":0", "my-ns", 1, time.Minute)
+	assert.NoError(t, a.scaleUp(ctx, "my-ns", "my-pl-main"))
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(step), step))
+	assert.Equal(t, uint32(1), step.Spec.Replicas)
+	assert.True(t, step.IsActivated(time.Minute))
+
+	dynamicInterface := dynamicfake.NewSimpleDynamicClient(scheme, step.DeepCopy())
+	r := &controllers.StepReconciler{Client: c, Log: ctrl.Log, Recorder: record.NewFakeRecorder(10), DynamicInterface: dynamicInterface}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(step)})
+	assert.NoError(t, err)
+	assert.Empty(t, dynamicInterface.Actions(), "the step is not scaled back down to zero")
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(step), step))
+	assert.Equal(t, uint32(1), step.Status.Replicas)
+	pods := &corev1.PodList{}
+	assert.NoError(t, c.List(ctx, pods))
+	assert.Len(t, pods.Items, 1)
+}
@@ -0,0 +1,16 @@
+package archive
+
+import (
+	"context"
+	"io"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// Interface archives completed pipelines before they are deleted, so that they can be audited later.
+type Interface interface {
+	io.Closer
+	// Archive records the final state of the pipeline and its steps. It must be idempotent, as a pipeline may be
+	// archived more than once if it fails to be deleted.
+	Archive(ctx context.Context, pipeline dfv1.Pipeline, steps []dfv1.Step) error
+}
@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+var schema = []string{
+	`create table if not exists dataflow_pipelines (
+  uid varchar(128) not null,
+  namespace varchar(253) not null,
+  name varchar(253) not null,
+  phase varchar(32) not null,
+  message text,
+  completedat timestamp null,
+  pipeline longtext not null,
+  primary key (uid)
+)`,
+	`create table if not exists dataflow_steps (
+  pipelineuid varchar(128) not null,
+  name varchar(253) not null,
+  phase varchar(32) not null,
+  message text,
+  step longtext not null,
+  primary key (pipelineuid, name)
+)`,
+}
+
+type sqlArchive struct {
+	db *sql.DB
+}
+
+// NewSQL opens the database and creates the archive's tables if they do not exist. The schema and statements are
+// MySQL's, so "mysql" is the only driver supported.
+func NewSQL(ctx context.Context, driver, dataSource string) (Interface, error) {
+	db, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	db.SetConnMaxLifetime(3 * time.Minute)
+	for _, s := range schema {
+		if _, err := db.ExecContext(ctx, s); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create archive table: %w", err)
+		}
+	}
+	return &sqlArchive{db}, nil
+}
+
+func (a *sqlArchive) Archive(ctx context.Context, pipeline dfv1.Pipeline, steps []dfv1.Step) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }() // ignored if the transaction is committed
+	uid := string(pipeline.UID)
+	for _, s := range []string{"delete from dataflow_steps where pipelineuid = ?", "delete from dataflow_pipelines where uid = ?"} {
+		if _, err := tx.ExecContext(ctx, s, uid); err != nil {
+			return fmt.Errorf("failed to delete previous archive: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		"insert into dataflow_pipelines (uid, namespace, name, phase, message, completedat, pipeline) values (?, ?, ?, ?, ?, ?, ?)",
+		uid, pipeline.Namespace, pipeline.Name, string(pipeline.Status.Phase), pipeline.Status.Message, pipeline.Status.LastUpdated.Time, util.MustJSON(pipeline),
+	); err != nil {
+		return fmt.Errorf("failed to archive pipeline: %w", err)
+	}
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx,
+			"insert into dataflow_steps (pipelineuid, name, phase, message, step) values (?, ?, ?, ?, ?)",
+			uid, step.Spec.Name, string(step.Status.Phase), step.Status.Message, util.MustJSON(step),
+		); err != nil {
+			return fmt.Errorf("failed to archive step %q: %w", step.Spec.Name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (a *sqlArchive) Close() error {
+	return a.db.Close()
+}
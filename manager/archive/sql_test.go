@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// fakeDriver records the statements executed (as their verb and table), by data source, and fails any statement
+// starting with the data source's "fail:" prefix
+type fakeDriver struct {
+	mu         sync.Mutex
+	statements map[string][]string
+}
+
+var (
+	testDriver = &fakeDriver{statements: map[string][]string{}}
+	table      = regexp.MustCompile(`dataflow_\w+`)
+)
+
+func init() {
+	sql.Register("fake", testDriver)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d, name}, nil }
+
+func (d *fakeDriver) get(name string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.statements[name]
+}
+
+type fakeConn struct {
+	d    *fakeDriver
+	name string
+}
+
+func (c *fakeConn) exec(query string) error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.statements[c.name] = append(c.d.statements[c.name], strings.TrimSpace(strings.Fields(query)[0]+" "+table.FindString(query)))
+	if prefix := strings.TrimPrefix(c.name, "fail:"); prefix != c.name && strings.HasPrefix(query, prefix) {
+		return fmt.Errorf("failed")
+	}
+	return nil
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return &fakeTx{c}, nil }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), s.c.exec(s.query)
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+type fakeTx struct{ c *fakeConn }
+
+func (t *fakeTx) Commit() error   { return t.c.exec("commit") }
+func (t *fakeTx) Rollback() error { return t.c.exec("rollback") }
+
+func TestNewSQL(t *testing.T) {
+	ctx := context.Background()
+	t.Run("CreatesTables", func(t *testing.T) {
+		a, err := NewSQL(ctx, "fake", "new")
+		if assert.NoError(t, err) {
+			assert.NoError(t, a.Close())
+		}
+		assert.Equal(t, []string{"create dataflow_pipelines", "create dataflow_steps"}, testDriver.get("new"))
+	})
+	t.Run("Error", func(t *testing.T) {
+		_, err := NewSQL(ctx, "fake", "fail:create")
+		assert.EqualError(t, err, "failed to create archive table: failed")
+	})
+	t.Run("UnknownDriver", func(t *testing.T) {
+		_, err := NewSQL(ctx, "unknown", "")
+		assert.Error(t, err)
+	})
+}
+
+func Test_sqlArchive_Archive(t *testing.T) {
+	ctx := context.Background()
+	pipeline := dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl", UID: "my-uid"},
+		Status:     dfv1.PipelineStatus{Phase: dfv1.PipelineSucceeded},
+	}
+	steps := []dfv1.Step{{Spec: dfv1.StepSpec{Name: "a"}}, {Spec: dfv1.StepSpec{Name: "b"}}}
+	t.Run("Archive", func(t *testing.T) {
+		a, err := NewSQL(ctx, "fake", "archive")
+		assert.NoError(t, err)
+		defer func() { _ = a.Close() }()
+		assert.NoError(t, a.Archive(ctx, pipeline, steps))
+		assert.Equal(t, []string{
+			"create dataflow_pipelines", "create dataflow_steps",
+			// previous archive is deleted, so archiving is idempotent
+			"delete dataflow_steps", "delete dataflow_pipelines",
+			"insert dataflow_pipelines", "insert dataflow_steps", "insert dataflow_steps",
+			"commit",
+		}, testDriver.get("archive"))
+	})
+	t.Run("Error", func(t *testing.T) {
+		a, err := NewSQL(ctx, "fake", "fail:insert into dataflow_steps")
+		assert.NoError(t, err)
+		defer func() { _ = a.Close() }()
+		assert.EqualError(t, a.Archive(ctx, pipeline, steps), `failed to archive step "a": failed`)
+		statements := testDriver.get("fail:insert into dataflow_steps")
+		assert.Equal(t, "rollback", statements[len(statements)-1])
+	})
+}
@@ -1,29 +1,114 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
-	"github.com/argoproj-labs/argo-dataflow/shared/util"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/yaml"
 
 	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
-	corev1 "k8s.io/api/core/v1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
 )
 
+// ConfigMapName is the name of the config map the controller reads its configuration from.
+const ConfigMapName = "dataflow-controller-config"
+
+type config struct {
+	ImageFormat        string                      `json:"imageFormat"`
+	RunnerImage        string                      `json:"runnerImage"`
+	PullPolicy         corev1.PullPolicy           `json:"pullPolicy,omitempty"`
+	UpdateInterval     time.Duration               `json:"updateInterval"`
+	ScalingDelay       time.Duration               `json:"scalingDelay"`
+	PeekDelay          time.Duration               `json:"peekDelay"`
+	DeletionDelay      time.Duration               `json:"deletionDelay"`
+	CleanUpTimeout     time.Duration               `json:"cleanUpTimeout"`     // how long after deletion to give up cleaning up a pipeline's sources
+	DegradedErrorRatio float64                     `json:"degradedErrorRatio"` // pipelines are degraded if a step's source or sink has a higher ratio of errors
+	SidecarResources   corev1.ResourceRequirements `json:"sidecarResources,omitempty"`
+	PodSecurityContext *corev1.PodSecurityContext  `json:"podSecurityContext,omitempty"`
+	NodeSelector       map[string]string           `json:"nodeSelector,omitempty"`
+	Tolerations        []corev1.Toleration         `json:"tolerations,omitempty"`
+	Archive            *dfv1.Database              `json:"archive,omitempty"` // if specified, completed pipelines are archived before they are deleted
+	// the controller gets the status of each replica from its sidecar and updates the step's status, rather than each
+	// sidecar patching the step's status
+	AggregateStepStatus bool `json:"aggregateStepStatus,omitempty"`
+}
+
+// the parts of the config that change the pods we create
+type podConfig struct {
+	RunnerImage         string                      `json:"runnerImage"`
+	PullPolicy          corev1.PullPolicy           `json:"pullPolicy,omitempty"`
+	SidecarResources    corev1.ResourceRequirements `json:"sidecarResources,omitempty"`
+	PodSecurityContext  *corev1.PodSecurityContext  `json:"podSecurityContext,omitempty"`
+	NodeSelector        map[string]string           `json:"nodeSelector,omitempty"`
+	Tolerations         []corev1.Toleration         `json:"tolerations,omitempty"`
+	AggregateStepStatus bool                        `json:"aggregateStepStatus,omitempty"`
+}
+
+func (c config) podConfig() podConfig {
+	return podConfig{c.RunnerImage, c.PullPolicy, c.SidecarResources, c.PodSecurityContext, c.NodeSelector, c.Tolerations, c.AggregateStepStatus}
+}
+
 var (
-	scalingDelay   = util.GetEnvDuration(dfv1.EnvScalingDelay, time.Minute)
-	peekDelay      = util.GetEnvDuration(dfv1.EnvPeekDelay, 4*time.Minute)
-	imagePrefix    = os.Getenv(dfv1.EnvImagePrefix)
-	imageFormat    = ""
-	runnerImage    = ""
-	pullPolicy     = corev1.PullPolicy(os.Getenv(dfv1.EnvPullPolicy))
-	updateInterval = util.GetEnvDuration(dfv1.EnvUpdateInterval, 1*time.Minute)
-	deletionDelay  = util.GetEnvDuration(dfv1.EnvDeletionDelay, 720*time.Hour) // ~30d
-	logger         = util.NewLogger()
+	logger        = util.NewLogger()
+	configMu      = sync.RWMutex{}
+	currentConfig config
 )
 
 func init() {
+	var err error
+	currentConfig, err = newConfig(nil)
+	if err != nil {
+		panic(err)
+	}
+	logConfig(currentConfig)
+}
+
+func getConfig() config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return currentConfig
+}
+
+func setConfig(x config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	if notEqual, patch := util.NotEqual(currentConfig, x); notEqual {
+		logger.Info("reconciler config changed", "patch", patch)
+		currentConfig = x
+	}
+}
+
+// newConfig creates the config from the config map's data, falling back to environment variables and then defaults
+func newConfig(data map[string]string) (config, error) {
+	get := func(key, env string) string {
+		if v, ok := data[key]; ok {
+			return v
+		}
+		return os.Getenv(env)
+	}
+	getDuration := func(key, env string, def time.Duration) (time.Duration, error) {
+		if v := get(key, env); v != "" {
+			x, err := time.ParseDuration(v)
+			if err != nil {
+				return 0, fmt.Errorf("%s=%s; value must be duration: %w", key, v, err)
+			}
+			return x, nil
+		}
+		return def, nil
+	}
+	c := config{PullPolicy: corev1.PullPolicy(get("pullPolicy", dfv1.EnvPullPolicy))}
+	imagePrefix := get("imagePrefix", dfv1.EnvImagePrefix)
 	if imagePrefix == "" {
 		imagePrefix = "quay.io/argoproj"
 	}
@@ -31,15 +116,100 @@ func init() {
 	if tag == "v0.0.0-latest-0" {
 		tag = "latest"
 	}
-	imageFormat = fmt.Sprintf("%s/%s:%s", imagePrefix, "%s", tag)
-	runnerImage = fmt.Sprintf(imageFormat, "dataflow-runner")
+	c.ImageFormat = fmt.Sprintf("%s/%s:%s", imagePrefix, "%s", tag)
+	c.RunnerImage = fmt.Sprintf(c.ImageFormat, "dataflow-runner")
+	var err error
+	if c.UpdateInterval, err = getDuration("updateInterval", dfv1.EnvUpdateInterval, 1*time.Minute); err != nil {
+		return c, err
+	}
+	if c.ScalingDelay, err = getDuration("scalingDelay", dfv1.EnvScalingDelay, time.Minute); err != nil {
+		return c, err
+	}
+	if c.PeekDelay, err = getDuration("peekDelay", dfv1.EnvPeekDelay, 4*time.Minute); err != nil {
+		return c, err
+	}
+	if c.DeletionDelay, err = getDuration("deletionDelay", dfv1.EnvDeletionDelay, 720*time.Hour); err != nil { // ~30d
+		return c, err
+	}
+	if c.CleanUpTimeout, err = getDuration("cleanUpTimeout", "", 10*time.Minute); err != nil {
+		return c, err
+	}
+	c.DegradedErrorRatio = 0.1
+	if v := get("degradedErrorRatio", ""); v != "" {
+		if c.DegradedErrorRatio, err = strconv.ParseFloat(v, 64); err != nil {
+			return c, fmt.Errorf("degradedErrorRatio=%s; value must be a number: %w", v, err)
+		}
+	}
+	if v := get("aggregateStepStatus", dfv1.EnvAggregateStepStatus); v != "" {
+		if c.AggregateStepStatus, err = strconv.ParseBool(v); err != nil {
+			return c, fmt.Errorf("aggregateStepStatus=%s; value must be a boolean: %w", v, err)
+		}
+	}
+	for key, v := range map[string]interface{}{
+		"sidecarResources":   &c.SidecarResources,
+		"podSecurityContext": &c.PodSecurityContext,
+		"nodeSelector":       &c.NodeSelector,
+		"tolerations":        &c.Tolerations,
+		"archive":            &c.Archive,
+	} {
+		if text, ok := data[key]; ok {
+			if err := yaml.UnmarshalStrict([]byte(text), v); err != nil {
+				return c, fmt.Errorf("failed to parse %q: %w", key, err)
+			}
+		}
+	}
+	// the archive's schema and statements are MySQL's
+	if x := c.Archive; x != nil && x.Driver != "mysql" {
+		return c, fmt.Errorf("archive driver %q is not supported, only \"mysql\" is", x.Driver)
+	}
+	return c, nil
+}
+
+func logConfig(c config) {
 	logger.Info("reconciler config",
-		"imageFormat", imageFormat,
-		"runnerImage", runnerImage,
-		"pullPolicy", pullPolicy,
-		"updateInterval", updateInterval.String(),
-		"scalingDelay", scalingDelay.String(),
-		"peekDelay", peekDelay.String(),
-		"deletionDelay", deletionDelay.String(),
+		"imageFormat", c.ImageFormat,
+		"runnerImage", c.RunnerImage,
+		"pullPolicy", c.PullPolicy,
+		"updateInterval", c.UpdateInterval.String(),
+		"scalingDelay", c.ScalingDelay.String(),
+		"peekDelay", c.PeekDelay.String(),
+		"deletionDelay", c.DeletionDelay.String(),
+		"cleanUpTimeout", c.CleanUpTimeout.String(),
+		"degradedErrorRatio", c.DegradedErrorRatio,
+		"aggregateStepStatus", c.AggregateStepStatus,
+		"archive", c.Archive != nil,
 	)
 }
+
+// ConfigMapReconciler reloads the controller's configuration when the config map changes
+type ConfigMapReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// +kubebuilder:rbac:groups=,resources=configmaps,verbs=get;list;watch
+func (r *ConfigMapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, req.NamespacedName, cm); err != nil {
+		if !apierr.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		r.Log.Info("config map not found, using defaults")
+	}
+	c, err := newConfig(cm.Data)
+	if err != nil {
+		// do not requeue, we'll be notified when the config map is fixed
+		r.Log.Error(err, "invalid config map, keeping the current config")
+		return ctrl.Result{}, nil
+	}
+	setConfig(c)
+	return ctrl.Result{}, nil
+}
+
+func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.ConfigMap{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetNamespace() == os.Getenv(dfv1.EnvNamespace) && obj.GetName() == ConfigMapName
+		}))).
+		Complete(r)
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_newConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		c, err := newConfig(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "quay.io/argoproj/%s:latest", c.ImageFormat)
+		assert.Equal(t, "quay.io/argoproj/dataflow-runner:latest", c.RunnerImage)
+		assert.Equal(t, time.Minute, c.ScalingDelay)
+		assert.Equal(t, 4*time.Minute, c.PeekDelay)
+		assert.Equal(t, 720*time.Hour, c.DeletionDelay)
+		assert.Equal(t, 0.1, c.DegradedErrorRatio)
+		assert.False(t, c.AggregateStepStatus)
+	})
+	t.Run("Env", func(t *testing.T) {
+		defer os.Unsetenv(dfv1.EnvPeekDelay)
+		_ = os.Setenv(dfv1.EnvPeekDelay, "2m")
+		c, err := newConfig(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, c.PeekDelay)
+		c, err = newConfig(map[string]string{"peekDelay": "3m"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3*time.Minute, c.PeekDelay, "config map takes precedence")
+	})
+	t.Run("ConfigMap", func(t *testing.T) {
+		c, err := newConfig(map[string]string{
+			"imagePrefix":         "my-registry",
+			"pullPolicy":          "Always",
+			"sidecarResources":    "limits:\n  memory: 1Gi\n",
+			"nodeSelector":        "kubernetes.io/os: linux\n",
+			"tolerations":         "- key: foo\n  operator: Exists\n",
+			"archive":             "driver: mysql\ndataSource:\n  value: my-dsn\n",
+			"degradedErrorRatio":  "0.5",
+			"aggregateStepStatus": "true",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "my-registry/dataflow-runner:latest", c.RunnerImage)
+		assert.Equal(t, corev1.PullAlways, c.PullPolicy)
+		assert.Equal(t, 0.5, c.DegradedErrorRatio)
+		assert.True(t, c.AggregateStepStatus)
+		assert.True(t, c.podConfig().AggregateStepStatus)
+		assert.Equal(t, resource.MustParse("1Gi"), c.SidecarResources.Limits["memory"])
+		assert.Equal(t, map[string]string{"kubernetes.io/os": "linux"}, c.NodeSelector)
+		assert.Equal(t, []corev1.Toleration{{Key: "foo", Operator: corev1.TolerationOpExists}}, c.Tolerations)
+		if assert.NotNil(t, c.Archive) {
+			assert.Equal(t, "mysql", c.Archive.Driver)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := newConfig(map[string]string{"updateInterval": "xx"})
+		assert.Error(t, err)
+		_, err = newConfig(map[string]string{"nodeSelector": "- a"})
+		assert.Error(t, err)
+		_, err = newConfig(map[string]string{"degradedErrorRatio": "high"})
+		assert.Error(t, err)
+		_, err = newConfig(map[string]string{"archive": "driver: postgres\n"})
+		assert.EqualError(t, err, `archive driver "postgres" is not supported, only "mysql" is`)
+	})
+}
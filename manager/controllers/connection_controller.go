@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+// ConnectionReconciler validates connections and cluster connections, recording the result in their status
+type ConnectionReconciler struct {
+	client.Client
+	Log                 logr.Logger
+	KubernetesInterface kubernetes.Interface
+	Namespaces          *Namespaces
+}
+
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=connections,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=connections/status,verbs=update
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=clusterconnections,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=clusterconnections/status,verbs=update
+// +kubebuilder:rbac:groups=,resources=secrets,verbs=get
+func (r *ConnectionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	conn := &dfv1.Connection{}
+	if err := r.Get(ctx, req.NamespacedName, conn); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	err := r.validate(ctx, conn.Namespace, conn.Spec)
+	if newStatus := newConnectionStatus(conn.Status, conn.Generation, err); newStatus != nil {
+		r.Log.Info("updating connection status", "connection", req.NamespacedName, "err", err)
+		conn.Status = *newStatus
+		if err := r.Status().Update(ctx, conn); util.IgnoreConflict(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+	if err != nil { // we are not notified when the secrets change, so we must check them again
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *ConnectionReconciler) reconcileClusterConnection(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	conn := &dfv1.ClusterConnection{}
+	if err := r.Get(ctx, req.NamespacedName, conn); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// the secrets are in each pipeline's namespace, so we cannot check them
+	err := conn.Spec.Validate()
+	if newStatus := newConnectionStatus(conn.Status, conn.Generation, err); newStatus != nil {
+		r.Log.Info("updating cluster connection status", "clusterConnection", req.Name, "err", err)
+		conn.Status = *newStatus
+		if err := r.Status().Update(ctx, conn); util.IgnoreConflict(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// validate returns an error if the spec is invalid, or any secret key it references does not exist
+func (r *ConnectionReconciler) validate(ctx context.Context, namespace string, spec dfv1.ConnectionSpec) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	secrets := r.KubernetesInterface.CoreV1().Secrets(namespace)
+	for _, x := range spec.GetSecretKeySelectors() {
+		secret, err := secrets.Get(ctx, x.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get secret %q: %w", x.Name, err)
+		}
+		if _, ok := secret.Data[x.Key]; !ok {
+			return fmt.Errorf("secret %q does not have key %q", x.Name, x.Key)
+		}
+	}
+	return nil
+}
+
+// newConnectionStatus returns the status for the validation error, or nil if it has not changed
+func newConnectionStatus(old dfv1.ConnectionStatus, generation int64, err error) *dfv1.ConnectionStatus {
+	newStatus := old.DeepCopy()
+	newStatus.ObservedGeneration = generation
+	c := metav1.Condition{Type: dfv1.ConditionValid, Status: metav1.ConditionTrue, Reason: "Valid"}
+	if err != nil {
+		c.Status, c.Reason, c.Message = metav1.ConditionFalse, "Invalid", err.Error()
+	}
+	meta.SetStatusCondition(&newStatus.Conditions, c)
+	if notEqual, _ := util.NotEqual(old, *newStatus); !notEqual {
+		return nil
+	}
+	return newStatus
+}
+
+func (r *ConnectionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&dfv1.Connection{})
+	if err := r.Namespaces.watchNamespaces(b, r.Client, func() client.ObjectList { return &dfv1.ConnectionList{} }).
+		WithEventFilter(r.Namespaces.Predicate()).
+		Complete(r); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dfv1.ClusterConnection{}).
+		Complete(reconcile.Func(r.reconcileClusterConnection))
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestConnectionReconciler(t *testing.T) {
+	ctx := context.Background()
+	token := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "my-secret"}, Key: "token"}
+	valid := &dfv1.Connection{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "valid", Generation: 1},
+		Spec:       dfv1.ConnectionSpec{STAN: &dfv1.STANConnection{NATSURL: "nats", ClusterID: "stan", Auth: &dfv1.STANAuth{Token: token}}},
+	}
+	missingKey := &dfv1.Connection{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "missing-key", Generation: 1},
+		Spec:       dfv1.ConnectionSpec{S3: &dfv1.S3Connection{Credentials: &dfv1.AWSCredentials{AccessKeyID: *token, SecretAccessKey: corev1.SecretKeySelector{LocalObjectReference: token.LocalObjectReference, Key: "other"}}}},
+	}
+	cluster := &dfv1.ClusterConnection{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Generation: 1},
+		Spec:       dfv1.ConnectionSpec{Kafka: &dfv1.KafkaConfig{}},
+	}
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	r := &ConnectionReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(valid, missingKey, cluster).Build(),
+		Log:    ctrl.Log,
+		KubernetesInterface: kubefake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-secret"},
+			Data:       map[string][]byte{"token": []byte("my-token")},
+		}),
+	}
+	t.Run("Valid", func(t *testing.T) {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(valid)})
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		conn := &dfv1.Connection{}
+		assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(valid), conn))
+		assert.Equal(t, int64(1), conn.Status.ObservedGeneration)
+		assert.True(t, meta.IsStatusConditionTrue(conn.Status.Conditions, dfv1.ConditionValid))
+		assert.False(t, conn.Status.IsInvalid(1))
+	})
+	t.Run("MissingKey", func(t *testing.T) {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(missingKey)})
+		assert.NoError(t, err)
+		assert.NotZero(t, result.RequeueAfter)
+		conn := &dfv1.Connection{}
+		assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(missingKey), conn))
+		assert.True(t, conn.Status.IsInvalid(1))
+		assert.Equal(t, `secret "my-secret" does not have key "other"`, meta.FindStatusCondition(conn.Status.Conditions, dfv1.ConditionValid).Message)
+		assert.False(t, conn.Status.IsInvalid(2), "the status is for an older generation")
+	})
+	t.Run("Cluster", func(t *testing.T) {
+		_, err := r.reconcileClusterConnection(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cluster)})
+		assert.NoError(t, err)
+		conn := &dfv1.ClusterConnection{}
+		assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(cluster), conn))
+		assert.Equal(t, "kafka brokers must be specified", meta.FindStatusCondition(conn.Status.Conditions, dfv1.ConditionValid).Message)
+	})
+}
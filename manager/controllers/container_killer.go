@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/argoproj-labs/argo-dataflow/shared/containerkiller"
+)
+
+// killContainer kills the pod's container, logging rather than returning any error, as the next reconciliation will
+// try again, and returns whether it was killed
+func killContainer(log logr.Logger, k containerkiller.Interface, pod corev1.Pod, container string) bool {
+	if err := k.KillContainer(pod, container); err != nil {
+		log.Error(err, "failed to kill container", "pod", pod.Name, "container", container)
+		return false
+	}
+	containersKilledTotal.WithLabelValues(pod.Namespace, container).Inc()
+	return true
+}
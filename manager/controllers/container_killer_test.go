@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type fakeContainerKiller struct{ err error }
+
+func (k fakeContainerKiller) KillContainer(corev1.Pod, string) error { return k.err }
+
+func Test_killContainer(t *testing.T) {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kill-test", Name: "my-pod"}}
+	counter := containersKilledTotal.WithLabelValues("kill-test", "main")
+	before := testutil.ToFloat64(counter)
+	assert.True(t, killContainer(ctrl.Log, fakeContainerKiller{}, pod, "main"))
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	assert.False(t, killContainer(ctrl.Log, fakeContainerKiller{err: fmt.Errorf("failed")}, pod, "main"))
+	assert.Equal(t, before+1, testutil.ToFloat64(counter), "not counted")
+}
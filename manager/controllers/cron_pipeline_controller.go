@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+// CronPipelineReconciler creates pipelines on a schedule, and deletes old ones
+type CronPipelineReconciler struct {
+	client.Client
+	Log        logr.Logger
+	Recorder   record.EventRecorder
+	Namespaces *Namespaces
+}
+
+// the same as the cron source
+var cronPipelineParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// the most missed schedules we look through to find the latest
+const maxMissedSchedules = 1000
+
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=cronpipelines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=cronpipelines/status,verbs=update
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=pipelines,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=,resources=events,verbs=create;patch
+func (r *CronPipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cronPipeline := &dfv1.CronPipeline{}
+	if err := r.Get(ctx, req.NamespacedName, cronPipeline); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if cronPipeline.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+	return r.reconcile(ctx, cronPipeline, time.Now())
+}
+
+func (r *CronPipelineReconciler) reconcile(ctx context.Context, cronPipeline *dfv1.CronPipeline, now time.Time) (ctrl.Result, error) {
+	log := r.Log.WithValues("cronPipeline", client.ObjectKeyFromObject(cronPipeline))
+
+	pipelines := &dfv1.PipelineList{}
+	if err := r.List(ctx, pipelines, client.InNamespace(cronPipeline.Namespace), client.MatchingLabels{dfv1.KeyCronPipelineName: cronPipeline.Name}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+
+	newStatus := cronPipeline.Status.DeepCopy()
+	newStatus.Message = ""
+	var active []string
+	var succeeded, failed []dfv1.Pipeline
+	for _, pl := range pipelines.Items {
+		// pipelines with an invalid spec fail without the condition
+		if !meta.IsStatusConditionTrue(pl.Status.Conditions, dfv1.ConditionCompleted) && !pl.Status.Phase.Completed() {
+			active = append(active, pl.Name)
+		} else if pl.Status.Phase == dfv1.PipelineSucceeded {
+			succeeded = append(succeeded, pl)
+			if x := newStatus.LastSuccessfulTime; x == nil || x.Before(&pl.Status.LastUpdated) {
+				newStatus.LastSuccessfulTime = pl.Status.LastUpdated.DeepCopy()
+			}
+		} else {
+			failed = append(failed, pl)
+		}
+	}
+
+	if err := r.deleteOldest(ctx, succeeded, cronPipeline.Spec.GetSuccessfulPipelinesHistoryLimit()); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.deleteOldest(ctx, failed, cronPipeline.Spec.GetFailedPipelinesHistoryLimit()); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	requeueAfter := time.Duration(0)
+	if schedule, err := cronPipelineParser.Parse(cronPipeline.Spec.Schedule); err != nil {
+		newStatus.Message = fmt.Sprintf("invalid schedule: %v", err)
+	} else {
+		last := cronPipeline.CreationTimestamp.Time
+		if x := newStatus.LastScheduleTime; x != nil {
+			last = x.Time
+		}
+		if scheduledTime := getScheduledTime(schedule, last, now); !scheduledTime.IsZero() {
+			// a suspended cron pipeline skips its schedules, rather than catching up when it is resumed
+			if !cronPipeline.Spec.Suspend {
+				var err error
+				if active, err = r.run(ctx, cronPipeline, scheduledTime, active); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			newStatus.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+			last = scheduledTime
+		}
+		requeueAfter = schedule.Next(last).Sub(now) // "@every" schedules are relative to the last one
+	}
+
+	sort.Strings(active)
+	newStatus.Active = active
+
+	if notEqual, patch := util.NotEqual(cronPipeline.Status, *newStatus); notEqual {
+		log.Info("updating cron pipeline status", "patch", patch)
+		cronPipeline.Status = *newStatus
+		if err := r.Status().Update(ctx, cronPipeline); util.IgnoreConflict(err) != nil { // conflict is ok, we will reconcile again soon
+			return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// run creates the pipeline for the scheduled time, according to the concurrency policy, and returns the new active
+// pipelines
+func (r *CronPipelineReconciler) run(ctx context.Context, cronPipeline *dfv1.CronPipeline, scheduledTime time.Time, active []string) ([]string, error) {
+	switch cronPipeline.Spec.GetConcurrencyPolicy() {
+	case dfv1.ConcurrencyForbid:
+		if len(active) > 0 {
+			r.Recorder.Eventf(cronPipeline, "Normal", "Skipped", "Skipped schedule %s as %d pipelines are still running", scheduledTime.Format(time.RFC3339), len(active))
+			return active, nil
+		}
+	case dfv1.ConcurrencyReplace:
+		for _, name := range active {
+			if err := r.Delete(ctx, &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: cronPipeline.Namespace, Name: name}}); client.IgnoreNotFound(err) != nil {
+				return nil, fmt.Errorf("failed to delete pipeline %q: %w", name, err)
+			}
+			r.Recorder.Eventf(cronPipeline, "Normal", "Deleted", "Deleted running pipeline %s", name)
+		}
+		active = nil
+	}
+	obj := newCronPipelinePipeline(cronPipeline, scheduledTime)
+	if err := r.Create(ctx, obj); apierr.IsAlreadyExists(err) {
+		// we created it before, but failed to update the status
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to create pipeline %q: %w", obj.Name, err)
+	} else {
+		r.Recorder.Eventf(cronPipeline, "Normal", "Created", "Created pipeline %s", obj.Name)
+	}
+	return append(active, obj.Name), nil
+}
+
+// deleteOldest deletes all but the newest `limit` pipelines
+func (r *CronPipelineReconciler) deleteOldest(ctx context.Context, pipelines []dfv1.Pipeline, limit int) error {
+	if len(pipelines) <= limit {
+		return nil
+	}
+	sort.Slice(pipelines, func(i, j int) bool {
+		a, b := pipelines[i], pipelines[j]
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return b.CreationTimestamp.Before(&a.CreationTimestamp)
+		}
+		return a.Name > b.Name // names end with the scheduled time
+	})
+	for _, pl := range pipelines[limit:] {
+		r.Log.Info("deleting old pipeline", "pipeline", pl.Name)
+		if err := r.Delete(ctx, &pl); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete pipeline %q: %w", pl.Name, err)
+		}
+	}
+	return nil
+}
+
+// getScheduledTime returns the latest time the pipeline was scheduled to run after `last` and no later than `now`, or
+// zero if it was not
+func getScheduledTime(schedule cron.Schedule, last, now time.Time) time.Time {
+	var t time.Time
+	for next, n := schedule.Next(last), 0; !next.After(now); next, n = schedule.Next(next), n+1 {
+		if n == maxMissedSchedules { // e.g. a frequent schedule while the controller was down
+			return now
+		}
+		t = next
+	}
+	return t
+}
+
+func newCronPipelinePipeline(cronPipeline *dfv1.CronPipeline, scheduledTime time.Time) *dfv1.Pipeline {
+	x := cronPipeline.Spec.Template
+	obj := &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       cronPipeline.Namespace,
+			Name:            fmt.Sprintf("%s-%d", cronPipeline.Name, scheduledTime.Unix()),
+			Labels:          map[string]string{},
+			Annotations:     map[string]string{},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cronPipeline.GetObjectMeta(), dfv1.CronPipelineGroupVersionKind)},
+		},
+		Spec: *x.Spec.DeepCopy(),
+	}
+	if m := x.Metadata; m != nil {
+		for k, v := range m.Labels {
+			obj.Labels[k] = v
+		}
+		for k, v := range m.Annotations {
+			obj.Annotations[k] = v
+		}
+	}
+	obj.Labels[dfv1.KeyCronPipelineName] = cronPipeline.Name
+	return obj
+}
+
+func (r *CronPipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&dfv1.CronPipeline{}).
+		Owns(&dfv1.Pipeline{})
+	return r.Namespaces.watchNamespaces(b, r.Client, func() client.ObjectList { return &dfv1.CronPipelineList{} }).
+		WithEventFilter(r.Namespaces.Predicate()).
+		WithOptions(r.Namespaces.controllerOptions()).
+		Complete(withErrorMetrics("cronpipeline", r.Namespaces.withRateLimit(r)))
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+var cronPipelineCreated = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestCronPipelineReconciler(objs ...client.Object) *CronPipelineReconciler {
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	return &CronPipelineReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Log:      ctrl.Log,
+		Recorder: record.NewFakeRecorder(100),
+	}
+}
+
+func newTestCronPipeline(spec dfv1.CronPipelineSpec) *dfv1.CronPipeline {
+	return &dfv1.CronPipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-cpl", CreationTimestamp: metav1.Time{Time: cronPipelineCreated}},
+		Spec:       spec,
+	}
+}
+
+func listCronPipelinePipelines(t *testing.T, r *CronPipelineReconciler) []string {
+	pipelines := &dfv1.PipelineList{}
+	assert.NoError(t, r.List(context.Background(), pipelines, client.MatchingLabels{dfv1.KeyCronPipelineName: "my-cpl"}))
+	var names []string
+	for _, pl := range pipelines.Items {
+		names = append(names, pl.Name)
+	}
+	return names
+}
+
+func Test_getScheduledTime(t *testing.T) {
+	schedule, err := cronPipelineParser.Parse("@every 1h")
+	assert.NoError(t, err)
+	assert.True(t, getScheduledTime(schedule, cronPipelineCreated, cronPipelineCreated.Add(30*time.Minute)).IsZero())
+	assert.Equal(t, cronPipelineCreated.Add(time.Hour), getScheduledTime(schedule, cronPipelineCreated, cronPipelineCreated.Add(time.Hour)))
+	assert.Equal(t, cronPipelineCreated.Add(3*time.Hour), getScheduledTime(schedule, cronPipelineCreated, cronPipelineCreated.Add(3*time.Hour+time.Minute)), "the latest missed schedule")
+	now := cronPipelineCreated.Add(2000 * time.Hour)
+	assert.Equal(t, now, getScheduledTime(schedule, cronPipelineCreated, now), "too many missed schedules")
+}
+
+func Test_newCronPipelinePipeline(t *testing.T) {
+	cronPipeline := newTestCronPipeline(dfv1.CronPipelineSpec{Template: dfv1.CronPipelineTemplate{
+		Metadata: &dfv1.Metadata{Labels: map[string]string{"foo": "bar"}, Annotations: map[string]string{"baz": "qux"}},
+		Spec:     dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Name: "main"}}},
+	}})
+	pl := newCronPipelinePipeline(cronPipeline, cronPipelineCreated)
+	assert.Equal(t, "my-ns", pl.Namespace)
+	assert.Equal(t, "my-cpl-1609459200", pl.Name)
+	assert.Equal(t, map[string]string{"foo": "bar", dfv1.KeyCronPipelineName: "my-cpl"}, pl.Labels)
+	assert.Equal(t, map[string]string{"baz": "qux"}, pl.Annotations)
+	assert.Len(t, pl.OwnerReferences, 1)
+	assert.Len(t, pl.Spec.Steps, 1)
+}
+
+func TestCronPipelineReconciler_reconcile(t *testing.T) {
+	ctx := context.Background()
+	get := func(t *testing.T, r *CronPipelineReconciler) *dfv1.CronPipeline {
+		x := &dfv1.CronPipeline{}
+		assert.NoError(t, r.Get(ctx, client.ObjectKey{Namespace: "my-ns", Name: "my-cpl"}, x))
+		return x
+	}
+	t.Run("InvalidSchedule", func(t *testing.T) {
+		r := newTestCronPipelineReconciler(newTestCronPipeline(dfv1.CronPipelineSpec{Schedule: "foo"}))
+		_, err := r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Contains(t, get(t, r).Status.Message, "invalid schedule")
+		assert.Empty(t, listCronPipelinePipelines(t, r))
+	})
+	t.Run("Schedule", func(t *testing.T) {
+		r := newTestCronPipelineReconciler(newTestCronPipeline(dfv1.CronPipelineSpec{Schedule: "@every 1h"}))
+		result, err := r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(30*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Minute, result.RequeueAfter)
+		assert.Empty(t, listCronPipelinePipelines(t, r))
+
+		result, err = r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, result.RequeueAfter)
+		assert.Equal(t, []string{"my-cpl-1609462800"}, listCronPipelinePipelines(t, r))
+		x := get(t, r)
+		assert.Equal(t, []string{"my-cpl-1609462800"}, x.Status.Active)
+		if assert.NotNil(t, x.Status.LastScheduleTime) {
+			assert.True(t, x.Status.LastScheduleTime.Time.Equal(cronPipelineCreated.Add(time.Hour)))
+		}
+
+		_, err = r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(time.Hour+time.Minute))
+		assert.NoError(t, err)
+		assert.Len(t, listCronPipelinePipelines(t, r), 1, "not scheduled again")
+	})
+	t.Run("Suspend", func(t *testing.T) {
+		r := newTestCronPipelineReconciler(newTestCronPipeline(dfv1.CronPipelineSpec{Schedule: "@every 1h", Suspend: true}))
+		_, err := r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Empty(t, listCronPipelinePipelines(t, r))
+		assert.NotNil(t, get(t, r).Status.LastScheduleTime, "skipped")
+	})
+	for policy, want := range map[dfv1.ConcurrencyPolicy][]string{
+		dfv1.ConcurrencyAllow:   {"my-cpl-1609462800", "my-cpl-1609466400"},
+		dfv1.ConcurrencyForbid:  {"my-cpl-1609462800"},
+		dfv1.ConcurrencyReplace: {"my-cpl-1609466400"},
+	} {
+		t.Run(string(policy), func(t *testing.T) {
+			r := newTestCronPipelineReconciler(newTestCronPipeline(dfv1.CronPipelineSpec{Schedule: "@every 1h", ConcurrencyPolicy: policy}))
+			_, err := r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(time.Hour))
+			assert.NoError(t, err)
+			_, err = r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(2*time.Hour))
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, want, listCronPipelinePipelines(t, r))
+			assert.ElementsMatch(t, want, get(t, r).Status.Active)
+		})
+	}
+	t.Run("HistoryLimits", func(t *testing.T) {
+		completed := func(name string, phase dfv1.PipelinePhase) *dfv1.Pipeline {
+			return &dfv1.Pipeline{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: name, Labels: map[string]string{dfv1.KeyCronPipelineName: "my-cpl"}},
+				Status: dfv1.PipelineStatus{
+					Phase:       phase,
+					Conditions:  []metav1.Condition{{Type: dfv1.ConditionCompleted, Status: metav1.ConditionTrue}},
+					LastUpdated: metav1.Time{Time: cronPipelineCreated},
+				},
+			}
+		}
+		r := newTestCronPipelineReconciler(
+			newTestCronPipeline(dfv1.CronPipelineSpec{Schedule: "@every 1h", SuccessfulPipelinesHistoryLimit: uint32Ptr(1)}),
+			completed("my-cpl-1", dfv1.PipelineSucceeded),
+			completed("my-cpl-2", dfv1.PipelineSucceeded),
+			completed("my-cpl-3", dfv1.PipelineFailed),
+			completed("my-cpl-4", dfv1.PipelineFailed),
+		)
+		_, err := r.reconcile(ctx, get(t, r), cronPipelineCreated.Add(30*time.Minute))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"my-cpl-2", "my-cpl-4"}, listCronPipelinePipelines(t, r))
+		x := get(t, r)
+		assert.Empty(t, x.Status.Active)
+		assert.NotNil(t, x.Status.LastSuccessfulTime)
+	})
+}
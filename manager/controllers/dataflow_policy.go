@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// policies are the policies of a namespace, all of which apply
+type policies []dfv1.DataflowPolicy
+
+func getPolicies(ctx context.Context, c client.Reader, namespace string) (policies, error) {
+	list := &dfv1.DataflowPolicyList{}
+	if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+	return list.Items, nil
+}
+
+// getViolations returns a message for each way the step does not comply with any of the policies
+func (p policies) getViolations(step dfv1.StepSpec) []string {
+	var violations []string
+	for _, x := range p {
+		for _, v := range x.Spec.GetViolations(step) {
+			violations = append(violations, fmt.Sprintf("policy %q: %s", x.Name, v))
+		}
+	}
+	return violations
+}
+
+// getMaxReplicas returns the least max replicas of the policies, and the name of the policy it is from, or nil if
+// replicas are not limited
+func (p policies) getMaxReplicas() (*int, string) {
+	return p.getMin(func(x dfv1.DataflowPolicySpec) *uint32 { return x.MaxReplicas })
+}
+
+// getMaxPods returns the least max pods of the policies, and the name of the policy it is from, or nil if pods are not
+// limited
+func (p policies) getMaxPods() (*int, string) {
+	return p.getMin(func(x dfv1.DataflowPolicySpec) *uint32 { return x.MaxPods })
+}
+
+func (p policies) getMin(get func(x dfv1.DataflowPolicySpec) *uint32) (*int, string) {
+	var min *int
+	name := ""
+	for _, x := range p {
+		if v := get(x.Spec); v != nil && (min == nil || int(*v) < *min) {
+			n := int(*v)
+			min, name = &n, x.Name
+		}
+	}
+	return min, name
+}
+
+// checkPolicies returns an invalid spec error if any step does not comply with the namespace's policies, so that
+// non-compliant steps are never created
+func (r *PipelineReconciler) checkPolicies(ctx context.Context, namespace string, spec dfv1.PipelineSpec) error {
+	p, err := getPolicies(ctx, r.Client, namespace)
+	if err != nil {
+		return err
+	}
+	for _, step := range spec.Steps {
+		if violations := p.getViolations(step); len(violations) > 0 {
+			return errInvalidSpec{fmt.Errorf("step %q: %s", step.Name, violations[0])}
+		}
+	}
+	return nil
+}
+
+// pipelinesForPolicy returns requests for every pipeline in the policy's namespace, so changes apply to them
+func (r *PipelineReconciler) pipelinesForPolicy(obj client.Object) []reconcile.Request {
+	pipelines := &dfv1.PipelineList{}
+	if err := r.List(context.Background(), pipelines, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list pipelines", "policy", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, pl := range pipelines.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pl)})
+	}
+	return requests
+}
+
+// limitReplicas returns the replicas the step may have under the policies, and a message for each limit applied. Steps
+// are not scaled up beyond the policies' max pods, but neither are they scaled down to meet it.
+//
+// Max pods is best-effort: the headroom is computed from the other steps' status replicas, and nothing is reserved, so
+// steps in the same namespace that are reconciled concurrently (or from a stale cache) may each claim the same headroom,
+// and together exceed it.
+func (r *StepReconciler) limitReplicas(ctx context.Context, step *dfv1.Step, p policies, currentReplicas, desiredReplicas int) (int, []string, error) {
+	var messages []string
+	if x, name := p.getMaxReplicas(); x != nil && desiredReplicas > *x {
+		messages = append(messages, fmt.Sprintf("policy %q: limited to %d replicas", name, *x))
+		desiredReplicas = *x
+	}
+	if x, name := p.getMaxPods(); x != nil && desiredReplicas > currentReplicas {
+		steps := &dfv1.StepList{}
+		if err := r.Client.List(ctx, steps, client.InNamespace(step.Namespace)); err != nil {
+			return 0, nil, fmt.Errorf("failed to list steps: %w", err)
+		}
+		available := *x
+		for _, s := range steps.Items {
+			if s.Name != step.Name {
+				available -= s.Status.GetReplicas()
+			}
+		}
+		if desiredReplicas > available {
+			messages = append(messages, fmt.Sprintf("policy %q: limited to %d pods in the namespace", name, *x))
+			desiredReplicas = available
+			if desiredReplicas < currentReplicas {
+				desiredReplicas = currentReplicas
+			}
+		}
+	}
+	return desiredReplicas, messages, nil
+}
+
+// stepsForPolicy returns requests for every step in the policy's namespace, so changes apply to them
+func (r *StepReconciler) stepsForPolicy(obj client.Object) []reconcile.Request {
+	steps := &dfv1.StepList{}
+	if err := r.List(context.Background(), steps, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list steps", "policy", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, s := range steps.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&s)})
+	}
+	return requests
+}
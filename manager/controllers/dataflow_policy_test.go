@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func newTestPolicy(name string, spec dfv1.DataflowPolicySpec) *dfv1.DataflowPolicy {
+	return &dfv1.DataflowPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: name}, Spec: spec}
+}
+
+func uint32Ptr(v uint32) *uint32 { return &v }
+
+func Test_policies(t *testing.T) {
+	p := policies{
+		*newTestPolicy("a", dfv1.DataflowPolicySpec{MaxReplicas: uint32Ptr(4), AllowedSinks: []string{"log"}}),
+		*newTestPolicy("b", dfv1.DataflowPolicySpec{MaxReplicas: uint32Ptr(2), MaxPods: uint32Ptr(10)}),
+	}
+	t.Run("getViolations", func(t *testing.T) {
+		assert.Empty(t, p.getViolations(dfv1.StepSpec{Sinks: []dfv1.Sink{{Name: "default", Log: &dfv1.Log{}}}}))
+		assert.Equal(t, []string{`policy "a": sink "default" type "kafka" is not allowed`}, p.getViolations(dfv1.StepSpec{Sinks: []dfv1.Sink{{Name: "default", Kafka: &dfv1.Kafka{}}}}))
+	})
+	t.Run("getMaxReplicas", func(t *testing.T) {
+		x, name := p.getMaxReplicas()
+		if assert.NotNil(t, x) {
+			assert.Equal(t, 2, *x)
+		}
+		assert.Equal(t, "b", name)
+	})
+	t.Run("getMaxPods", func(t *testing.T) {
+		x, name := p.getMaxPods()
+		if assert.NotNil(t, x) {
+			assert.Equal(t, 10, *x)
+		}
+		assert.Equal(t, "b", name)
+		x, _ = policies{}.getMaxPods()
+		assert.Nil(t, x)
+	})
+}
+
+func TestPipelineReconciler_checkPolicies(t *testing.T) {
+	ctx := context.Background()
+	r := newTestPipelineReconciler(newTestPolicy("my-policy", dfv1.DataflowPolicySpec{AllowedImages: []string{"quay.io/my-org/"}}))
+	assert.NoError(t, r.checkPolicies(ctx, "my-ns", dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Name: "main", Container: &dfv1.Container{Image: "quay.io/my-org/main"}}}}))
+	assert.NoError(t, r.checkPolicies(ctx, "other-ns", dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Name: "main", Container: &dfv1.Container{Image: "docker.io/main"}}}}))
+	err := r.checkPolicies(ctx, "my-ns", dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Name: "main", Container: &dfv1.Container{Image: "docker.io/main"}}}})
+	if assert.Error(t, err) {
+		assert.True(t, errors.As(err, &errInvalidSpec{}))
+		assert.EqualError(t, err, `step "main": policy "my-policy": image "docker.io/main" is not allowed`)
+	}
+}
+
+func TestStepReconciler_limitReplicas(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	step := &dfv1.Step{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main"}, Status: dfv1.StepStatus{Replicas: 1}}
+	other := &dfv1.Step{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-other"}, Status: dfv1.StepStatus{Replicas: 3}}
+	r := &StepReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(step, other).Build(), Log: ctrl.Log}
+	t.Run("NoPolicies", func(t *testing.T) {
+		replicas, messages, err := r.limitReplicas(ctx, step, nil, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, replicas)
+		assert.Empty(t, messages)
+	})
+	t.Run("MaxReplicas", func(t *testing.T) {
+		replicas, messages, err := r.limitReplicas(ctx, step, policies{*newTestPolicy("my-policy", dfv1.DataflowPolicySpec{MaxReplicas: uint32Ptr(2)})}, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, replicas)
+		assert.Equal(t, []string{`policy "my-policy": limited to 2 replicas`}, messages)
+	})
+	p := policies{*newTestPolicy("my-policy", dfv1.DataflowPolicySpec{MaxPods: uint32Ptr(5)})}
+	t.Run("MaxPods", func(t *testing.T) {
+		replicas, messages, err := r.limitReplicas(ctx, step, p, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, replicas, "the other step has 3 pods")
+		assert.Equal(t, []string{`policy "my-policy": limited to 5 pods in the namespace`}, messages)
+	})
+	t.Run("MaxPodsScaleDown", func(t *testing.T) {
+		replicas, messages, err := r.limitReplicas(ctx, step, p, 1, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, replicas)
+		assert.Empty(t, messages)
+	})
+	t.Run("MaxPodsExceeded", func(t *testing.T) {
+		other.Status.Replicas = 5
+		assert.NoError(t, r.Client.Status().Update(ctx, other))
+		replicas, _, err := r.limitReplicas(ctx, step, p, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, replicas, "steps are not scaled down to meet the limit")
+	})
+}
+
+func TestStepReconciler_stepsForPolicy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	r := &StepReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&dfv1.Step{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main"}},
+		&dfv1.Step{ObjectMeta: metav1.ObjectMeta{Namespace: "other-ns", Name: "my-pl-main"}},
+	).Build(), Log: ctrl.Log}
+	requests := r.stepsForPolicy(newTestPolicy("my-policy", dfv1.DataflowPolicySpec{}))
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, client.ObjectKey{Namespace: "my-ns", Name: "my-pl-main"}, requests[0].NamespacedName)
+	}
+}
+
+func TestPipelineReconciler_pipelinesForPolicy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	r := &PipelineReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}},
+		&dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "other-ns", Name: "my-pl"}},
+	).Build(), Log: ctrl.Log}
+	requests := r.pipelinesForPolicy(newTestPolicy("my-policy", dfv1.DataflowPolicySpec{}))
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, client.ObjectKey{Namespace: "my-ns", Name: "my-pl"}, requests[0].NamespacedName)
+	}
+}
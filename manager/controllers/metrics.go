@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+var (
+	scaleEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dataflow",
+		Name:      "step_scale_events_total",
+		Help:      "Number of times steps were scaled, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_step_scale_events_total",
+	}, []string{"namespace", "pipeline", "step", "direction"})
+	containersKilledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dataflow",
+		Name:      "containers_killed_total",
+		Help:      "Number of containers killed, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_containers_killed_total",
+	}, []string{"namespace", "container"})
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dataflow",
+		Name:      "reconcile_errors_total",
+		Help:      "Number of reconcile errors, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_reconcile_errors_total",
+	}, []string{"controller", "type"})
+	podStartupSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "dataflow",
+		Name:      "pod_startup_seconds",
+		Help:      "Time from a pod being created to it running, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_pod_startup_seconds",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(scaleEventsTotal, containersKilledTotal, reconcileErrorsTotal, podStartupSeconds)
+}
+
+var (
+	pipelinesDesc       = prometheus.NewDesc("dataflow_pipelines", "Number of pipelines, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_pipelines", []string{"namespace", "phase"}, nil)
+	stepsDesc           = prometheus.NewDesc("dataflow_steps", "Number of steps, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_steps", []string{"namespace", "phase"}, nil)
+	desiredReplicasDesc = prometheus.NewDesc("dataflow_step_desired_replicas", "Desired replicas of the step, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_step_desired_replicas", []string{"namespace", "pipeline", "step"}, nil)
+	currentReplicasDesc = prometheus.NewDesc("dataflow_step_current_replicas", "Current replicas of the step, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_step_current_replicas", []string{"namespace", "pipeline", "step"}, nil)
+)
+
+// metricsCollector reports the pipelines and steps from the cache each time it is scraped, so that series for deleted
+// objects go away
+type metricsCollector struct {
+	client.Reader
+}
+
+// NewMetricsCollector returns a collector of the pipelines and steps that the reader can list.
+func NewMetricsCollector(r client.Reader) prometheus.Collector {
+	return &metricsCollector{r}
+}
+
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pipelinesDesc
+	ch <- stepsDesc
+	ch <- desiredReplicasDesc
+	ch <- currentReplicasDesc
+}
+
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	type key struct{ namespace, phase string }
+	pipelines := &dfv1.PipelineList{}
+	if err := c.List(ctx, pipelines); err != nil {
+		ch <- prometheus.NewInvalidMetric(pipelinesDesc, err)
+	} else {
+		counts := map[key]int{}
+		for _, pl := range pipelines.Items {
+			counts[key{pl.Namespace, string(pl.Status.Phase)}]++
+		}
+		for k, n := range counts {
+			ch <- prometheus.MustNewConstMetric(pipelinesDesc, prometheus.GaugeValue, float64(n), k.namespace, k.phase)
+		}
+	}
+	steps := &dfv1.StepList{}
+	if err := c.List(ctx, steps); err != nil {
+		ch <- prometheus.NewInvalidMetric(stepsDesc, err)
+		return
+	}
+	counts := map[key]int{}
+	for _, step := range steps.Items {
+		counts[key{step.Namespace, string(step.Status.Phase)}]++
+		pipelineName := step.GetLabels()[dfv1.KeyPipelineName]
+		ch <- prometheus.MustNewConstMetric(desiredReplicasDesc, prometheus.GaugeValue, float64(step.Spec.Replicas), step.Namespace, pipelineName, step.Spec.Name)
+		ch <- prometheus.MustNewConstMetric(currentReplicasDesc, prometheus.GaugeValue, float64(step.Status.Replicas), step.Namespace, pipelineName, step.Spec.Name)
+	}
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(stepsDesc, prometheus.GaugeValue, float64(n), k.namespace, k.phase)
+	}
+}
+
+// withErrorMetrics counts the errors returned by the reconciler, by their API reason (e.g. "Conflict"), or "Unknown"
+func withErrorMetrics(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+		result, err := r.Reconcile(ctx, req)
+		if err != nil {
+			reason := string(apierr.ReasonForError(err))
+			if reason == "" {
+				reason = "Unknown"
+			}
+			reconcileErrorsTotal.WithLabelValues(controller, reason).Inc()
+		}
+		return result, err
+	})
+}
+
+// podStartupPredicate observes how long each pod took to start, when it changes to running
+var podStartupPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		old, ok := e.ObjectOld.(*corev1.Pod)
+		pod, ok2 := e.ObjectNew.(*corev1.Pod)
+		if ok && ok2 && old.Status.Phase != corev1.PodRunning && pod.Status.Phase == corev1.PodRunning {
+			podStartupSeconds.WithLabelValues(pod.Namespace).Observe(time.Since(pod.CreationTimestamp.Time).Seconds())
+		}
+		return true
+	},
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_metricsCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	c := NewMetricsCollector(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a"}, Status: dfv1.PipelineStatus{Phase: dfv1.PipelineRunning}},
+		&dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "b"}, Status: dfv1.PipelineStatus{Phase: dfv1.PipelineRunning}},
+		&dfv1.Step{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a-main", Labels: map[string]string{dfv1.KeyPipelineName: "a"}},
+			Spec:       dfv1.StepSpec{Name: "main", Replicas: 2},
+			Status:     dfv1.StepStatus{Phase: dfv1.StepRunning, Replicas: 1},
+		},
+	).Build())
+	err := testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP dataflow_pipelines Number of pipelines, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_pipelines
+# TYPE dataflow_pipelines gauge
+dataflow_pipelines{namespace="ns",phase="Running"} 2
+# HELP dataflow_step_current_replicas Current replicas of the step, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_step_current_replicas
+# TYPE dataflow_step_current_replicas gauge
+dataflow_step_current_replicas{namespace="ns",pipeline="a",step="main"} 1
+# HELP dataflow_step_desired_replicas Desired replicas of the step, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_step_desired_replicas
+# TYPE dataflow_step_desired_replicas gauge
+dataflow_step_desired_replicas{namespace="ns",pipeline="a",step="main"} 2
+# HELP dataflow_steps Number of steps, see https://github.com/argoproj-labs/argo-dataflow/blob/main/docs/METRICS.md#dataflow_steps
+# TYPE dataflow_steps gauge
+dataflow_steps{namespace="ns",phase="Running"} 1
+`))
+	assert.NoError(t, err)
+}
+
+func Test_withErrorMetrics(t *testing.T) {
+	errs := []error{nil, apierr.NewConflict(schema.GroupResource{}, "x", fmt.Errorf("conflict")), fmt.Errorf("unknown")}
+	r := withErrorMetrics("test", reconcile.Func(func(context.Context, ctrl.Request) (ctrl.Result, error) {
+		err := errs[0]
+		errs = errs[1:]
+		return ctrl.Result{}, err
+	}))
+	for i := 0; i < 3; i++ {
+		_, _ = r.Reconcile(context.Background(), ctrl.Request{})
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("test", "Conflict")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("test", "Unknown")))
+}
+
+func Test_podStartupPredicate(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-time.Minute))
+	pending := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "startup", CreationTimestamp: created}, Status: corev1.PodStatus{Phase: corev1.PodPending}}
+	running := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "startup", CreationTimestamp: created}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	assert.True(t, podStartupPredicate.Update(event.UpdateEvent{ObjectOld: pending, ObjectNew: running}))
+	assert.True(t, podStartupPredicate.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: running}))
+	m := &dto.Metric{}
+	assert.NoError(t, podStartupSeconds.WithLabelValues("startup").(prometheus.Metric).Write(m))
+	assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+}
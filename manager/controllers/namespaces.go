@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// Namespaces are the namespaces the controllers watch, when they are not limited to the controller's own namespace. A
+// nil value means only the controller's namespace is watched.
+type Namespaces struct {
+	// Reader reads the namespaces, typically from the cache
+	client.Reader
+	// Selector, if not nil, limits the namespaces to those with matching labels
+	Selector labels.Selector
+	// MaxConcurrentReconciles is how many objects each controller may reconcile at once
+	MaxConcurrentReconciles int
+}
+
+// namespaceSettings are the defaults and limits from a namespace's annotations
+type namespaceSettings struct {
+	// MaxReplicas, if not nil, is the most replicas any step may have
+	MaxReplicas *int
+	// DeletionDelay, if not nil, overrides the controller's deletion delay
+	DeletionDelay *time.Duration
+}
+
+func (n *Namespaces) getNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	ns := &corev1.Namespace{}
+	if err := n.Get(ctx, client.ObjectKey{Name: name}, ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// isTerminating returns true if the namespace is being deleted. It is always false when only the controller's own
+// namespace is watched, as the controller cannot read namespaces.
+func (n *Namespaces) isTerminating(ctx context.Context, name string) (bool, error) {
+	if n == nil {
+		return false, nil
+	}
+	ns, err := n.getNamespace(ctx, name)
+	if apierr.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get namespace %q: %w", name, err)
+	}
+	return !ns.GetDeletionTimestamp().IsZero() || ns.Status.Phase == corev1.NamespaceTerminating, nil
+}
+
+// Predicate filters out objects in namespaces that do not match the selector. Cluster-scoped objects are never
+// filtered out.
+func (n *Namespaces) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		if n == nil || n.Selector == nil || obj.GetNamespace() == "" {
+			return true
+		}
+		ns, err := n.getNamespace(context.Background(), obj.GetNamespace())
+		if err != nil {
+			return false // the namespace is being deleted, or we cannot read it
+		}
+		return n.Selector.Matches(labels.Set(ns.Labels))
+	})
+}
+
+// namespaceChangedPredicate passes namespaces that are created, or whose labels or annotations change, as these decide
+// whether the namespace is selected, and its settings
+var namespaceChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) ||
+			!reflect.DeepEqual(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations())
+	},
+	DeleteFunc: func(event.DeleteEvent) bool { return false },
+}
+
+// watchNamespaces watches the namespaces, if there is a selector, so that when a namespace is labelled to match it,
+// its existing objects are reconciled, rather than waiting for them to change
+func (n *Namespaces) watchNamespaces(b *builder.Builder, r client.Reader, newList func() client.ObjectList) *builder.Builder {
+	if n == nil || n.Selector == nil {
+		return b
+	}
+	return b.Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		return objectsInNamespace(r, newList(), obj.GetName())
+	}), builder.WithPredicates(namespaceChangedPredicate))
+}
+
+// objectsInNamespace returns requests for the objects in the namespace
+func objectsInNamespace(r client.Reader, list client.ObjectList, namespace string) []reconcile.Request {
+	if err := r.List(context.Background(), list, client.InNamespace(namespace)); err != nil {
+		logger.Error(err, "failed to list objects in namespace", "namespace", namespace)
+		return nil
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		logger.Error(err, "failed to extract list", "namespace", namespace)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, item := range items {
+		if obj, ok := item.(client.Object); ok {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+		}
+	}
+	return requests
+}
+
+// getSettings returns the settings from the namespace's annotations
+func (n *Namespaces) getSettings(ctx context.Context, namespace string) (namespaceSettings, error) {
+	s := namespaceSettings{}
+	if n == nil {
+		return s, nil
+	}
+	ns, err := n.getNamespace(ctx, namespace)
+	if apierr.IsNotFound(err) {
+		return s, nil
+	} else if err != nil {
+		return s, fmt.Errorf("failed to get namespace %q: %w", namespace, err)
+	}
+	if v, ok := ns.Annotations[dfv1.KeyMaxReplicas]; ok {
+		x, err := strconv.Atoi(v)
+		if err != nil {
+			return s, fmt.Errorf("namespace %q annotation %s=%s; value must be integer: %w", namespace, dfv1.KeyMaxReplicas, v, err)
+		}
+		s.MaxReplicas = &x
+	}
+	if v, ok := ns.Annotations[dfv1.KeyDeletionDelay]; ok {
+		x, err := time.ParseDuration(v)
+		if err != nil {
+			return s, fmt.Errorf("namespace %q annotation %s=%s; value must be duration: %w", namespace, dfv1.KeyDeletionDelay, v, err)
+		}
+		s.DeletionDelay = &x
+	}
+	return s, nil
+}
+
+// controllerOptions returns the options for a controller
+func (n *Namespaces) controllerOptions() controller.Options {
+	if n == nil {
+		return controller.Options{}
+	}
+	return controller.Options{MaxConcurrentReconciles: n.MaxConcurrentReconciles}
+}
+
+// withRateLimit rate-limits the reconciles of each namespace separately, so that one busy namespace cannot use up the
+// reconciles of the others. This is done when the request is reconciled, rather than when it is queued, as requests
+// queued by changes and requeues, rather than errors, do not go through the queue's rate limiter. A request from a
+// namespace that has used up its rate is requeued until it may be reconciled.
+func (n *Namespaces) withRateLimit(r reconcile.Reconciler) reconcile.Reconciler {
+	if n == nil {
+		return r
+	}
+	limiter := newNamespaceRateLimiter(rate.Limit(10), 100)
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		if d := limiter.delay(req.Namespace); d > 0 {
+			return reconcile.Result{RequeueAfter: d}, nil
+		}
+		return r.Reconcile(ctx, req)
+	})
+}
+
+type namespaceRateLimiter struct {
+	mu       sync.Mutex
+	limit    rate.Limit
+	burst    int
+	limiters map[string]*rate.Limiter
+}
+
+func newNamespaceRateLimiter(limit rate.Limit, burst int) *namespaceRateLimiter {
+	return &namespaceRateLimiter{limit: limit, burst: burst, limiters: map[string]*rate.Limiter{}}
+}
+
+// delay returns zero, and takes a token, if the namespace may be reconciled now, otherwise how long until it may be
+func (r *namespaceRateLimiter) delay(namespace string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.limiters[namespace]
+	if !ok {
+		l = rate.NewLimiter(r.limit, r.burst)
+		r.limiters[namespace] = l
+	}
+	reservation := l.Reserve()
+	if d := reservation.Delay(); d > 0 {
+		reservation.Cancel() // the token is taken when the request is reconciled
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func newTestNamespaces(selector labels.Selector) *Namespaces {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	return &Namespaces{
+		Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        "team-a",
+				Labels:      map[string]string{"dataflow": "true"},
+				Annotations: map[string]string{dfv1.KeyMaxReplicas: "2", dfv1.KeyDeletionDelay: "1h"},
+			}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Annotations: map[string]string{dfv1.KeyMaxReplicas: "two"}}},
+		).Build(),
+		Selector: selector,
+	}
+}
+
+func TestNamespaces_getSettings(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Nil", func(t *testing.T) {
+		var n *Namespaces
+		s, err := n.getSettings(ctx, "team-a")
+		assert.NoError(t, err)
+		assert.Nil(t, s.MaxReplicas)
+	})
+	n := newTestNamespaces(nil)
+	t.Run("Annotated", func(t *testing.T) {
+		s, err := n.getSettings(ctx, "team-a")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, *s.MaxReplicas)
+		assert.Equal(t, time.Hour, *s.DeletionDelay)
+	})
+	t.Run("NotAnnotated", func(t *testing.T) {
+		s, err := n.getSettings(ctx, "team-b")
+		assert.NoError(t, err)
+		assert.Nil(t, s.MaxReplicas)
+		assert.Nil(t, s.DeletionDelay)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := n.getSettings(ctx, "invalid")
+		assert.Error(t, err)
+	})
+}
+
+func TestNamespaces_Predicate(t *testing.T) {
+	create := func(n *Namespaces, obj *dfv1.Pipeline) bool {
+		return n.Predicate().Create(event.CreateEvent{Object: obj})
+	}
+	inNamespace := func(namespace string) *dfv1.Pipeline {
+		return &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "my-pl"}}
+	}
+	var unset *Namespaces
+	assert.True(t, create(unset, inNamespace("team-b")))
+	assert.True(t, create(newTestNamespaces(nil), inNamespace("team-b")))
+	n := newTestNamespaces(labels.SelectorFromSet(labels.Set{"dataflow": "true"}))
+	assert.True(t, create(n, inNamespace("team-a")))
+	assert.False(t, create(n, inNamespace("team-b")))
+	assert.False(t, create(n, inNamespace("not-found")))
+	assert.True(t, n.Predicate().Create(event.CreateEvent{Object: &dfv1.ClusterConnection{}}), "cluster-scoped")
+}
+
+func Test_namespaceChangedPredicate(t *testing.T) {
+	ns := func(labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: labels}}
+	}
+	assert.True(t, namespaceChangedPredicate.Create(event.CreateEvent{Object: ns(nil)}))
+	assert.True(t, namespaceChangedPredicate.Update(event.UpdateEvent{ObjectOld: ns(nil), ObjectNew: ns(map[string]string{"dataflow": "true"})}), "labelled")
+	assert.False(t, namespaceChangedPredicate.Update(event.UpdateEvent{ObjectOld: ns(nil), ObjectNew: ns(nil)}), "unchanged")
+	assert.False(t, namespaceChangedPredicate.Delete(event.DeleteEvent{Object: ns(nil)}))
+}
+
+func Test_objectsInNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	r := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "my-pl"}},
+		&dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "my-pl"}},
+	).Build()
+	requests := objectsInNamespace(r, &dfv1.PipelineList{}, "team-a")
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "my-pl"}}}, requests)
+}
+
+func Test_namespaceRateLimiter(t *testing.T) {
+	r := newNamespaceRateLimiter(1, 1)
+	assert.Zero(t, r.delay("team-a"))
+	assert.NotZero(t, r.delay("team-a"), "team-a has used its burst")
+	assert.NotZero(t, r.delay("team-a"), "still waiting")
+	assert.Zero(t, r.delay("team-b"), "team-b is not affected by team-a")
+}
+
+func TestNamespaces_withRateLimit(t *testing.T) {
+	var reconciled []string
+	r := (&Namespaces{}).withRateLimit(reconcile.Func(func(_ context.Context, req reconcile.Request) (reconcile.Result, error) {
+		reconciled = append(reconciled, req.String())
+		return reconcile.Result{}, nil
+	}))
+	// as the controller does, with plain adds, which are not rate-limited by the queue
+	queue := workqueue.New()
+	defer queue.ShutDown()
+	for i := 0; i < 150; i++ {
+		queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: strconv.Itoa(i)}})
+	}
+	queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "my-pl"}})
+	deferred := 0
+	for queue.Len() > 0 {
+		item, _ := queue.Get()
+		result, err := r.Reconcile(context.Background(), item.(reconcile.Request))
+		assert.NoError(t, err)
+		if result.RequeueAfter > 0 {
+			deferred++
+		}
+		queue.Done(item)
+	}
+	assert.Len(t, reconciled, 101, "team-a's burst, and team-b")
+	assert.Equal(t, "team-b/my-pl", reconciled[100])
+	assert.Equal(t, 50, deferred)
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/manager/archive"
+	shareddb "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/db"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+// getArchive returns the archive for the config, re-opening it if the config has changed, or nil if archiving is
+// disabled
+func (r *PipelineReconciler) getArchive(ctx context.Context, x *dfv1.Database) (archive.Interface, error) {
+	r.archiveMu.Lock()
+	defer r.archiveMu.Unlock()
+	hash := ""
+	if x != nil {
+		hash = util.MustHash(x)
+	}
+	if hash == r.archiveHash {
+		return r.archive, nil
+	}
+	if r.archive != nil {
+		_ = r.archive.Close()
+		r.archive, r.archiveHash = nil, ""
+	}
+	if x == nil {
+		return nil, nil
+	}
+	// the data source secret is in the controller's namespace
+	dataSource, err := shareddb.GetDataSource(ctx, r.KubernetesInterface.CoreV1().Secrets(os.Getenv(dfv1.EnvNamespace)), *x)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive data source: %w", err)
+	}
+	a, err := archive.NewSQL(ctx, x.Driver, dataSource)
+	if err != nil {
+		return nil, err
+	}
+	r.archive, r.archiveHash = a, hash
+	return a, nil
+}
+
+// archivePipeline archives the pipeline and its steps, if archiving is enabled
+func (r *PipelineReconciler) archivePipeline(ctx context.Context, x *dfv1.Database, pipeline *dfv1.Pipeline) error {
+	a, err := r.getArchive(ctx, x)
+	if err != nil || a == nil {
+		return err
+	}
+	steps := &dfv1.StepList{}
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipeline.Name)
+	if err := r.List(ctx, steps, &client.ListOptions{Namespace: pipeline.Namespace, LabelSelector: selector}); err != nil {
+		return fmt.Errorf("failed to list steps: %w", err)
+	}
+	return a.Archive(ctx, *pipeline, steps.Items)
+}
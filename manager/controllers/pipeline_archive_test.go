@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+type testArchive struct {
+	closed   bool
+	pipeline dfv1.Pipeline
+	steps    []dfv1.Step
+}
+
+func (a *testArchive) Archive(_ context.Context, pipeline dfv1.Pipeline, steps []dfv1.Step) error {
+	a.pipeline, a.steps = pipeline, steps
+	return nil
+}
+
+func (a *testArchive) Close() error {
+	a.closed = true
+	return nil
+}
+
+func TestPipelineReconciler_getArchive(t *testing.T) {
+	ctx := context.Background()
+	x := &dfv1.Database{Driver: "mysql", DataSource: &dfv1.DBDataSource{Value: "my-data-source"}}
+	t.Run("Disabled", func(t *testing.T) {
+		r := newTestPipelineReconciler()
+		a, err := r.getArchive(ctx, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, a)
+	})
+	t.Run("Unchanged", func(t *testing.T) {
+		r := newTestPipelineReconciler()
+		existing := &testArchive{}
+		r.archive, r.archiveHash = existing, util.MustHash(x)
+		a, err := r.getArchive(ctx, x)
+		assert.NoError(t, err)
+		assert.Equal(t, existing, a)
+		assert.False(t, existing.closed)
+	})
+	t.Run("BecameDisabled", func(t *testing.T) {
+		r := newTestPipelineReconciler()
+		existing := &testArchive{}
+		r.archive, r.archiveHash = existing, util.MustHash(x)
+		a, err := r.getArchive(ctx, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, a)
+		assert.True(t, existing.closed)
+	})
+	t.Run("Changed", func(t *testing.T) {
+		r := newTestPipelineReconciler()
+		r.KubernetesInterface = fake.NewSimpleClientset()
+		existing := &testArchive{}
+		r.archive, r.archiveHash = existing, util.MustHash(x)
+		_, err := r.getArchive(ctx, &dfv1.Database{Driver: "unknown", DataSource: &dfv1.DBDataSource{Value: "my-data-source"}})
+		assert.Error(t, err, "the driver is not registered")
+		assert.True(t, existing.closed)
+		assert.Nil(t, r.archive)
+		assert.Empty(t, r.archiveHash)
+	})
+	t.Run("NoDataSource", func(t *testing.T) {
+		r := newTestPipelineReconciler()
+		r.KubernetesInterface = fake.NewSimpleClientset()
+		_, err := r.getArchive(ctx, &dfv1.Database{Driver: "mysql"})
+		assert.EqualError(t, err, "failed to get archive data source: data source not specified")
+	})
+}
+
+func TestPipelineReconciler_archivePipeline(t *testing.T) {
+	ctx := context.Background()
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}}
+	step := func(namespace, pipelineName string) *dfv1.Step {
+		return &dfv1.Step{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      pipelineName + "-main",
+			Labels:    map[string]string{dfv1.KeyPipelineName: pipelineName},
+		}}
+	}
+	r := newTestPipelineReconciler(pipeline, step("my-ns", "my-pl"), step("my-ns", "other-pl"), step("other-ns", "my-pl"))
+	t.Run("Disabled", func(t *testing.T) {
+		assert.NoError(t, r.archivePipeline(ctx, nil, pipeline))
+	})
+	t.Run("Enabled", func(t *testing.T) {
+		x := &dfv1.Database{Driver: "mysql", DataSource: &dfv1.DBDataSource{Value: "my-data-source"}}
+		a := &testArchive{}
+		r.archive, r.archiveHash = a, util.MustHash(x)
+		assert.NoError(t, r.archivePipeline(ctx, x, pipeline))
+		assert.Equal(t, "my-pl", a.pipeline.Name)
+		if assert.Len(t, a.steps, 1, "only the pipeline's steps") {
+			assert.Equal(t, "my-ns", a.steps[0].Namespace)
+			assert.Equal(t, "my-pl-main", a.steps[0].Name)
+		}
+	})
+}
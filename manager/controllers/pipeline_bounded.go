@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// boundStepSources makes sources from bounded steps that have succeeded bounded too, so those steps complete in turn
+func (r *PipelineReconciler) boundStepSources(ctx context.Context, pipeline *dfv1.Pipeline, spec *dfv1.PipelineSpec) error {
+	steps := &dfv1.StepList{}
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipeline.Name)
+	if err := r.Client.List(ctx, steps, &client.ListOptions{Namespace: pipeline.Namespace, LabelSelector: selector}); err != nil {
+		return fmt.Errorf("failed to list steps: %w", err)
+	}
+	succeeded := map[string]bool{}
+	for _, step := range steps.Items {
+		succeeded[step.Spec.Name] = step.Status.Phase == dfv1.StepSucceeded
+	}
+	spec.BoundStepSources(succeeded)
+	return nil
+}
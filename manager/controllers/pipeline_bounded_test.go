@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestPipelineReconciler_boundStepSources(t *testing.T) {
+	ctx := context.Background()
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}}
+	newStep := func(name string, phase dfv1.StepPhase) *dfv1.Step {
+		return &dfv1.Step{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-" + name, Labels: map[string]string{dfv1.KeyPipelineName: "my-pl"}},
+			Spec:       dfv1.StepSpec{Name: name},
+			Status:     dfv1.StepStatus{Phase: phase},
+		}
+	}
+	spec := func() *dfv1.PipelineSpec {
+		return &dfv1.PipelineSpec{Steps: []dfv1.StepSpec{
+			{Name: "a", Sources: []dfv1.Source{{Name: "default", Kafka: &dfv1.KafkaSource{}, Bounded: true}}},
+			{Name: "b", Sources: []dfv1.Source{{Name: "default", Step: "a"}}},
+		}}
+	}
+	t.Run("Running", func(t *testing.T) {
+		r := newTestPipelineReconciler(newStep("a", dfv1.StepRunning))
+		s := spec()
+		assert.NoError(t, r.boundStepSources(ctx, pipeline, s))
+		assert.False(t, s.Steps[1].Sources[0].Bounded)
+	})
+	t.Run("Succeeded", func(t *testing.T) {
+		r := newTestPipelineReconciler(newStep("a", dfv1.StepSucceeded))
+		s := spec()
+		assert.NoError(t, r.boundStepSources(ctx, pipeline, s))
+		assert.True(t, s.Steps[1].Sources[0].Bounded)
+	})
+}
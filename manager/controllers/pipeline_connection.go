@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// getConnection returns the spec of the connection in the namespace with the name, or of the cluster connection, or
+// nil if neither exists, in which case the sidecar falls back to the `dataflow-*-${name}` secret
+func (r *PipelineReconciler) getConnection(ctx context.Context, namespace, name string) (*dfv1.ConnectionSpec, error) {
+	conn := &dfv1.Connection{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, conn); err == nil {
+		if conn.Status.IsInvalid(conn.Generation) {
+			return nil, errInvalidSpec{fmt.Errorf("connection %q is invalid", name)}
+		}
+		return &conn.Spec, nil
+	} else if !apierr.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get connection %q: %w", name, err)
+	}
+	cconn := &dfv1.ClusterConnection{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, cconn); err == nil {
+		if cconn.Status.IsInvalid(cconn.Generation) {
+			return nil, errInvalidSpec{fmt.Errorf("cluster connection %q is invalid", name)}
+		}
+		return &cconn.Spec, nil
+	} else if !apierr.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get cluster connection %q: %w", name, err)
+	}
+	return nil, nil
+}
+
+type connectionUser interface {
+	ApplyConnection(c dfv1.ConnectionSpec) error
+}
+
+// resolveConnections fills in the sources, sinks and notifications of the spec from the connections they reference
+func (r *PipelineReconciler) resolveConnections(ctx context.Context, namespace string, spec *dfv1.PipelineSpec) error {
+	apply := func(name string, x connectionUser) error {
+		if name == "" {
+			return nil
+		}
+		c, err := r.getConnection(ctx, namespace, name)
+		if err != nil || c == nil {
+			return err
+		}
+		if err := x.ApplyConnection(*c); err != nil {
+			return errInvalidSpec{err}
+		}
+		return nil
+	}
+	for _, step := range spec.Steps {
+		for _, s := range step.Sources {
+			var err error
+			if x := s.Kafka; x != nil {
+				err = apply(x.Name, &x.Kafka)
+			} else if x := s.STAN; x != nil {
+				err = apply(x.Name, x)
+			} else if x := s.S3; x != nil {
+				err = apply(x.Name, &x.S3)
+			}
+			if err != nil {
+				return fmt.Errorf("source %q of step %q: %w", s.Name, step.Name, err)
+			}
+		}
+		for _, s := range step.Sinks {
+			var err error
+			if x := s.Kafka; x != nil {
+				err = apply(x.Name, x)
+			} else if x := s.STAN; x != nil {
+				err = apply(x.Name, x)
+			} else if x := s.S3; x != nil {
+				err = apply(x.Name, &x.S3)
+			} else if x := s.DB; x != nil {
+				err = apply(x.Name, x)
+			}
+			if err != nil {
+				return fmt.Errorf("sink %q of step %q: %w", s.Name, step.Name, err)
+			}
+		}
+	}
+	for _, n := range spec.Notifications {
+		var err error
+		if x := n.Kafka; x != nil {
+			err = apply(x.Name, x)
+		} else if x := n.STAN; x != nil {
+			err = apply(x.Name, x)
+		}
+		if err != nil {
+			return fmt.Errorf("notification %q: %w", n.Name, err)
+		}
+	}
+	return nil
+}
+
+// pipelinesForConnection returns requests for every pipeline that might use the connection, so changes roll out to them
+func (r *PipelineReconciler) pipelinesForConnection(obj client.Object) []reconcile.Request {
+	pipelines := &dfv1.PipelineList{}
+	if err := r.List(context.Background(), pipelines, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list pipelines", "connection", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, pl := range pipelines.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pl)})
+	}
+	return requests
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestPipelineReconciler_resolveConnections(t *testing.T) {
+	ctx := context.Background()
+	r := newTestPipelineReconciler(
+		&dfv1.Connection{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-kafka"},
+			Spec:       dfv1.ConnectionSpec{Kafka: &dfv1.KafkaConfig{Brokers: []string{"my-broker"}}},
+		},
+		&dfv1.ClusterConnection{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-kafka"},
+			Spec:       dfv1.ConnectionSpec{Kafka: &dfv1.KafkaConfig{Brokers: []string{"cluster-broker"}}},
+		},
+		&dfv1.ClusterConnection{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-db"},
+			Spec:       dfv1.ConnectionSpec{DB: &dfv1.Database{Driver: "mysql", DataSource: &dfv1.DBDataSource{Value: "my-ds"}}},
+		},
+		&dfv1.Connection{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "invalid", Generation: 1},
+			Status: dfv1.ConnectionStatus{
+				ObservedGeneration: 1,
+				Conditions:         []metav1.Condition{{Type: dfv1.ConditionValid, Status: metav1.ConditionFalse}},
+			},
+		},
+	)
+	t.Run("Resolved", func(t *testing.T) {
+		spec := &dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{
+			Name:    "main",
+			Sources: []dfv1.Source{{Kafka: &dfv1.KafkaSource{Kafka: dfv1.Kafka{Name: "my-kafka"}}}},
+			Sinks:   []dfv1.Sink{{Kafka: &dfv1.Kafka{Name: "default"}}, {DB: &dfv1.DBSink{Name: "my-db"}}},
+		}}}
+		assert.NoError(t, r.resolveConnections(ctx, "my-ns", spec))
+		assert.Equal(t, []string{"my-broker"}, spec.Steps[0].Sources[0].Kafka.Brokers, "the namespaced connection takes precedence")
+		assert.Empty(t, spec.Steps[0].Sinks[0].Kafka.Brokers, "left for the secret")
+		assert.Equal(t, "my-ds", spec.Steps[0].Sinks[1].DB.DataSource.Value)
+	})
+	t.Run("WrongType", func(t *testing.T) {
+		spec := &dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Sinks: []dfv1.Sink{{STAN: &dfv1.STAN{Name: "my-kafka"}}}}}}
+		err := r.resolveConnections(ctx, "my-ns", spec)
+		assert.True(t, errors.As(err, &errInvalidSpec{}))
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		spec := &dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Sinks: []dfv1.Sink{{S3: &dfv1.S3Sink{S3: dfv1.S3{Name: "invalid"}}}}}}}
+		err := r.resolveConnections(ctx, "my-ns", spec)
+		assert.True(t, errors.As(err, &errInvalidSpec{}))
+	})
+	t.Run("Requests", func(t *testing.T) {
+		r := newTestPipelineReconciler(&dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}})
+		assert.Len(t, r.pipelinesForConnection(&dfv1.Connection{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns"}}), 1)
+		assert.Len(t, r.pipelinesForConnection(&dfv1.ClusterConnection{}), 1)
+	})
+}
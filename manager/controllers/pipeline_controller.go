@@ -18,21 +18,30 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/manager/archive"
 	"github.com/argoproj-labs/argo-dataflow/shared/containerkiller"
 	"github.com/argoproj-labs/argo-dataflow/shared/util"
 )
@@ -40,23 +49,41 @@ import (
 // PipelineReconciler reconciles a Pipeline object
 type PipelineReconciler struct {
 	client.Client
-	Log             logr.Logger
-	Scheme          *runtime.Scheme
-	ContainerKiller containerkiller.Interface
+	Log                 logr.Logger
+	Scheme              *runtime.Scheme
+	Recorder            record.EventRecorder
+	ContainerKiller     containerkiller.Interface
+	KubernetesInterface kubernetes.Interface
+	Namespaces          *Namespaces
+	archiveMu           sync.Mutex
+	archiveHash         string
+	archive             archive.Interface
+	notifier            notifier
 }
 
 // +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=pipelines,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=pipelines/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=steps,verbs=get;watch;list;create
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=pipelinetemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=connections,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=clusterconnections,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=dataflowpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=steps,verbs=get;watch;list;create;update;delete
+// +kubebuilder:rbac:groups=,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=,resources=configmaps,verbs=create;get;delete
 // +kubebuilder:rbac:groups=,resources=services,verbs=create;get;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=create;get;delete
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=,resources=secrets,verbs=create;get;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=,resources=endpoints,resourceNames=kubernetes,verbs=get
 func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("pipeline", req.NamespacedName)
 
 	pipeline := &dfv1.Pipeline{}
 	if err := r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		if apierr.IsNotFound(err) {
+			r.notifier.forget(req.NamespacedName)
+		}
 		// we'll ignore not-found errors, since they can't be fixed by an immediate
 		// requeue (we'll need to wait for a new notification), and we can get them
 		// on deleted requests.
@@ -64,27 +91,97 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	}
 
 	if !pipeline.GetDeletionTimestamp().IsZero() {
+		return r.finalize(ctx, log, pipeline)
+	}
+
+	if updated, err := r.ensureFinalizer(ctx, pipeline); err != nil {
+		return ctrl.Result{}, util.IgnoreConflict(err) // conflict is ok, we will reconcile again soon
+	} else if updated {
+		return ctrl.Result{}, nil // we'll be notified of the update
+	}
+
+	if updated, err := r.rollback(ctx, log, pipeline); err != nil {
+		return ctrl.Result{}, util.IgnoreConflict(err)
+	} else if updated {
 		return ctrl.Result{}, nil
 	}
 
+	revision, err := r.recordRevision(ctx, log, pipeline)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	revisionText := strconv.FormatInt(revision.Revision, 10)
+
+	requeueAfter := time.Duration(0)
 	if pipeline.Status.Phase.Completed() {
-		deleteAt := pipeline.Status.LastUpdated.Time.Add(deletionDelay)
+		cfg := getConfig()
+		settings, err := r.Namespaces.getSettings(ctx, pipeline.Namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		deletionDelay := cfg.DeletionDelay
+		if settings.DeletionDelay != nil {
+			deletionDelay = *settings.DeletionDelay
+		}
+		deleteAt := pipeline.Status.LastUpdated.Time.Add(pipeline.GetResolvedSpec().TTLStrategy.GetDeletionDelay(pipeline.Status.Phase, deletionDelay))
 		if time.Now().After(deleteAt) {
+			if err := r.archivePipeline(ctx, cfg.Archive, pipeline); err != nil {
+				r.Recorder.Eventf(pipeline, "Warning", "ArchiveFailed", "failed to archive pipeline: %v", err)
+				return ctrl.Result{RequeueAfter: time.Minute}, nil
+			}
 			log.Info("deleting pipeline", "lastUpdated", pipeline.Status.LastUpdated)
 			return ctrl.Result{}, r.Delete(ctx, pipeline)
 		}
+		requeueAfter = time.Until(deleteAt) // the pipeline may not change again, so we must requeue to delete it
 	}
 
 	log.Info("reconciling")
 
-	for _, step := range pipeline.Spec.Steps {
+	trigger, waitingFor, err := r.checkDependencies(ctx, pipeline, metav1.Now())
+	if err == nil && len(waitingFor) > 0 {
+		newStatus := *pipeline.Status.DeepCopy()
+		newStatus.Phase, newStatus.Message = dfv1.PipelinePending, "waiting for "+strings.Join(waitingFor, ", ")
+		newStatus.Revision, newStatus.ChangeCause = revision.Revision, revision.Annotations[dfv1.KeyChangeCause]
+		return r.updateStatus(ctx, log, pipeline, pipeline.GetResolvedSpec().Notifications, newStatus, requeueAfter)
+	}
+	var spec dfv1.PipelineSpec
+	var templateStatus *dfv1.TemplateStatus
+	if err == nil {
+		spec, templateStatus, err = r.resolveSpec(ctx, pipeline, trigger)
+	}
+	if err == nil {
+		spec = *spec.DeepCopy() // so we do not change the pipeline, or the template status
+		err = r.resolveSteps(ctx, pipeline, &spec)
+	}
+	if err == nil {
+		err = r.checkPolicies(ctx, pipeline.Namespace, spec)
+	}
+	if err == nil {
+		err = validateNotifications(spec.Notifications)
+	}
+	if err == nil {
+		err = r.boundStepSources(ctx, pipeline, &spec)
+	}
+	if err != nil {
+		if !errors.As(err, &errInvalidSpec{}) {
+			return ctrl.Result{}, err
+		}
+		newStatus := *pipeline.Status.DeepCopy()
+		newStatus.Phase, newStatus.Message = dfv1.PipelineFailed, err.Error()
+		newStatus.Revision, newStatus.ChangeCause = revision.Revision, revision.Annotations[dfv1.KeyChangeCause]
+		newStatus.Trigger = trigger
+		return r.updateStatus(ctx, log, pipeline, pipeline.GetResolvedSpec().Notifications, newStatus, requeueAfter)
+	}
+
+	for _, step := range spec.Steps {
 		stepFullName := pipeline.Name + "-" + step.Name
 		matchLabels := map[string]string{dfv1.KeyPipelineName: pipeline.Name, dfv1.KeyStepName: step.Name}
 		obj := &dfv1.Step{
 			ObjectMeta: metav1.ObjectMeta{
-				Namespace: pipeline.Namespace,
-				Name:      stepFullName,
-				Labels:    matchLabels,
+				Namespace:   pipeline.Namespace,
+				Name:        stepFullName,
+				Labels:      matchLabels,
+				Annotations: map[string]string{dfv1.KeyRevision: revisionText},
 				OwnerReferences: []metav1.OwnerReference{
 					*metav1.NewControllerRef(pipeline.GetObjectMeta(), dfv1.PipelineGroupVersionKind),
 				},
@@ -98,9 +195,10 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 					return ctrl.Result{}, err
 				}
 				step.Replicas = old.Spec.Replicas // copy this field as it should only be modified by `kubectl scale`, edited by the user
-				if notEqual, patch := util.NotEqual(step, old.Spec); notEqual {
-					log.Info("updating step due to changed spec", "patch", patch)
+				if notEqual, patch := util.NotEqual(step, old.Spec); notEqual || old.Annotations[dfv1.KeyRevision] != revisionText {
+					log.Info("updating step due to changed spec", "patch", patch, "revision", revisionText)
 					old.Spec = step
+					metav1.SetMetaDataAnnotation(&old.ObjectMeta, dfv1.KeyRevision, revisionText)
 					if err := r.Client.Update(ctx, old); util.IgnoreConflict(err) != nil { // ignore conflicts, we will be reconciling again shortly if this happens
 						return ctrl.Result{}, err
 					}
@@ -111,6 +209,10 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 	}
 
+	if err := r.reconcileNetworkPolicies(ctx, pipeline, spec); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	steps := &dfv1.StepList{}
 	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipeline.Name)
 	if err := r.Client.List(ctx, steps, &client.ListOptions{Namespace: pipeline.Namespace, LabelSelector: selector}); err != nil {
@@ -120,10 +222,13 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	pending, running, succeeded, failed := 0, 0, 0, 0
 	newStatus := *pipeline.Status.DeepCopy()
 	newStatus.Phase = dfv1.PipelineUnknown
+	newStatus.Template = templateStatus
+	newStatus.Revision, newStatus.ChangeCause = revision.Revision, revision.Annotations[dfv1.KeyChangeCause]
+	newStatus.Trigger = trigger
 	terminate, sunkMessages := false, false
 	for _, step := range steps.Items {
 		stepName := step.Spec.Name
-		if !pipeline.Spec.HasStep(stepName) { // this happens when a pipeline changes and a step is removed
+		if !spec.HasStep(stepName) { // this happens when a pipeline changes and a step is removed
 			log.Info("deleting excess step", "stepName", stepName)
 			if err := r.Client.Delete(ctx, &step); err != nil {
 				return ctrl.Result{}, fmt.Errorf("failed to delete excess step %s: %w", step.GetName(), err)
@@ -154,6 +259,8 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		terminate = false
 	}
 
+	newStatus.Edges = getEdges(spec, pipeline, steps.Items)
+
 	var ss []string
 	for s, n := range map[string]int{
 		"pending":   pending,
@@ -184,6 +291,12 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 	}
 
+	if degraded := getDegradedMessage(steps.Items, getConfig().DegradedErrorRatio); degraded != "" {
+		meta.SetStatusCondition(&newStatus.Conditions, metav1.Condition{Type: dfv1.ConditionDegraded, Status: metav1.ConditionTrue, Reason: dfv1.ConditionDegraded, Message: degraded})
+	} else if len(newStatus.Conditions) > 0 {
+		meta.RemoveStatusCondition(&newStatus.Conditions, dfv1.ConditionDegraded)
+	}
+
 	if terminate {
 		pods := &corev1.PodList{}
 		selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipeline.Name)
@@ -193,31 +306,76 @@ func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		for _, pod := range pods.Items {
 			for _, s := range pod.Status.ContainerStatuses {
 				if s.Name == dfv1.CtrMain {
-					if err := r.ContainerKiller.KillContainer(pod, s.Name); err != nil {
-						log.Error(err, "failed to kill container", "pod", pod.Name, "container", s.Name)
-					}
+					killContainer(log, r.ContainerKiller, pod, s.Name)
 				}
 			}
 		}
 	}
 
+	return r.updateStatus(ctx, log, pipeline, spec.Notifications, newStatus, requeueAfter)
+}
+
+// updateStatus updates the pipeline's status, if it has changed, and then sends any notifications that the change
+// triggered, so that each transition is only notified once
+func (r *PipelineReconciler) updateStatus(ctx context.Context, log logr.Logger, pipeline *dfv1.Pipeline, notifications []dfv1.Notification, newStatus dfv1.PipelineStatus, requeueAfter time.Duration) (ctrl.Result, error) {
+	triggerNotifications(notifications, pipeline.Status, &newStatus, metav1.Now())
+
 	if notEqual, patch := util.NotEqual(pipeline.Status, newStatus); notEqual {
 		log.Info("updating pipeline status", "phase", newStatus.Phase, "message", newStatus.Message, "patch", patch)
 
 		newStatus.LastUpdated = metav1.Now()
 		pipeline.Status = newStatus
 
-		if err := r.Status().Update(ctx, pipeline); util.IgnoreConflict(err) != nil { // conflict is ok, we will reconcile again soon
+		if err := r.Status().Update(ctx, pipeline); apierr.IsConflict(err) {
+			return ctrl.Result{}, nil // conflict is ok, we will reconcile again soon
+		} else if err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
 		}
 	}
 
-	return ctrl.Result{}, nil
+	if x, err := r.sendNotifications(ctx, log, pipeline); err != nil {
+		return ctrl.Result{}, err
+	} else if x > 0 && (requeueAfter == 0 || x < requeueAfter) {
+		requeueAfter = x
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// getEdges returns the edges between the steps, with the metrics of the sources they flow into
+func getEdges(spec dfv1.PipelineSpec, pipeline *dfv1.Pipeline, steps []dfv1.Step) []dfv1.Edge {
+	statuses := map[string]dfv1.StepStatus{}
+	for _, step := range steps {
+		statuses[step.Spec.Name] = step.Status
+	}
+	edges := spec.GetEdges(pipeline.Namespace, pipeline.Name)
+	for i, e := range edges {
+		s := statuses[e.To].SourceStatuses.Get(e.Source)
+		edges[i].Rate, edges[i].Pending = s.GetRate(), s.Pending
+	}
+	return edges
+}
+
+// resolveSteps wires the steps together, and then fills in their sources and sinks from their connections
+func (r *PipelineReconciler) resolveSteps(ctx context.Context, pipeline *dfv1.Pipeline, spec *dfv1.PipelineSpec) error {
+	if err := spec.WireSteps(pipeline.Namespace, pipeline.Name); err != nil {
+		return errInvalidSpec{err}
+	}
+	return r.resolveConnections(ctx, pipeline.Namespace, spec)
 }
 
 func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&dfv1.Pipeline{}).
 		Owns(&dfv1.Step{}).
-		Complete(r)
+		Owns(&networkingv1.NetworkPolicy{}).
+		Watches(&source.Kind{Type: &dfv1.PipelineTemplate{}}, handler.EnqueueRequestsFromMapFunc(r.pipelinesForTemplate)).
+		Watches(&source.Kind{Type: &dfv1.Pipeline{}}, handler.EnqueueRequestsFromMapFunc(r.pipelinesForDependency)).
+		Watches(&source.Kind{Type: &dfv1.Connection{}}, handler.EnqueueRequestsFromMapFunc(r.pipelinesForConnection)).
+		Watches(&source.Kind{Type: &dfv1.ClusterConnection{}}, handler.EnqueueRequestsFromMapFunc(r.pipelinesForConnection)).
+		Watches(&source.Kind{Type: &dfv1.DataflowPolicy{}}, handler.EnqueueRequestsFromMapFunc(r.pipelinesForPolicy))
+	return r.Namespaces.watchNamespaces(b, r.Client, func() client.ObjectList { return &dfv1.PipelineList{} }).
+		WithEventFilter(r.Namespaces.Predicate()).
+		WithOptions(r.Namespaces.controllerOptions()).
+		Complete(withErrorMetrics("pipeline", r.Namespaces.withRateLimit(r)))
 }
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// checkDependencies returns the trigger status once all of the pipeline's dependencies are met, or the dependencies it
+// is still waiting for. Once triggered, the pipeline does not wait again, even if the dependencies change.
+func (r *PipelineReconciler) checkDependencies(ctx context.Context, pipeline *dfv1.Pipeline, now metav1.Time) (*dfv1.TriggerStatus, []string, error) {
+	if x := pipeline.Status.Trigger; x != nil || len(pipeline.Spec.DependsOn) == 0 {
+		return x, nil, nil
+	}
+	if err := pipeline.Spec.ValidateDependencies(); err != nil {
+		return nil, nil, errInvalidSpec{err}
+	}
+	var waitingFor []string
+	dependencies := map[string]*dfv1.Pipeline{}
+	for _, d := range pipeline.Spec.DependsOn {
+		x := &dfv1.Pipeline{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: pipeline.Namespace, Name: d.Pipeline}, x); apierr.IsNotFound(err) {
+			waitingFor = append(waitingFor, d.String())
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to get pipeline %q: %w", d.Pipeline, err)
+		}
+		dependencies[d.Pipeline] = x
+		if d.IsMet(*x) {
+			continue
+		}
+		if x.Status.Phase.Completed() {
+			return nil, nil, errInvalidSpec{fmt.Errorf("dependency %s cannot be met, it completed with phase %s", d, x.Status.Phase)}
+		}
+		waitingFor = append(waitingFor, d.String())
+	}
+	if len(waitingFor) > 0 {
+		return nil, waitingFor, nil
+	}
+	trigger := &dfv1.TriggerStatus{TriggeredAt: now}
+	if x := pipeline.Spec.Trigger; x != nil {
+		for _, p := range x.Parameters {
+			value, err := getJSONPath(dependencies[p.Pipeline], p.JSONPath)
+			if err != nil {
+				return nil, nil, errInvalidSpec{fmt.Errorf("failed to get trigger parameter %q: %w", p.Name, err)}
+			}
+			if trigger.Arguments == nil {
+				trigger.Arguments = map[string]string{}
+			}
+			trigger.Arguments[p.Name] = value
+		}
+	}
+	return trigger, nil, nil
+}
+
+func getJSONPath(pipeline *dfv1.Pipeline, path string) (string, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pipeline)
+	if err != nil {
+		return "", err
+	}
+	j := jsonpath.New("")
+	if err := j.Parse(path); err != nil {
+		return "", fmt.Errorf("failed to parse JSON path %q: %w", path, err)
+	}
+	buf := &bytes.Buffer{}
+	if err := j.Execute(buf, obj); err != nil {
+		return "", fmt.Errorf("failed to get JSON path %q: %w", path, err)
+	}
+	return buf.String(), nil
+}
+
+// pipelinesForDependency returns requests for the pipelines waiting for the pipeline, so they start once it is met
+func (r *PipelineReconciler) pipelinesForDependency(obj client.Object) []reconcile.Request {
+	pipelines := &dfv1.PipelineList{}
+	if err := r.List(context.Background(), pipelines, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list pipelines", "dependency", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, pl := range pipelines.Items {
+		if pl.Status.Trigger != nil {
+			continue
+		}
+		for _, d := range pl.Spec.DependsOn {
+			if d.Pipeline == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pl)})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestPipelineReconciler_checkDependencies(t *testing.T) {
+	ctx := context.Background()
+	now := metav1.Now()
+	newPipeline := func(name string, phase dfv1.PipelinePhase) *dfv1.Pipeline {
+		return &dfv1.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: name, Annotations: map[string]string{"output-prefix": "2021/06/01/"}},
+			Status:     dfv1.PipelineStatus{Phase: phase},
+		}
+	}
+	downstream := &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "b"},
+		Spec: dfv1.PipelineSpec{
+			DependsOn:   []dfv1.Dependency{{Pipeline: "a"}},
+			TemplateRef: &dfv1.TemplateRef{Name: "my-plt"},
+			Trigger:     &dfv1.Trigger{Parameters: []dfv1.TriggerParameter{{Name: "prefix", Pipeline: "a", JSONPath: "{.metadata.annotations.output-prefix}"}}},
+		},
+	}
+	t.Run("NoDependencies", func(t *testing.T) {
+		trigger, waitingFor, err := newTestPipelineReconciler().checkDependencies(ctx, &dfv1.Pipeline{}, now)
+		assert.NoError(t, err)
+		assert.Nil(t, trigger)
+		assert.Empty(t, waitingFor)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		trigger, waitingFor, err := newTestPipelineReconciler().checkDependencies(ctx, downstream, now)
+		assert.NoError(t, err)
+		assert.Nil(t, trigger)
+		assert.Equal(t, []string{"a (Succeeded)"}, waitingFor)
+	})
+	t.Run("Running", func(t *testing.T) {
+		_, waitingFor, err := newTestPipelineReconciler(newPipeline("a", dfv1.PipelineRunning)).checkDependencies(ctx, downstream, now)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a (Succeeded)"}, waitingFor)
+	})
+	t.Run("Failed", func(t *testing.T) {
+		_, _, err := newTestPipelineReconciler(newPipeline("a", dfv1.PipelineFailed)).checkDependencies(ctx, downstream, now)
+		assert.IsType(t, errInvalidSpec{}, err)
+		assert.EqualError(t, err, "dependency a (Succeeded) cannot be met, it completed with phase Failed")
+	})
+	t.Run("Succeeded", func(t *testing.T) {
+		trigger, waitingFor, err := newTestPipelineReconciler(newPipeline("a", dfv1.PipelineSucceeded)).checkDependencies(ctx, downstream, now)
+		assert.NoError(t, err)
+		assert.Empty(t, waitingFor)
+		if assert.NotNil(t, trigger) {
+			assert.Equal(t, now, trigger.TriggeredAt)
+			assert.Equal(t, map[string]string{"prefix": "2021/06/01/"}, trigger.Arguments)
+		}
+	})
+	t.Run("AlreadyTriggered", func(t *testing.T) {
+		x := downstream.DeepCopy()
+		x.Status.Trigger = &dfv1.TriggerStatus{}
+		trigger, waitingFor, err := newTestPipelineReconciler().checkDependencies(ctx, x, now)
+		assert.NoError(t, err)
+		assert.Empty(t, waitingFor)
+		assert.Equal(t, x.Status.Trigger, trigger)
+	})
+	t.Run("Requests", func(t *testing.T) {
+		a := newPipeline("a", dfv1.PipelineSucceeded)
+		requests := newTestPipelineReconciler(a, downstream).pipelinesForDependency(a)
+		if assert.Len(t, requests, 1) {
+			assert.Equal(t, "b", requests[0].Name)
+		}
+	})
+}
+
+func Test_getJSONPath(t *testing.T) {
+	pipeline := &dfv1.Pipeline{Status: dfv1.PipelineStatus{Message: "1 succeeded"}}
+	x, err := getJSONPath(pipeline, "{.status.message}")
+	assert.NoError(t, err)
+	assert.Equal(t, "1 succeeded", x)
+	_, err = getJSONPath(pipeline, "{.status.missing}")
+	assert.Error(t, err)
+}
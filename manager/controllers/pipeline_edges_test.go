@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_getEdges(t *testing.T) {
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}}
+	spec := dfv1.PipelineSpec{Steps: []dfv1.StepSpec{
+		{Name: "a", Sinks: []dfv1.Sink{{Name: "b", STAN: &dfv1.STAN{Subject: "a-b"}}}},
+		{Name: "b", Sources: []dfv1.Source{{Name: "a", STAN: &dfv1.STAN{Subject: "a-b"}}}},
+	}}
+	pending := uint64(3)
+	steps := []dfv1.Step{{
+		Spec: dfv1.StepSpec{Name: "b"},
+		Status: dfv1.StepStatus{SourceStatuses: dfv1.SourceStatuses{"a": {
+			Pending: &pending,
+			Metrics: map[string]dfv1.Metrics{"0": {Rate: resource.MustParse("2")}, "1": {Rate: resource.MustParse("3")}},
+		}}},
+	}}
+	edges := getEdges(spec, pipeline, steps)
+	if assert.Len(t, edges, 1) {
+		assert.Equal(t, "a", edges[0].From)
+		assert.Equal(t, "b", edges[0].To)
+		assert.Equal(t, "5", edges[0].Rate.String())
+		assert.Equal(t, &pending, edges[0].Pending)
+	}
+}
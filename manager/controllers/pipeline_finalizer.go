@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	sharedkafka "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/kafka"
+	sharedstan "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/stan"
+	"github.com/argoproj-labs/argo-dataflow/runner/sidecar/source"
+)
+
+func skipCleanUp(pipeline *dfv1.Pipeline) bool {
+	return pipeline.GetAnnotations()[dfv1.KeySkipCleanUp] == "true"
+}
+
+// ensureFinalizer adds the finalizer, or removes it if the user has opted out of clean-up, returning true if the
+// pipeline was updated
+func (r *PipelineReconciler) ensureFinalizer(ctx context.Context, pipeline *dfv1.Pipeline) (bool, error) {
+	want := !skipCleanUp(pipeline)
+	if want == controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer) {
+		return false, nil
+	}
+	if want {
+		controllerutil.AddFinalizer(pipeline, dfv1.KeyFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(pipeline, dfv1.KeyFinalizer)
+	}
+	if err := r.Update(ctx, pipeline); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// finalize deletes the pipeline's Kafka consumer groups and STAN durables, and then removes the finalizer.
+// S3 sources keep their scratch files in the pod's `emptyDir`, so they are deleted with the pods.
+// If the clean-up keeps failing, it gives up once the clean-up timeout passes, or when the namespace is being deleted, so
+// that neither the pipeline nor its namespace are left terminating forever.
+func (r *PipelineReconciler) finalize(ctx context.Context, log logr.Logger, pipeline *dfv1.Pipeline) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	if !skipCleanUp(pipeline) {
+		// the consumer groups and durables cannot be deleted while the pods are still members of them
+		if done, err := r.deleteSteps(ctx, log, pipeline); err != nil {
+			return ctrl.Result{}, err
+		} else if !done {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		if errs := r.cleanUp(ctx, log, pipeline); len(errs) > 0 {
+			for _, err := range errs {
+				r.Recorder.Event(pipeline, "Warning", "CleanUpFailed", err.Error())
+			}
+			if reason, err := r.giveUpCleanUp(ctx, pipeline); err != nil {
+				return ctrl.Result{}, err
+			} else if reason == "" {
+				return ctrl.Result{RequeueAfter: time.Minute}, nil
+			} else {
+				log.Info("giving up clean-up", "reason", reason)
+				r.Recorder.Event(pipeline, "Warning", "CleanUpAbandoned", "Gave up cleaning up consumer groups and durables because "+reason)
+			}
+		} else {
+			r.Recorder.Event(pipeline, "Normal", "CleanedUp", "Deleted consumer groups and durables")
+		}
+	}
+	log.Info("removing finalizer")
+	controllerutil.RemoveFinalizer(pipeline, dfv1.KeyFinalizer)
+	return ctrl.Result{}, r.Update(ctx, pipeline)
+}
+
+// giveUpCleanUp returns why the failing clean-up should be given up, or "" if it should be retried
+func (r *PipelineReconciler) giveUpCleanUp(ctx context.Context, pipeline *dfv1.Pipeline) (string, error) {
+	if terminating, err := r.Namespaces.isTerminating(ctx, pipeline.Namespace); err != nil {
+		return "", err
+	} else if terminating {
+		return "the namespace is being deleted", nil
+	}
+	timeout := getConfig().CleanUpTimeout
+	if time.Since(pipeline.GetDeletionTimestamp().Time) > timeout {
+		return fmt.Sprintf("it did not succeed within %v", timeout), nil
+	}
+	return "", nil
+}
+
+// deleteSteps deletes the pipeline's steps, returning true once all of the pods are gone
+func (r *PipelineReconciler) deleteSteps(ctx context.Context, log logr.Logger, pipeline *dfv1.Pipeline) (bool, error) {
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipeline.Name)
+	steps := &dfv1.StepList{}
+	if err := r.List(ctx, steps, &client.ListOptions{Namespace: pipeline.Namespace, LabelSelector: selector}); err != nil {
+		return false, fmt.Errorf("failed to list steps: %w", err)
+	}
+	for _, step := range steps.Items {
+		if step.GetDeletionTimestamp().IsZero() {
+			log.Info("deleting step", "step", step.Name)
+			if err := r.Delete(ctx, &step); client.IgnoreNotFound(err) != nil {
+				return false, fmt.Errorf("failed to delete step %s: %w", step.Name, err)
+			}
+		}
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, &client.ListOptions{Namespace: pipeline.Namespace, LabelSelector: selector}); err != nil {
+		return false, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return len(pods.Items) == 0, nil
+}
+
+// cleanUp deletes the broker-side resources of every source, returning any errors
+func (r *PipelineReconciler) cleanUp(ctx context.Context, log logr.Logger, pipeline *dfv1.Pipeline) []error {
+	spec := pipeline.GetResolvedSpec()
+	spec = *spec.DeepCopy()
+	if err := r.resolveSteps(ctx, pipeline, &spec); err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, step := range spec.Steps {
+		for _, s := range step.Sources {
+			groupID := source.GroupID(clusterName, pipeline.Namespace, pipeline.Name, step.Name, s.Name)
+			if err := r.cleanUpSource(ctx, pipeline, step.Name, s, groupID); apierr.IsNotFound(err) {
+				log.Info("nothing to clean up, a secret was not found", "step", step.Name, "source", s.Name, "err", err.Error())
+			} else if err != nil {
+				errs = append(errs, fmt.Errorf("failed to clean up source %q of step %q: %w", s.Name, step.Name, err))
+			} else {
+				log.Info("cleaned up source", "step", step.Name, "source", s.Name, "groupID", groupID)
+			}
+		}
+	}
+	return errs
+}
+
+// cleanUpSource deletes the source's consumer group or durable. There is nothing to clean up if the broker is not known,
+// e.g. because the connection or `dataflow-*` secret has already been deleted.
+func (r *PipelineReconciler) cleanUpSource(ctx context.Context, pipeline *dfv1.Pipeline, stepName string, s dfv1.Source, groupID string) error {
+	if x := s.Kafka; x != nil {
+		secretInterface := r.KubernetesInterface.CoreV1().Secrets(pipeline.Namespace)
+		k := *x.Kafka.DeepCopy()
+		if err := sharedkafka.Enrich(ctx, secretInterface, &k); err != nil {
+			return err
+		}
+		if len(k.Brokers) == 0 {
+			return nil
+		}
+		return sharedkafka.DeleteConsumerGroup(ctx, secretInterface, k, groupID)
+	} else if x := s.STAN; x != nil {
+		secretInterface := r.KubernetesInterface.CoreV1().Secrets(pipeline.Namespace)
+		y := x.DeepCopy()
+		if err := sharedstan.Enrich(ctx, secretInterface, y, pipeline.Namespace, pipeline.Name); err != nil {
+			return err
+		}
+		if y.NATSURL == "" {
+			return nil
+		}
+		clientID := fmt.Sprintf("%s-%s-%s-clean-up-%s", pipeline.Namespace, pipeline.Name, stepName, s.Name)
+		return sharedstan.DeleteDurable(ctx, secretInterface, *y, clientID, groupID)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func newTestPipelineReconciler(objs ...client.Object) *PipelineReconciler {
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	return &PipelineReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Log:      ctrl.Log,
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func TestPipelineReconciler_ensureFinalizer(t *testing.T) {
+	ctx := context.Background()
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}}
+	r := newTestPipelineReconciler(pipeline)
+	updated, err := r.ensureFinalizer(ctx, pipeline)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.True(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+	updated, err = r.ensureFinalizer(ctx, pipeline)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+	t.Run("OptOut", func(t *testing.T) {
+		pipeline.Annotations = map[string]string{dfv1.KeySkipCleanUp: "true"}
+		updated, err := r.ensureFinalizer(ctx, pipeline)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.False(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+	})
+}
+
+func TestPipelineReconciler_finalize(t *testing.T) {
+	ctx := context.Background()
+	labels := map[string]string{dfv1.KeyPipelineName: "my-pl"}
+	newPipeline := func() *dfv1.Pipeline {
+		return &dfv1.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl", Finalizers: []string{dfv1.KeyFinalizer}},
+			Spec:       dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Name: "main", Sources: dfv1.Sources{{Name: "default", HTTP: &dfv1.HTTPSource{}}}}}},
+		}
+	}
+	t.Run("WaitForPods", func(t *testing.T) {
+		pipeline := newPipeline()
+		r := newTestPipelineReconciler(
+			pipeline,
+			&dfv1.Step{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main", Labels: labels}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main-0", Labels: labels}},
+		)
+		result, err := r.finalize(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.NotZero(t, result.RequeueAfter)
+		steps := &dfv1.StepList{}
+		assert.NoError(t, r.List(ctx, steps))
+		assert.Empty(t, steps.Items)
+		assert.True(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+	})
+	t.Run("CleanedUp", func(t *testing.T) {
+		pipeline := newPipeline()
+		r := newTestPipelineReconciler(pipeline)
+		result, err := r.finalize(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.False(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+		assert.Equal(t, "Normal CleanedUp Deleted consumer groups and durables", <-r.Recorder.(*record.FakeRecorder).Events)
+	})
+	t.Run("NothingToCleanUp", func(t *testing.T) {
+		pipeline := newPipeline()
+		pipeline.Spec.Steps[0].Sources = dfv1.Sources{
+			{Name: "kafka", Kafka: &dfv1.KafkaSource{Kafka: dfv1.Kafka{Topic: "my-topic"}}},
+			{Name: "stan", STAN: &dfv1.STAN{Subject: "my-subject"}},
+		}
+		r := newTestPipelineReconciler(pipeline)
+		r.KubernetesInterface = kubefake.NewSimpleClientset() // the dataflow-kafka-default and dataflow-stan-default secrets are gone
+		result, err := r.finalize(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.False(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+		assert.Equal(t, "Normal CleanedUp Deleted consumer groups and durables", <-r.Recorder.(*record.FakeRecorder).Events)
+	})
+	newFailingPipeline := func(deletedAt time.Time) *dfv1.Pipeline {
+		pipeline := newPipeline()
+		pipeline.DeletionTimestamp = &metav1.Time{Time: deletedAt}
+		pipeline.Spec.Steps[0].Sources = dfv1.Sources{{Step: "missing"}}
+		return pipeline
+	}
+	t.Run("Retry", func(t *testing.T) {
+		pipeline := newFailingPipeline(time.Now())
+		r := newTestPipelineReconciler(pipeline)
+		result, err := r.finalize(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, result.RequeueAfter)
+		assert.True(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+		assert.Contains(t, <-r.Recorder.(*record.FakeRecorder).Events, "Warning CleanUpFailed")
+	})
+	t.Run("TimedOut", func(t *testing.T) {
+		pipeline := newFailingPipeline(time.Now().Add(-time.Hour))
+		r := newTestPipelineReconciler(pipeline)
+		result, err := r.finalize(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.False(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+		events := r.Recorder.(*record.FakeRecorder).Events
+		assert.Contains(t, <-events, "Warning CleanUpFailed")
+		assert.Equal(t, "Warning CleanUpAbandoned Gave up cleaning up consumer groups and durables because it did not succeed within 10m0s", <-events)
+	})
+	t.Run("NamespaceTerminating", func(t *testing.T) {
+		pipeline := newFailingPipeline(time.Now())
+		r := newTestPipelineReconciler(pipeline)
+		scheme := runtime.NewScheme()
+		_ = corev1.AddToScheme(scheme)
+		r.Namespaces = &Namespaces{Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-ns"}, Status: corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating}},
+		).Build()}
+		result, err := r.finalize(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		assert.False(t, controllerutil.ContainsFinalizer(pipeline, dfv1.KeyFinalizer))
+		events := r.Recorder.(*record.FakeRecorder).Events
+		assert.Contains(t, <-events, "Warning CleanUpFailed")
+		assert.Equal(t, "Warning CleanUpAbandoned Gave up cleaning up consumer groups and durables because the namespace is being deleted", <-events)
+	})
+}
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	sharedkafka "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/kafka"
+	sharedstan "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/stan"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+// the label every namespace has, since Kubernetes v1.21
+const keyNamespaceName = "kubernetes.io/metadata.name"
+
+// endpoint is a host and port a step connects to, or that connects to a step
+type endpoint struct {
+	host string
+	port int32
+}
+
+// parseEndpoint parses "host:port", or a URL, using the default port if none is specified
+func parseEndpoint(s string, defaultPort int32) (endpoint, bool) {
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil || u.Hostname() == "" {
+			return endpoint{}, false
+		}
+		switch u.Scheme {
+		case "http":
+			defaultPort = 80
+		case "https":
+			defaultPort = 443
+		}
+		s = u.Host
+	} else if strings.HasPrefix(s, "git@") { // e.g. git@github.com:argoproj-labs/argo-dataflow.git
+		return endpoint{host: strings.SplitN(strings.TrimPrefix(s, "git@"), ":", 2)[0], port: 22}, true
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return endpoint{host: s, port: defaultPort}, s != ""
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return endpoint{}, false
+	}
+	return endpoint{host: host, port: int32(p)}, true
+}
+
+// getEgressEndpoints returns the endpoints the step connects to, that are configured in the spec
+func getEgressEndpoints(step dfv1.StepSpec) []endpoint {
+	var endpoints []endpoint
+	seen := map[endpoint]bool{}
+	add := func(s string, defaultPort int32) {
+		if e, ok := parseEndpoint(s, defaultPort); ok && !seen[e] {
+			seen[e] = true
+			endpoints = append(endpoints, e)
+		}
+	}
+	addKafka := func(x dfv1.Kafka) {
+		for _, b := range x.Brokers {
+			add(b, 9092)
+		}
+	}
+	addSTAN := func(x dfv1.STAN) {
+		add(x.NATSURL, 4222)
+		add(x.NATSMonitoringURL, 8222)
+	}
+	addS3 := func(x dfv1.S3) {
+		if x.Endpoint != nil {
+			add(x.Endpoint.URL, 443)
+		} else {
+			add("https://s3.amazonaws.com", 443) // outside the cluster, so must be allowed using `egress`
+		}
+	}
+	for _, s := range step.Sources {
+		if x := s.Kafka; x != nil {
+			addKafka(x.Kafka)
+		} else if x := s.STAN; x != nil {
+			addSTAN(*x)
+		} else if x := s.S3; x != nil {
+			addS3(x.S3)
+		}
+	}
+	for _, s := range step.Sinks {
+		if x := s.Kafka; x != nil {
+			addKafka(*x)
+		} else if x := s.STAN; x != nil {
+			addSTAN(*x)
+		} else if x := s.S3; x != nil {
+			addS3(x.S3)
+		} else if x := s.HTTP; x != nil {
+			add(x.URL, 80)
+		}
+	}
+	if x := step.Git; x != nil {
+		add(x.URL, 443)
+	}
+	return endpoints
+}
+
+// getNamespace returns the namespace of a service's host name (e.g. "my-svc.my-ns.svc"), or empty if the host is not
+// a service's. Hosts such as "my-svc.my-ns" cannot be told apart from external hosts, so are not considered services.
+func getNamespace(host, namespace string) string {
+	if host == "" || net.ParseIP(host) != nil {
+		return ""
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) == 1 {
+		return namespace
+	}
+	if len(parts) >= 3 && parts[2] == "svc" {
+		return parts[1]
+	}
+	return ""
+}
+
+// getAPIServerEgress returns rules allowing the addresses and ports of the `kubernetes` service's endpoints, i.e. the
+// Kubernetes API servers. Network policies apply after the service's IP is translated, so allowing it would not work.
+func getAPIServerEgress(endpoints *corev1.Endpoints) []networkingv1.NetworkPolicyEgressRule {
+	var rules []networkingv1.NetworkPolicyEgressRule
+	for _, subset := range endpoints.Subsets {
+		rule := networkingv1.NetworkPolicyEgressRule{}
+		for _, p := range subset.Ports {
+			protocol, port := p.Protocol, intstr.FromInt(int(p.Port))
+			rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+		}
+		for _, a := range subset.Addresses {
+			rule.To = append(rule.To, getPeers(endpoint{host: a.IP}, "")...)
+		}
+		if len(rule.Ports) > 0 && len(rule.To) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// getPeers returns the peers for the endpoint, or nil if it could be any host
+func getPeers(e endpoint, namespace string) []networkingv1.NetworkPolicyPeer {
+	if ip := net.ParseIP(e.host); ip != nil {
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		return []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: fmt.Sprintf("%s/%d", e.host, bits)}}}
+	}
+	if ns := getNamespace(e.host, namespace); ns != "" {
+		return []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{keyNamespaceName: ns}}}}
+	}
+	return nil
+}
+
+func tcpPorts(ports ...int32) []networkingv1.NetworkPolicyPort {
+	var x []networkingv1.NetworkPolicyPort
+	for _, p := range ports {
+		protocol, port := corev1.ProtocolTCP, intstr.FromInt(int(p))
+		x = append(x, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+	}
+	return x
+}
+
+// newNetworkPolicy returns the network policy for the step, apiServer are the rules allowing the Kubernetes API
+func newNetworkPolicy(pipeline *dfv1.Pipeline, spec dfv1.PipelineSpec, step dfv1.StepSpec, apiServer []networkingv1.NetworkPolicyEgressRule) *networkingv1.NetworkPolicy {
+	x := spec.NetworkPolicy
+	matchLabels := map[string]string{dfv1.KeyPipelineName: pipeline.Name, dfv1.KeyStepName: step.Name}
+
+	// the controller gets the status of each replica, and the activator (in the controller's pod) sends it messages
+	controller := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: activatorSelector}}
+	if ns := os.Getenv(dfv1.EnvNamespace); ns != "" {
+		controller.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{keyNamespaceName: ns}}
+	}
+	sourcePeers := []networkingv1.NetworkPolicyPeer{controller}
+	hasSources := false
+	for _, s := range step.Sources {
+		if s.HTTP != nil {
+			hasSources = true
+		} else if y := s.S3; y != nil {
+			hasSources = true // the S3 server sends bucket notifications
+			if y.Endpoint != nil {
+				if e, ok := parseEndpoint(y.Endpoint.URL, 443); ok {
+					sourcePeers = append(sourcePeers, getPeers(e, pipeline.Namespace)...)
+				}
+			}
+		}
+	}
+	if hasSources {
+		for _, e := range spec.GetEdges(pipeline.Namespace, pipeline.Name) {
+			if e.To == step.Name {
+				sourcePeers = append(sourcePeers, networkingv1.NetworkPolicyPeer{
+					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{dfv1.KeyPipelineName: pipeline.Name, dfv1.KeyStepName: e.From}},
+				})
+			}
+		}
+		sourcePeers = append(sourcePeers, x.Ingress...)
+	}
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{Ports: tcpPorts(3570), From: sourcePeers},
+		{Ports: tcpPorts(3571), From: []networkingv1.NetworkPolicyPeer{controller}}, // only the controller gets the status
+	}
+	if len(x.Prometheus) > 0 {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{Ports: tcpPorts(3570, 8080), From: x.Prometheus})
+	}
+
+	udp := corev1.ProtocolUDP
+	dns := intstr.FromInt(53)
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{Ports: append(tcpPorts(53), networkingv1.NetworkPolicyPort{Protocol: &udp, Port: &dns})},
+	}
+	egress = append(egress, apiServer...) // the sidecar reads secrets and updates the step's status
+	for _, e := range getEgressEndpoints(step) {
+		// a rule without peers would allow any host, so hosts outside the cluster must be allowed using `egress`
+		if peers := getPeers(e, pipeline.Namespace); len(peers) > 0 {
+			egress = append(egress, networkingv1.NetworkPolicyEgressRule{Ports: tcpPorts(e.port), To: peers})
+		}
+	}
+	egress = append(egress, x.Egress...)
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       pipeline.Namespace,
+			Name:            pipeline.Name + "-" + step.Name,
+			Labels:          matchLabels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(pipeline.GetObjectMeta(), dfv1.PipelineGroupVersionKind)},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: matchLabels},
+			Ingress:     ingress,
+			Egress:      egress,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+}
+
+// reconcileNetworkPolicies creates or updates a network policy for each step, if the pipeline has network policies,
+// and deletes the others
+func (r *PipelineReconciler) reconcileNetworkPolicies(ctx context.Context, pipeline *dfv1.Pipeline, spec dfv1.PipelineSpec) error {
+	wanted := map[string]bool{}
+	if spec.NetworkPolicy != nil {
+		// not cached, as we only need this one of the cluster's endpoints
+		endpoints, err := r.KubernetesInterface.CoreV1().Endpoints(metav1.NamespaceDefault).Get(ctx, "kubernetes", metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get the Kubernetes API's endpoints: %w", err)
+		}
+		apiServer := getAPIServerEgress(endpoints)
+		for _, step := range spec.Steps {
+			step, err := r.enrichStep(ctx, pipeline, step)
+			if err != nil {
+				return fmt.Errorf("failed to get the brokers of step %q: %w", step.Name, err)
+			}
+			obj := newNetworkPolicy(pipeline, spec, step, apiServer)
+			wanted[obj.Name] = true
+			if err := r.createOrUpdateNetworkPolicy(ctx, obj); err != nil {
+				return fmt.Errorf("failed to create network policy %s: %w", obj.Name, err)
+			}
+		}
+	}
+	policies := &networkingv1.NetworkPolicyList{}
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipeline.Name)
+	if err := r.Client.List(ctx, policies, &client.ListOptions{Namespace: pipeline.Namespace, LabelSelector: selector}); err != nil {
+		return fmt.Errorf("failed to list network policies: %w", err)
+	}
+	for _, obj := range policies.Items {
+		if wanted[obj.Name] {
+			continue
+		}
+		r.Log.Info("deleting excess network policy", "name", obj.Name)
+		if err := r.Client.Delete(ctx, &obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete network policy %s: %w", obj.Name, err)
+		}
+	}
+	return nil
+}
+
+// enrichStep returns a copy of the step, with the brokers of its Kafka and STAN sources and sinks filled in from the
+// `dataflow-kafka-*` and `dataflow-stan-*` secrets, as the sidecar does, so that the step is allowed to connect to them
+func (r *PipelineReconciler) enrichStep(ctx context.Context, pipeline *dfv1.Pipeline, step dfv1.StepSpec) (dfv1.StepSpec, error) {
+	step = *step.DeepCopy()
+	secretInterface := r.KubernetesInterface.CoreV1().Secrets(pipeline.Namespace)
+	for _, s := range step.Sources {
+		if x := s.Kafka; x != nil {
+			if err := sharedkafka.Enrich(ctx, secretInterface, &x.Kafka); err != nil {
+				return step, err
+			}
+		} else if x := s.STAN; x != nil {
+			if err := sharedstan.Enrich(ctx, secretInterface, x, pipeline.Namespace, pipeline.Name); err != nil {
+				return step, err
+			}
+		}
+	}
+	for _, s := range step.Sinks {
+		if x := s.Kafka; x != nil {
+			if err := sharedkafka.Enrich(ctx, secretInterface, x); err != nil {
+				return step, err
+			}
+		} else if x := s.STAN; x != nil {
+			if err := sharedstan.Enrich(ctx, secretInterface, x, pipeline.Namespace, pipeline.Name); err != nil {
+				return step, err
+			}
+		}
+	}
+	return step, nil
+}
+
+// createOrUpdateNetworkPolicy creates the network policy, or updates the existing policy's spec if it has changed
+func (r *PipelineReconciler) createOrUpdateNetworkPolicy(ctx context.Context, obj *networkingv1.NetworkPolicy) error {
+	if err := r.Client.Create(ctx, obj); !apierr.IsAlreadyExists(err) {
+		return err
+	}
+	old := &networkingv1.NetworkPolicy{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), old); err != nil {
+		return err
+	}
+	if notEqual, patch := util.NotEqual(old.Spec, obj.Spec); notEqual {
+		r.Log.Info("updating network policy", "name", obj.Name, "patch", patch)
+		old.Spec = obj.Spec
+		return util.IgnoreConflict(r.Client.Update(ctx, old)) // ignore conflicts, we will be reconciling again shortly if this happens
+	}
+	return nil
+}
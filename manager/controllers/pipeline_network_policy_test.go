@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_parseEndpoint(t *testing.T) {
+	for s, want := range map[string]endpoint{
+		"kafka-broker:9092":                     {"kafka-broker", 9092},
+		"kafka-broker":                          {"kafka-broker", 1},
+		"nats://nats.dataflow.svc:4222":         {"nats.dataflow.svc", 4222},
+		"http://testapi/count/incr":             {"testapi", 80},
+		"https://example.com/foo":               {"example.com", 443},
+		"git@github.com:argoproj-labs/dataflow": {"github.com", 22},
+		"https://github.com/argoproj-labs/repo": {"github.com", 443},
+		"http://10.0.0.1:8080/sources/default":  {"10.0.0.1", 8080},
+	} {
+		t.Run(s, func(t *testing.T) {
+			e, ok := parseEndpoint(s, 1)
+			assert.True(t, ok)
+			assert.Equal(t, want, e)
+		})
+	}
+	_, ok := parseEndpoint("", 1)
+	assert.False(t, ok)
+}
+
+func Test_getNamespace(t *testing.T) {
+	assert.Equal(t, "my-ns", getNamespace("kafka-broker", "my-ns"))
+	assert.Equal(t, "dataflow", getNamespace("nats.dataflow.svc", "my-ns"))
+	assert.Equal(t, "dataflow", getNamespace("nats.dataflow.svc.cluster.local", "my-ns"))
+	assert.Empty(t, getNamespace("example.com", "my-ns"))
+	assert.Empty(t, getNamespace("10.0.0.1", "my-ns"))
+	assert.Empty(t, getNamespace("", "my-ns"))
+}
+
+func Test_getEgressEndpoints(t *testing.T) {
+	endpoints := getEgressEndpoints(dfv1.StepSpec{
+		Sources: dfv1.Sources{
+			{Kafka: &dfv1.KafkaSource{Kafka: dfv1.Kafka{KafkaConfig: dfv1.KafkaConfig{Brokers: []string{"kafka-broker:9092"}}}}},
+			{STAN: &dfv1.STAN{NATSURL: "nats://nats", NATSMonitoringURL: "http://nats:8222"}},
+		},
+		Sinks: []dfv1.Sink{
+			{Kafka: &dfv1.Kafka{KafkaConfig: dfv1.KafkaConfig{Brokers: []string{"kafka-broker:9092"}}}},
+			{HTTP: &dfv1.HTTPSink{URL: "https://example.com/in"}},
+			{S3: &dfv1.S3Sink{S3: dfv1.S3{Endpoint: &dfv1.AWSEndpoint{URL: "http://moto:5000"}}}},
+			{Log: &dfv1.Log{}},
+		},
+	})
+	assert.Equal(t, []endpoint{
+		{"kafka-broker", 9092},
+		{"nats", 4222},
+		{"nats", 8222},
+		{"example.com", 443},
+		{"moto", 5000},
+	}, endpoints, "duplicates are removed")
+}
+
+var testAPIServerEndpoints = &corev1.Endpoints{
+	ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kubernetes"},
+	Subsets: []corev1.EndpointSubset{{
+		Addresses: []corev1.EndpointAddress{{IP: "172.18.0.2"}, {IP: "172.18.0.3"}},
+		Ports:     []corev1.EndpointPort{{Name: "https", Port: 6443, Protocol: corev1.ProtocolTCP}},
+	}},
+}
+
+func Test_getAPIServerEgress(t *testing.T) {
+	rules := getAPIServerEgress(testAPIServerEndpoints)
+	if assert.Len(t, rules, 1) {
+		assert.Equal(t, tcpPorts(6443), rules[0].Ports)
+		assert.Equal(t, []networkingv1.NetworkPolicyPeer{
+			{IPBlock: &networkingv1.IPBlock{CIDR: "172.18.0.2/32"}},
+			{IPBlock: &networkingv1.IPBlock{CIDR: "172.18.0.3/32"}},
+		}, rules[0].To)
+	}
+	assert.Empty(t, getAPIServerEgress(&corev1.Endpoints{}), "no rule that allows any host")
+}
+
+func Test_newNetworkPolicy(t *testing.T) {
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}}
+	prometheus := networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{keyNamespaceName: "monitoring"}}}
+	spec := dfv1.PipelineSpec{
+		NetworkPolicy: &dfv1.NetworkPolicy{Prometheus: []networkingv1.NetworkPolicyPeer{prometheus}},
+		Steps: []dfv1.StepSpec{
+			{Name: "a", Sinks: []dfv1.Sink{{HTTP: &dfv1.HTTPSink{URL: "https://my-pl-b/sources/default"}}}},
+			{Name: "b", Sources: dfv1.Sources{{Name: "default", HTTP: &dfv1.HTTPSource{}}}, Sinks: []dfv1.Sink{{Kafka: &dfv1.Kafka{KafkaConfig: dfv1.KafkaConfig{Brokers: []string{"kafka-broker.kafka.svc:9092"}}}}}},
+			{Name: "c", Sinks: []dfv1.Sink{{HTTP: &dfv1.HTTPSink{URL: "https://example.com/in"}}}},
+		},
+	}
+	apiServer := getAPIServerEgress(testAPIServerEndpoints)
+	t.Run("NoSources", func(t *testing.T) {
+		x := newNetworkPolicy(pipeline, spec, spec.Steps[0], apiServer)
+		assert.Equal(t, "my-pl-a", x.Name)
+		assert.Equal(t, map[string]string{dfv1.KeyPipelineName: "my-pl", dfv1.KeyStepName: "a"}, x.Spec.PodSelector.MatchLabels)
+		if assert.Len(t, x.Spec.Ingress, 3) {
+			assert.Len(t, x.Spec.Ingress[0].From, 1, "only the controller")
+			assert.Equal(t, tcpPorts(3571), x.Spec.Ingress[1].Ports)
+			assert.Equal(t, x.Spec.Ingress[0].From, x.Spec.Ingress[1].From, "only the controller gets the status")
+			assert.Equal(t, []networkingv1.NetworkPolicyPeer{prometheus}, x.Spec.Ingress[2].From)
+		}
+		if assert.Len(t, x.Spec.Egress, 3) {
+			assert.Equal(t, apiServer[0], x.Spec.Egress[1], "only the Kubernetes API servers")
+			assert.Equal(t, int32(443), x.Spec.Egress[2].Ports[0].Port.IntVal)
+			assert.Equal(t, "my-ns", x.Spec.Egress[2].To[0].NamespaceSelector.MatchLabels[keyNamespaceName])
+		}
+	})
+	t.Run("HTTPSource", func(t *testing.T) {
+		x := newNetworkPolicy(pipeline, spec, spec.Steps[1], apiServer)
+		if assert.Len(t, x.Spec.Ingress[0].From, 2) {
+			assert.Equal(t, map[string]string{dfv1.KeyPipelineName: "my-pl", dfv1.KeyStepName: "a"}, x.Spec.Ingress[0].From[1].PodSelector.MatchLabels)
+		}
+		if assert.Len(t, x.Spec.Egress, 3) {
+			assert.Equal(t, int32(9092), x.Spec.Egress[2].Ports[0].Port.IntVal)
+			assert.Equal(t, "kafka", x.Spec.Egress[2].To[0].NamespaceSelector.MatchLabels[keyNamespaceName])
+		}
+	})
+	t.Run("ExternalHost", func(t *testing.T) {
+		x := newNetworkPolicy(pipeline, spec, spec.Steps[2], apiServer)
+		assert.Len(t, x.Spec.Egress, 2, "no rule that allows any host, it must be allowed using egress")
+		for _, rule := range x.Spec.Egress[1:] {
+			assert.NotEmpty(t, rule.To)
+		}
+	})
+}
+
+func TestPipelineReconciler_reconcileNetworkPolicies(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = networkingv1.AddToScheme(scheme)
+	r := &PipelineReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Log: ctrl.Log, KubernetesInterface: kubefake.NewSimpleClientset()}
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}}
+	spec := dfv1.PipelineSpec{NetworkPolicy: &dfv1.NetworkPolicy{}, Steps: []dfv1.StepSpec{{Name: "a"}, {Name: "b"}}}
+	assert.Error(t, r.reconcileNetworkPolicies(ctx, pipeline, spec), "the Kubernetes API's endpoints are needed")
+	r.KubernetesInterface = kubefake.NewSimpleClientset(testAPIServerEndpoints)
+	list := func() []networkingv1.NetworkPolicy {
+		policies := &networkingv1.NetworkPolicyList{}
+		assert.NoError(t, r.Client.List(ctx, policies))
+		return policies.Items
+	}
+	assert.NoError(t, r.reconcileNetworkPolicies(ctx, pipeline, spec))
+	assert.Len(t, list(), 2)
+	spec.NetworkPolicy.Egress = []networkingv1.NetworkPolicyEgressRule{{}}
+	spec.Steps = spec.Steps[:1]
+	assert.NoError(t, r.reconcileNetworkPolicies(ctx, pipeline, spec))
+	if policies := list(); assert.Len(t, policies, 1) {
+		assert.Len(t, policies[0].Spec.Egress, 3, "updated")
+	}
+	x := &networkingv1.NetworkPolicy{}
+	assert.NoError(t, r.Client.Get(ctx, client.ObjectKey{Namespace: "my-ns", Name: "my-pl-a"}, x))
+	spec.NetworkPolicy = nil
+	assert.NoError(t, r.reconcileNetworkPolicies(ctx, pipeline, spec))
+	assert.Empty(t, list())
+}
+
+func TestPipelineReconciler_enrichStep(t *testing.T) {
+	ctx := context.Background()
+	r := &PipelineReconciler{KubernetesInterface: kubefake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "dataflow-kafka-default"}, Data: map[string][]byte{"brokers": []byte("kafka-broker.kafka.svc:9092")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "dataflow-stan-default"}, Data: map[string][]byte{"natsUrl": []byte("nats://nats.dataflow.svc")}},
+	)}
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}}
+	step := dfv1.StepSpec{
+		Sources: dfv1.Sources{{Kafka: &dfv1.KafkaSource{Kafka: dfv1.Kafka{Name: "default"}}}},
+		Sinks:   []dfv1.Sink{{STAN: &dfv1.STAN{Name: "default"}}, {Kafka: &dfv1.Kafka{Name: "other"}}},
+	}
+	enriched, err := r.enrichStep(ctx, pipeline, step)
+	assert.NoError(t, err)
+	assert.Empty(t, step.Sources[0].Kafka.Brokers, "copied")
+	assert.ElementsMatch(t, []endpoint{{"kafka-broker.kafka.svc", 9092}, {"nats.dataflow.svc", 4222}}, getEgressEndpoints(enriched))
+}
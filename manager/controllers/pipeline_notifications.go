@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig"
+	"github.com/go-logr/logr"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	sharedkafka "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/kafka"
+	sharedstan "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/stan"
+	"github.com/argoproj-labs/argo-dataflow/runner/sidecar/sink"
+	httpsink "github.com/argoproj-labs/argo-dataflow/runner/sidecar/sink/http"
+	kafkasink "github.com/argoproj-labs/argo-dataflow/runner/sidecar/sink/kafka"
+	stansink "github.com/argoproj-labs/argo-dataflow/runner/sidecar/sink/stan"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+const (
+	notificationMaxAttempts = 5
+	// how often the pipeline is reconciled while notifications are being sent, to record their outcome
+	notificationPollInterval = time.Second
+)
+
+// vars so tests can change them
+var (
+	notificationRetryDelay = 30 * time.Second
+	// notificationTimeout bounds how long sending a notification may take
+	notificationTimeout = 10 * time.Second
+)
+
+// notificationKey is a trigger of one of a pipeline's notifications
+type notificationKey struct {
+	pipeline    types.NamespacedName
+	name        string
+	triggeredAt int64 // in seconds, as stored in the status
+}
+
+// notificationAttempt is the state of an attempt to send a notification
+type notificationAttempt struct {
+	done     bool
+	err      error
+	recorded bool      // the outcome is recorded in the status
+	retryAt  time.Time // when the failed attempt may be retried, once recorded
+}
+
+// notifier sends notifications in the background, so that a slow or unreachable destination cannot block the
+// reconciliation of every pipeline, and holds their outcome until a later reconciliation records it in the status
+type notifier struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	attempts map[notificationKey]notificationAttempt
+}
+
+func (n *notifier) get(k notificationKey) (notificationAttempt, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, ok := n.attempts[k]
+	return a, ok
+}
+
+func (n *notifier) send(k notificationKey, f func() error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.attempts == nil {
+		n.attempts = map[notificationKey]notificationAttempt{}
+	}
+	n.attempts[k] = notificationAttempt{}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		err := f()
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if _, ok := n.attempts[k]; ok { // otherwise forgotten while sending
+			n.attempts[k] = notificationAttempt{done: true, err: err}
+		}
+	}()
+}
+
+// recorded marks the outcomes as recorded, forgetting those that were delivered
+func (n *notifier) recorded(keys []notificationKey, retryAt time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, k := range keys {
+		if a, ok := n.attempts[k]; !ok || a.err == nil {
+			delete(n.attempts, k)
+		} else {
+			a.recorded, a.retryAt = true, retryAt
+			n.attempts[k] = a
+		}
+	}
+}
+
+// prune forgets the pipeline's attempts that are no longer needed, e.g. because the notification was triggered again
+func (n *notifier) prune(pipeline types.NamespacedName, keep map[notificationKey]bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for k := range n.attempts {
+		if k.pipeline == pipeline && !keep[k] {
+			delete(n.attempts, k)
+		}
+	}
+}
+
+func (n *notifier) forget(pipeline types.NamespacedName) {
+	n.prune(pipeline, nil)
+}
+
+func newNotificationTemplate(n dfv1.Notification) (*template.Template, error) {
+	return template.New(n.Name).Funcs(sprig.TxtFuncMap()).Parse(n.Body)
+}
+
+func validateNotifications(notifications []dfv1.Notification) error {
+	names := map[string]bool{}
+	for _, n := range notifications {
+		if err := n.Validate(); err != nil {
+			return errInvalidSpec{err}
+		}
+		if names[n.Name] {
+			return errInvalidSpec{fmt.Errorf("duplicate notification named %q", n.Name)}
+		}
+		names[n.Name] = true
+		if _, err := newNotificationTemplate(n); err != nil {
+			return errInvalidSpec{fmt.Errorf("notification %q has an invalid body: %w", n.Name, err)}
+		}
+	}
+	return nil
+}
+
+// triggerNotifications marks the notifications triggered by the change from the old to the new status to be sent
+func triggerNotifications(notifications []dfv1.Notification, oldStatus dfv1.PipelineStatus, newStatus *dfv1.PipelineStatus, now metav1.Time) {
+	var becameTrue []string // the conditions that are now true, but were not
+	for _, c := range newStatus.Conditions {
+		if c.Status != metav1.ConditionTrue {
+			continue
+		}
+		wasTrue := false
+		for _, x := range oldStatus.Conditions {
+			wasTrue = wasTrue || x.Type == c.Type && x.Status == metav1.ConditionTrue
+		}
+		if !wasTrue {
+			becameTrue = append(becameTrue, c.Type)
+		}
+	}
+	for _, n := range notifications {
+		if trigger := n.GetTrigger(newStatus.Phase != oldStatus.Phase, newStatus.Phase, becameTrue); trigger != "" {
+			newStatus.Notifications.Trigger(n.Name, trigger, now)
+		}
+	}
+}
+
+type notificationData struct {
+	Pipeline *dfv1.Pipeline
+	Trigger  string
+}
+
+func renderNotification(n dfv1.Notification, pipeline *dfv1.Pipeline, trigger string) ([]byte, error) {
+	if n.Body == "" {
+		return []byte(util.MustJSON(map[string]string{
+			"namespace": pipeline.Namespace,
+			"pipeline":  pipeline.Name,
+			"phase":     string(pipeline.Status.Phase),
+			"message":   pipeline.Status.Message,
+			"trigger":   trigger,
+		})), nil
+	}
+	tmpl, err := newNotificationTemplate(n)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, notificationData{pipeline, trigger}); err != nil {
+		return nil, fmt.Errorf("failed to render body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sendNotifications starts sending every notification that has been triggered, but not delivered, in the background,
+// and records the outcome of those that have been attempted in the status, returning how long to wait before
+// reconciling again to record the outcome of those being sent, or to retry any that failed
+func (r *PipelineReconciler) sendNotifications(ctx context.Context, log logr.Logger, pipeline *dfv1.Pipeline) (time.Duration, error) {
+	key := client.ObjectKeyFromObject(pipeline)
+	if !pipeline.Status.Notifications.AnyUndelivered(notificationMaxAttempts) {
+		r.notifier.forget(key)
+		return 0, nil
+	}
+	spec := &dfv1.PipelineSpec{Notifications: pipeline.GetResolvedSpec().Notifications}
+	spec = spec.DeepCopy()
+	if err := r.resolveConnections(ctx, pipeline.Namespace, spec); err != nil {
+		return 0, err
+	}
+	notifications := map[string]dfv1.Notification{}
+	for _, n := range spec.Notifications {
+		notifications[n.Name] = n
+	}
+	newStatus := *pipeline.Status.DeepCopy()
+	var attempted dfv1.NotificationStatuses
+	var recorded []notificationKey
+	undelivered := map[notificationKey]bool{}
+	var requeueAfter time.Duration
+	requeue := func(d time.Duration) {
+		if requeueAfter == 0 || d < requeueAfter {
+			requeueAfter = d
+		}
+	}
+	for _, s := range newStatus.Notifications {
+		n, ok := notifications[s.Name]
+		if !ok || s.Delivered || s.Attempts >= notificationMaxAttempts {
+			continue
+		}
+		k := notificationKey{key, s.Name, s.TriggeredAt.Unix()}
+		undelivered[k] = true
+		a, ok := r.notifier.get(k)
+		switch {
+		case !ok || a.recorded && !time.Now().Before(a.retryAt):
+			log.Info("sending notification", "notification", n.Name, "trigger", s.Trigger)
+			pipeline, trigger := pipeline.DeepCopy(), s.Trigger // the pipeline is changed once this returns
+			r.notifier.send(k, func() error { return r.sendNotification(context.Background(), pipeline, n, trigger) })
+			requeue(notificationPollInterval)
+		case !a.done:
+			requeue(notificationPollInterval)
+		case a.recorded:
+			requeue(time.Until(a.retryAt))
+		default:
+			s.Attempts++
+			if err := a.err; err != nil {
+				log.Error(err, "failed to send notification", "notification", n.Name, "trigger", s.Trigger, "attempts", s.Attempts)
+				r.Recorder.Eventf(pipeline, "Warning", "NotificationFailed", "failed to send notification %q: %v", n.Name, err)
+				s.Message = err.Error()
+				if s.Attempts < notificationMaxAttempts {
+					requeue(notificationRetryDelay)
+				}
+			} else {
+				log.Info("sent notification", "notification", n.Name, "trigger", s.Trigger)
+				r.Recorder.Eventf(pipeline, "Normal", "NotificationSent", "Sent notification %q for %s", n.Name, s.Trigger)
+				s.Delivered, s.Message = true, ""
+			}
+			attempted = append(attempted, s)
+			recorded = append(recorded, k)
+		}
+	}
+	r.notifier.prune(key, undelivered)
+	if len(attempted) > 0 {
+		if err := r.updateNotificationStatuses(ctx, pipeline, attempted); err != nil {
+			return 0, fmt.Errorf("failed to update status: %w", err)
+		}
+		r.notifier.recorded(recorded, time.Now().Add(notificationRetryDelay))
+	}
+	return requeueAfter, nil
+}
+
+// updateNotificationStatuses records the outcome of the attempts in the status, getting the pipeline again on conflict,
+// as a lost delivery would be sent again
+func (r *PipelineReconciler) updateNotificationStatuses(ctx context.Context, pipeline *dfv1.Pipeline, attempted dfv1.NotificationStatuses) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		for _, x := range attempted {
+			for i, s := range pipeline.Status.Notifications {
+				// if it was triggered again since we attempted it, that trigger still needs to be sent
+				if s.Name == x.Name && s.TriggeredAt.Equal(&x.TriggeredAt) {
+					pipeline.Status.Notifications[i] = x
+				}
+			}
+		}
+		err := r.Status().Update(ctx, pipeline)
+		if apierr.IsConflict(err) {
+			key := client.ObjectKeyFromObject(pipeline)
+			*pipeline = dfv1.Pipeline{} // otherwise fields omitted from the latest (e.g. delivered=false) are kept
+			if err := r.Get(ctx, key, pipeline); err != nil {
+				return err
+			}
+		}
+		return err
+	})
+}
+
+// sendNotification sends the notification, giving up after the timeout, the sinks bound their connections and requests
+// by the context's deadline, so the sink is closed before we return
+func (r *PipelineReconciler) sendNotification(ctx context.Context, pipeline *dfv1.Pipeline, n dfv1.Notification, trigger string) error {
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout) // also stops the STAN sink reconnecting
+	defer cancel()
+	msg, err := renderNotification(n, pipeline, trigger)
+	if err != nil {
+		return err
+	}
+	secretInterface := r.KubernetesInterface.CoreV1().Secrets(pipeline.Namespace)
+	var s sink.Interface
+	if x := n.HTTP; x != nil {
+		s, err = httpsink.New(ctx, secretInterface, *x)
+	} else if x := n.Kafka; x != nil {
+		k := x.DeepCopy()
+		if err := sharedkafka.Enrich(ctx, secretInterface, k); err != nil {
+			return err
+		}
+		s, err = kafkasink.New(ctx, secretInterface, *k)
+	} else if x := n.STAN; x != nil {
+		y := x.DeepCopy()
+		if err := sharedstan.Enrich(ctx, secretInterface, y, pipeline.Namespace, pipeline.Name); err != nil {
+			return err
+		}
+		s, err = stansink.New(ctx, secretInterface, pipeline.Namespace, pipeline.Name, "notifications", 0, n.Name, *y)
+	} else {
+		return fmt.Errorf("notification misconfigured")
+	}
+	if err != nil {
+		return err
+	}
+	if x, ok := s.(io.Closer); ok {
+		defer func() { _ = x.Close() }()
+	}
+	return s.Sink(msg)
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_validateNotifications(t *testing.T) {
+	n := dfv1.Notification{Name: "n", Phases: []dfv1.PipelinePhase{dfv1.PipelineFailed}, HTTP: &dfv1.HTTPSink{}}
+	assert.NoError(t, validateNotifications([]dfv1.Notification{n}))
+	err := validateNotifications([]dfv1.Notification{n, n})
+	assert.EqualError(t, err, `duplicate notification named "n"`)
+	assert.True(t, errors.As(err, &errInvalidSpec{}))
+	n.Body = "{{ .Pipeline.Name"
+	assert.Error(t, validateNotifications([]dfv1.Notification{n}))
+}
+
+func Test_triggerNotifications(t *testing.T) {
+	notifications := []dfv1.Notification{
+		{Name: "failed", Phases: []dfv1.PipelinePhase{dfv1.PipelineFailed}},
+		{Name: "degraded", Conditions: []string{dfv1.ConditionDegraded}},
+	}
+	degraded := []metav1.Condition{{Type: dfv1.ConditionDegraded, Status: metav1.ConditionTrue}}
+	t.Run("NoChange", func(t *testing.T) {
+		newStatus := dfv1.PipelineStatus{Phase: dfv1.PipelineFailed, Conditions: degraded}
+		triggerNotifications(notifications, dfv1.PipelineStatus{Phase: dfv1.PipelineFailed, Conditions: degraded}, &newStatus, metav1.Time{})
+		assert.Empty(t, newStatus.Notifications)
+	})
+	t.Run("Changed", func(t *testing.T) {
+		newStatus := dfv1.PipelineStatus{Phase: dfv1.PipelineFailed, Conditions: degraded}
+		triggerNotifications(notifications, dfv1.PipelineStatus{Phase: dfv1.PipelineRunning}, &newStatus, metav1.Time{})
+		assert.Equal(t, dfv1.NotificationStatuses{{Name: "failed", Trigger: "Failed"}, {Name: "degraded", Trigger: "Degraded"}}, newStatus.Notifications)
+	})
+}
+
+func Test_renderNotification(t *testing.T) {
+	pipeline := &dfv1.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"}, Status: dfv1.PipelineStatus{Phase: dfv1.PipelineFailed, Message: "oops"}}
+	msg, err := renderNotification(dfv1.Notification{}, pipeline, "Failed")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"namespace":"my-ns","pipeline":"my-pl","phase":"Failed","message":"oops","trigger":"Failed"}`, string(msg))
+	msg, err = renderNotification(dfv1.Notification{Body: `{"text": {{ printf "%s is %s" .Pipeline.Name .Trigger | quote }}}`}, pipeline, "Failed")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"text": "my-pl is Failed"}`, string(msg))
+}
+
+// sendNotificationsAndWait sends the notifications, waits for them to be attempted, and then records their outcome
+func sendNotificationsAndWait(t *testing.T, r *PipelineReconciler, pipeline *dfv1.Pipeline) time.Duration {
+	ctx := context.Background()
+	_, err := r.sendNotifications(ctx, ctrl.Log, pipeline)
+	assert.NoError(t, err)
+	r.notifier.wg.Wait()
+	requeueAfter, err := r.sendNotifications(ctx, ctrl.Log, pipeline)
+	assert.NoError(t, err)
+	return requeueAfter
+}
+
+func TestPipelineReconciler_sendNotifications(t *testing.T) {
+	defer func(x time.Duration) { notificationRetryDelay = x }(notificationRetryDelay)
+	notificationRetryDelay = 100 * time.Millisecond
+	var bodies []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	pipeline := &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"},
+		Spec: dfv1.PipelineSpec{Notifications: []dfv1.Notification{
+			{Name: "failed", Phases: []dfv1.PipelinePhase{dfv1.PipelineFailed}, Body: "{{ .Trigger }}", HTTP: &dfv1.HTTPSink{URL: server.URL}},
+		}},
+		Status: dfv1.PipelineStatus{Notifications: dfv1.NotificationStatuses{{Name: "failed", Trigger: "Failed"}}},
+	}
+	r := newTestPipelineReconciler(pipeline)
+	r.KubernetesInterface = fake.NewSimpleClientset()
+	t.Run("Failed", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		requeueAfter := sendNotificationsAndWait(t, r, pipeline)
+		assert.Equal(t, notificationRetryDelay, requeueAfter)
+		s := pipeline.Status.Notifications[0]
+		assert.False(t, s.Delivered)
+		assert.Equal(t, uint32(1), s.Attempts)
+		assert.Contains(t, s.Message, "500")
+	})
+	t.Run("RetryDelay", func(t *testing.T) {
+		requeueAfter, err := r.sendNotifications(context.Background(), ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.NotZero(t, requeueAfter)
+		assert.Len(t, bodies, 1, "not retried yet")
+	})
+	t.Run("Delivered", func(t *testing.T) {
+		status = http.StatusOK
+		time.Sleep(notificationRetryDelay)
+		requeueAfter := sendNotificationsAndWait(t, r, pipeline)
+		assert.Zero(t, requeueAfter)
+		s := pipeline.Status.Notifications[0]
+		assert.True(t, s.Delivered)
+		assert.Equal(t, uint32(2), s.Attempts)
+		assert.Empty(t, s.Message)
+		assert.Empty(t, r.notifier.attempts, "forgotten")
+	})
+	t.Run("OnlyOnce", func(t *testing.T) {
+		sendNotificationsAndWait(t, r, pipeline)
+		assert.Equal(t, []string{"Failed", "Failed"}, bodies)
+	})
+}
+
+func TestPipelineReconciler_sendNotifications_Background(t *testing.T) {
+	ctx := context.Background()
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { <-done }))
+	defer server.Close()
+	pipeline := &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"},
+		Spec: dfv1.PipelineSpec{Notifications: []dfv1.Notification{
+			{Name: "failed", Phases: []dfv1.PipelinePhase{dfv1.PipelineFailed}, HTTP: &dfv1.HTTPSink{URL: server.URL}},
+		}},
+		Status: dfv1.PipelineStatus{Notifications: dfv1.NotificationStatuses{{Name: "failed", Trigger: "Failed"}}},
+	}
+	r := newTestPipelineReconciler(pipeline)
+	r.KubernetesInterface = fake.NewSimpleClientset()
+	for i := 0; i < 2; i++ {
+		requeueAfter, err := r.sendNotifications(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err, "does not wait for the destination")
+		assert.Equal(t, notificationPollInterval, requeueAfter)
+		assert.Zero(t, pipeline.Status.Notifications[0].Attempts)
+	}
+	close(done)
+	r.notifier.wg.Wait()
+	_, err := r.sendNotifications(ctx, ctrl.Log, pipeline)
+	assert.NoError(t, err)
+	assert.True(t, pipeline.Status.Notifications[0].Delivered)
+}
+
+func TestPipelineReconciler_sendNotifications_Conflict(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	triggeredAt := metav1.NewTime(time.Now().Truncate(time.Second))
+	pipeline := &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"},
+		Spec: dfv1.PipelineSpec{Notifications: []dfv1.Notification{
+			{Name: "failed", Phases: []dfv1.PipelinePhase{dfv1.PipelineFailed}, HTTP: &dfv1.HTTPSink{URL: server.URL}},
+			{Name: "succeeded", Phases: []dfv1.PipelinePhase{dfv1.PipelineSucceeded}, HTTP: &dfv1.HTTPSink{URL: server.URL}},
+		}},
+		Status: dfv1.PipelineStatus{Notifications: dfv1.NotificationStatuses{
+			{Name: "failed", Trigger: "Failed", TriggeredAt: triggeredAt},
+			{Name: "succeeded", Trigger: "Succeeded", TriggeredAt: triggeredAt},
+		}},
+	}
+	r := newTestPipelineReconciler(pipeline)
+	r.KubernetesInterface = fake.NewSimpleClientset()
+	stale := &dfv1.Pipeline{}
+	assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(pipeline), stale))
+	_, err := r.sendNotifications(ctx, ctrl.Log, stale)
+	assert.NoError(t, err)
+	r.notifier.wg.Wait()
+
+	// changed by another reconciliation after this one read it, which re-triggered one notification
+	latest := stale.DeepCopy()
+	latest.Status.Message = "changed"
+	latest.Status.Notifications[1].TriggeredAt = metav1.NewTime(triggeredAt.Add(time.Second))
+	assert.NoError(t, r.Status().Update(ctx, latest))
+
+	_, err = r.sendNotifications(ctx, ctrl.Log, stale)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(pipeline), latest))
+	assert.Equal(t, "changed", latest.Status.Message)
+	assert.True(t, latest.Status.Notifications[0].Delivered, "the delivery is not lost")
+	assert.False(t, latest.Status.Notifications[1].Delivered, "the new trigger still needs to be sent")
+}
+
+func TestPipelineReconciler_sendNotification_Timeout(t *testing.T) {
+	defer func(x time.Duration) { notificationTimeout = x }(notificationTimeout)
+	notificationTimeout = 10 * time.Millisecond
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body) // the server only notices the connection is closed once the body is read
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer server.Close()
+	r := newTestPipelineReconciler()
+	r.KubernetesInterface = fake.NewSimpleClientset()
+	err := r.sendNotification(context.Background(), &dfv1.Pipeline{}, dfv1.Notification{Name: "failed", HTTP: &dfv1.HTTPSink{URL: server.URL}}, "Failed")
+	assert.Error(t, err)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the request was abandoned, rather than cancelled")
+	}
+}
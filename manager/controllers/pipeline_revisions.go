@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+// listRevisions returns the pipeline's revisions, oldest first
+func (r *PipelineReconciler) listRevisions(ctx context.Context, pipeline *dfv1.Pipeline) ([]appsv1.ControllerRevision, error) {
+	revisions := &appsv1.ControllerRevisionList{}
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipeline.Name)
+	if err := r.Client.List(ctx, revisions, &client.ListOptions{Namespace: pipeline.Namespace, LabelSelector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	items := revisions.Items
+	sort.Slice(items, func(i, j int) bool { return items[i].Revision < items[j].Revision })
+	return items, nil
+}
+
+// recordRevision records the pipeline's spec as its latest revision, re-using the existing revision if it has the same
+// spec (e.g. after a rollback), and deletes the oldest revisions beyond the history limit
+func (r *PipelineReconciler) recordRevision(ctx context.Context, log logr.Logger, pipeline *dfv1.Pipeline) (*appsv1.ControllerRevision, error) {
+	revisions, err := r.listRevisions(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	data := util.MustJSON(pipeline.Spec)
+	name := pipeline.Name + "-" + util.MustHash(data)[0:10]
+	changeCause := pipeline.Annotations[dfv1.KeyChangeCause]
+	latest := int64(0)
+	var current *appsv1.ControllerRevision
+	for i, x := range revisions {
+		latest = x.Revision
+		if x.Name == name {
+			current = &revisions[i]
+		}
+	}
+	if current == nil {
+		current = &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: pipeline.Namespace,
+				Name:      name,
+				Labels:    map[string]string{dfv1.KeyPipelineName: pipeline.Name},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(pipeline.GetObjectMeta(), dfv1.PipelineGroupVersionKind),
+				},
+			},
+			Data:     runtime.RawExtension{Raw: []byte(data)},
+			Revision: latest + 1,
+		}
+		if changeCause != "" {
+			metav1.SetMetaDataAnnotation(&current.ObjectMeta, dfv1.KeyChangeCause, changeCause)
+		}
+		log.Info("creating revision", "revision", current.Revision)
+		if err := r.Client.Create(ctx, current); err != nil {
+			return nil, fmt.Errorf("failed to create revision %s: %w", name, err)
+		}
+		revisions = append(revisions, *current)
+	} else if current.Revision != latest || current.Annotations[dfv1.KeyChangeCause] != changeCause {
+		if current.Revision != latest {
+			current.Revision = latest + 1
+		}
+		metav1.SetMetaDataAnnotation(&current.ObjectMeta, dfv1.KeyChangeCause, changeCause)
+		log.Info("updating revision", "revision", current.Revision)
+		if err := r.Client.Update(ctx, current); err != nil {
+			return nil, fmt.Errorf("failed to update revision %s: %w", name, err)
+		}
+	}
+	current = current.DeepCopy() // sorting moves the revisions
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+	// keep the current revision, and the history limit of previous revisions
+	for i := 0; i < len(revisions)-1-pipeline.Spec.GetRevisionHistoryLimit(); i++ {
+		x := revisions[i]
+		log.Info("deleting old revision", "revision", x.Revision)
+		if err := r.Client.Delete(ctx, &x); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("failed to delete revision %s: %w", x.Name, err)
+		}
+	}
+	return current, nil
+}
+
+// rollback replaces the pipeline's spec with the revision in its rollback annotation, and removes the annotation,
+// returning true if the pipeline was updated
+func (r *PipelineReconciler) rollback(ctx context.Context, log logr.Logger, pipeline *dfv1.Pipeline) (bool, error) {
+	text, ok := pipeline.Annotations[dfv1.KeyRollbackTo]
+	if !ok {
+		return false, nil
+	}
+	revisions, err := r.listRevisions(ctx, pipeline)
+	if err != nil {
+		return false, err
+	}
+	delete(pipeline.Annotations, dfv1.KeyRollbackTo)
+	var revision *appsv1.ControllerRevision
+	for i, x := range revisions {
+		if strconv.FormatInt(x.Revision, 10) == text {
+			revision = &revisions[i]
+		}
+	}
+	spec := dfv1.PipelineSpec{}
+	if revision == nil {
+		r.Recorder.Eventf(pipeline, "Warning", "RollbackFailed", "revision %q not found", text)
+	} else if err := json.Unmarshal(revision.Data.Raw, &spec); err != nil {
+		r.Recorder.Eventf(pipeline, "Warning", "RollbackFailed", "failed to unmarshal revision %q: %v", text, err)
+	} else {
+		log.Info("rolling back", "revision", revision.Revision)
+		pipeline.Spec = spec
+		// like deployments, the change cause is that of the revision
+		if changeCause, ok := revision.Annotations[dfv1.KeyChangeCause]; ok {
+			metav1.SetMetaDataAnnotation(&pipeline.ObjectMeta, dfv1.KeyChangeCause, changeCause)
+		} else {
+			delete(pipeline.Annotations, dfv1.KeyChangeCause)
+		}
+		r.Recorder.Eventf(pipeline, "Normal", "RolledBack", "Rolled back to revision %d", revision.Revision)
+	}
+	if err := r.Client.Update(ctx, pipeline); err != nil {
+		return false, fmt.Errorf("failed to update pipeline: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestPipelineReconciler_recordRevision(t *testing.T) {
+	ctx := context.Background()
+	one := uint32(1)
+	pipeline := &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl", UID: "my-uid"},
+		Spec:       dfv1.PipelineSpec{RevisionHistoryLimit: &one, Steps: []dfv1.StepSpec{{Name: "main"}}},
+	}
+	r := newTestPipelineReconciler(pipeline)
+	record := func(changeCause string, steps ...string) int64 {
+		pipeline.Annotations = map[string]string{dfv1.KeyChangeCause: changeCause}
+		pipeline.Spec.Steps = nil
+		for _, name := range steps {
+			pipeline.Spec.Steps = append(pipeline.Spec.Steps, dfv1.StepSpec{Name: name})
+		}
+		revision, err := r.recordRevision(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.Equal(t, changeCause, revision.Annotations[dfv1.KeyChangeCause])
+		return revision.Revision
+	}
+	assert.Equal(t, int64(1), record("create", "main"))
+	assert.Equal(t, int64(1), record("create", "main"), "unchanged")
+	assert.Equal(t, int64(2), record("add step", "main", "other"))
+	assert.Equal(t, int64(3), record("create", "main"), "re-uses the existing revision")
+	revisions, err := r.listRevisions(ctx, pipeline)
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 2, "deletes revisions over the limit") {
+		assert.Equal(t, int64(2), revisions[0].Revision)
+		assert.Equal(t, int64(3), revisions[1].Revision)
+	}
+}
+
+func TestPipelineReconciler_rollback(t *testing.T) {
+	ctx := context.Background()
+	pipeline := &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl", UID: "my-uid", Annotations: map[string]string{dfv1.KeyChangeCause: "create"}},
+		Spec:       dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Name: "main"}}},
+	}
+	r := newTestPipelineReconciler(pipeline)
+	_, err := r.recordRevision(ctx, ctrl.Log, pipeline)
+	assert.NoError(t, err)
+	t.Run("NoAnnotation", func(t *testing.T) {
+		updated, err := r.rollback(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.False(t, updated)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		pipeline.Annotations[dfv1.KeyRollbackTo] = "9"
+		updated, err := r.rollback(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.NotContains(t, pipeline.Annotations, dfv1.KeyRollbackTo)
+		assert.Equal(t, `Warning RollbackFailed revision "9" not found`, <-r.Recorder.(*record.FakeRecorder).Events)
+	})
+	t.Run("Found", func(t *testing.T) {
+		pipeline.Spec.Steps[0].Name = "broken"
+		pipeline.Annotations[dfv1.KeyChangeCause] = "break it"
+		pipeline.Annotations[dfv1.KeyRollbackTo] = "1"
+		updated, err := r.rollback(ctx, ctrl.Log, pipeline)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, `Normal RolledBack Rolled back to revision 1`, <-r.Recorder.(*record.FakeRecorder).Events)
+		x := &dfv1.Pipeline{}
+		assert.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(pipeline), x))
+		assert.Equal(t, "main", x.Spec.Steps[0].Name)
+		assert.Equal(t, "create", x.Annotations[dfv1.KeyChangeCause])
+		assert.NotContains(t, x.Annotations, dfv1.KeyRollbackTo)
+	})
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// errInvalidSpec is an error that cannot be fixed until the pipeline, or a template or connection it uses, changes
+type errInvalidSpec struct{ error }
+
+// resolveSpec returns the pipeline's spec, rendering it from its template if it has one, with the trigger's arguments
+func (r *PipelineReconciler) resolveSpec(ctx context.Context, pipeline *dfv1.Pipeline, trigger *dfv1.TriggerStatus) (dfv1.PipelineSpec, *dfv1.TemplateStatus, error) {
+	x := pipeline.Spec.TemplateRef
+	if x == nil {
+		return pipeline.Spec, nil, nil
+	}
+	if len(pipeline.Spec.Steps) > 0 {
+		return dfv1.PipelineSpec{}, nil, errInvalidSpec{fmt.Errorf("a pipeline must not have both steps and a templateRef")}
+	}
+	tmpl := &dfv1.PipelineTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: pipeline.Namespace, Name: x.Name}, tmpl); err != nil {
+		err = fmt.Errorf("failed to get template %q: %w", x.Name, err)
+		if apierr.IsNotFound(err) {
+			return dfv1.PipelineSpec{}, nil, errInvalidSpec{err}
+		}
+		return dfv1.PipelineSpec{}, nil, err
+	}
+	arguments := x.Arguments
+	if trigger != nil && len(trigger.Arguments) > 0 {
+		arguments = map[string]string{}
+		for k, v := range x.Arguments {
+			arguments[k] = v
+		}
+		for k, v := range trigger.Arguments { // the trigger's take precedence
+			arguments[k] = v
+		}
+	}
+	spec, err := tmpl.Spec.Render(arguments)
+	if err != nil {
+		return dfv1.PipelineSpec{}, nil, errInvalidSpec{fmt.Errorf("failed to render template %q: %w", x.Name, err)}
+	}
+	// the dependencies are checked before the template is rendered, as the trigger's arguments are needed to render it
+	if len(spec.DependsOn) > 0 || spec.Trigger != nil {
+		return dfv1.PipelineSpec{}, nil, errInvalidSpec{fmt.Errorf("template %q must not have dependsOn or trigger, set them on the pipeline instead", x.Name)}
+	}
+	mergeSpec(&spec, pipeline.Spec)
+	return spec, &dfv1.TemplateStatus{Name: tmpl.Name, Generation: tmpl.Generation, Spec: spec}, nil
+}
+
+// mergeSpec sets the fields of the pipeline's spec, other than its steps and template ref, over the rendered spec, so
+// the pipeline's take precedence over the template's
+func mergeSpec(spec *dfv1.PipelineSpec, x dfv1.PipelineSpec) {
+	if x.TTLStrategy != nil {
+		spec.TTLStrategy = x.TTLStrategy
+	}
+	if x.Transport != nil {
+		spec.Transport = x.Transport
+	}
+	if x.NetworkPolicy != nil {
+		spec.NetworkPolicy = x.NetworkPolicy
+	}
+	if x.RevisionHistoryLimit != nil {
+		spec.RevisionHistoryLimit = x.RevisionHistoryLimit
+	}
+	if len(x.Notifications) > 0 {
+		spec.Notifications = x.Notifications
+	}
+	if len(x.DependsOn) > 0 {
+		spec.DependsOn = x.DependsOn
+	}
+	if x.Trigger != nil {
+		spec.Trigger = x.Trigger
+	}
+}
+
+// pipelinesForTemplate returns requests for the pipelines that reference the template, so changes roll out to them
+func (r *PipelineReconciler) pipelinesForTemplate(obj client.Object) []reconcile.Request {
+	pipelines := &dfv1.PipelineList{}
+	if err := r.List(context.Background(), pipelines, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list pipelines", "template", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, pl := range pipelines.Items {
+		if x := pl.Spec.TemplateRef; x != nil && x.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pl)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestPipelineReconciler_resolveSpec(t *testing.T) {
+	ctx := context.Background()
+	tmpl := &dfv1.PipelineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-plt", Generation: 2},
+		Spec: dfv1.PipelineTemplateSpec{
+			Parameters: []dfv1.Parameter{{Name: "name"}},
+			Template:   runtime.RawExtension{Raw: []byte(`{"steps":[{"name":"{{parameters.name}}","cat":{}}]}`)},
+		},
+	}
+	newPipeline := func(templateName string) *dfv1.Pipeline {
+		return &dfv1.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl"},
+			Spec:       dfv1.PipelineSpec{TemplateRef: &dfv1.TemplateRef{Name: templateName, Arguments: map[string]string{"name": "main"}}},
+		}
+	}
+	dependentTmpl := &dfv1.PipelineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "dependent"},
+		Spec: dfv1.PipelineTemplateSpec{
+			Parameters: []dfv1.Parameter{{Name: "name"}},
+			Template:   runtime.RawExtension{Raw: []byte(`{"dependsOn":[{"pipeline":"other"}],"steps":[{"name":"{{parameters.name}}","cat":{}}]}`)},
+		},
+	}
+	r := newTestPipelineReconciler(tmpl, dependentTmpl, newPipeline("my-plt"))
+	t.Run("NoTemplate", func(t *testing.T) {
+		pipeline := &dfv1.Pipeline{Spec: dfv1.PipelineSpec{Steps: []dfv1.StepSpec{{Name: "main"}}}}
+		spec, status, err := r.resolveSpec(ctx, pipeline, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, pipeline.Spec, spec)
+		assert.Nil(t, status)
+	})
+	t.Run("Template", func(t *testing.T) {
+		spec, status, err := r.resolveSpec(ctx, newPipeline("my-plt"), nil)
+		assert.NoError(t, err)
+		if assert.Len(t, spec.Steps, 1) {
+			assert.Equal(t, "main", spec.Steps[0].Name)
+		}
+		if assert.NotNil(t, status) {
+			assert.Equal(t, "my-plt", status.Name)
+			assert.Equal(t, int64(2), status.Generation)
+			assert.Equal(t, spec, status.Spec)
+		}
+	})
+	t.Run("Merge", func(t *testing.T) {
+		pipeline := newPipeline("my-plt")
+		limit := uint32(3)
+		pipeline.Spec.TTLStrategy = &dfv1.TTLStrategy{}
+		pipeline.Spec.Transport = &dfv1.StepTransport{Type: dfv1.StepTransportKafka}
+		pipeline.Spec.NetworkPolicy = &dfv1.NetworkPolicy{}
+		pipeline.Spec.RevisionHistoryLimit = &limit
+		pipeline.Spec.Notifications = []dfv1.Notification{{Name: "my-notification"}}
+		pipeline.Spec.DependsOn = []dfv1.Dependency{{Pipeline: "other"}}
+		pipeline.Spec.Trigger = &dfv1.Trigger{}
+		spec, _, err := r.resolveSpec(ctx, pipeline, nil)
+		assert.NoError(t, err)
+		assert.Len(t, spec.Steps, 1)
+		assert.Nil(t, spec.TemplateRef)
+		spec.Steps = nil
+		pipeline.Spec.TemplateRef = nil
+		assert.Equal(t, pipeline.Spec, spec)
+	})
+	t.Run("Trigger", func(t *testing.T) {
+		spec, _, err := r.resolveSpec(ctx, newPipeline("my-plt"), &dfv1.TriggerStatus{Arguments: map[string]string{"name": "triggered"}})
+		assert.NoError(t, err)
+		if assert.Len(t, spec.Steps, 1) {
+			assert.Equal(t, "triggered", spec.Steps[0].Name)
+		}
+	})
+	t.Run("TemplateDependsOn", func(t *testing.T) {
+		_, _, err := r.resolveSpec(ctx, newPipeline("dependent"), nil)
+		assert.IsType(t, errInvalidSpec{}, err)
+		assert.EqualError(t, err, `template "dependent" must not have dependsOn or trigger, set them on the pipeline instead`)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		_, _, err := r.resolveSpec(ctx, newPipeline("other"), nil)
+		assert.IsType(t, errInvalidSpec{}, err)
+	})
+	t.Run("Requests", func(t *testing.T) {
+		requests := r.pipelinesForTemplate(tmpl)
+		if assert.Len(t, requests, 1) {
+			assert.Equal(t, "my-pl", requests[0].Name)
+		}
+	})
+}
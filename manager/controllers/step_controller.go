@@ -21,10 +21,13 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 
@@ -32,12 +35,16 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
 	"github.com/argoproj-labs/argo-dataflow/shared/containerkiller"
@@ -47,19 +54,28 @@ import (
 // StepReconciler reconciles a Step object
 type StepReconciler struct {
 	client.Client
-	Log              logr.Logger
-	Scheme           *runtime.Scheme
-	Recorder         record.EventRecorder
-	ContainerKiller  containerkiller.Interface
-	DynamicInterface dynamic.Interface
+	Log                 logr.Logger
+	Scheme              *runtime.Scheme
+	Recorder            record.EventRecorder
+	ContainerKiller     containerkiller.Interface
+	DynamicInterface    dynamic.Interface
+	KubernetesInterface kubernetes.Interface
+	Activator           bool // route the services of auto-scaled steps to the activator while they are scaled to zero
+	Namespaces          *Namespaces
 }
 
 type hash struct {
-	RunnerImage string        `json:"runnerImage"`
-	StepSpec    dfv1.StepSpec `json:"stepSpec"`
+	PodConfig podConfig     `json:"podConfig"`
+	StepSpec  dfv1.StepSpec `json:"stepSpec"`
 }
 
-var clusterName = os.Getenv(dfv1.EnvClusterName)
+var (
+	clusterName = os.Getenv(dfv1.EnvClusterName)
+	// the labels of the manager's pods, which run the activator
+	activatorSelector = map[string]string{"control-plane": "controller-manager"}
+	// the sidecar and the activator only serve HTTPS, this tells gateways (e.g. for HTTP routes) to connect using it
+	httpsAppProtocol = "https"
+)
 
 func init() {
 	logger.Info("config", "clusterName", clusterName)
@@ -67,14 +83,22 @@ func init() {
 
 // +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=steps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=steps/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=,resources=pods,verbs=get;watch;list;create
-// +kubebuilder:rbac:groups=,resources=services,verbs=get;watch;list;create
+// +kubebuilder:rbac:groups=dataflow.argoproj.io,resources=dataflowpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=,resources=pods,verbs=get;watch;list;create;patch;delete
+// +kubebuilder:rbac:groups=,resources=services,verbs=get;watch;list;create;update
+// +kubebuilder:rbac:groups=,resources=persistentvolumeclaims,verbs=get;watch;list;create;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;watch;list;create;update;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;create;update;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=,resources=secrets,verbs=get;create;update
 func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("step", req.NamespacedName)
 
 	step := &dfv1.Step{}
 	if err := r.Get(ctx, req.NamespacedName, step); err != nil {
+		if apierr.IsNotFound(err) {
+			stepErrorEvents.forget(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -87,8 +111,16 @@ func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 	log.Info("reconciling")
 
+	if updated, err := r.recordSourceEnds(ctx, log, step); err != nil {
+		return ctrl.Result{}, util.IgnoreConflict(err) // conflict is ok, we will reconcile again soon
+	} else if updated {
+		return ctrl.Result{}, nil // we'll be notified of the update, and then create the pods
+	}
+
+	cfg := getConfig()
+
 	if step.Spec.Scale != nil {
-		desiredReplicas := step.GetTargetReplicas(scalingDelay, peekDelay)
+		desiredReplicas := step.GetTargetReplicas(cfg.ScalingDelay, cfg.PeekDelay)
 
 		if int(step.Spec.Replicas) != desiredReplicas {
 			log.Info("auto-scaling step", "currentReplicas", step.Spec.Replicas, "desiredReplicas", desiredReplicas)
@@ -114,24 +146,72 @@ func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
+	settings, err := r.Namespaces.getSettings(ctx, step.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	currentReplicas := int(step.Status.Replicas)
-	desiredReplicas := int(step.Spec.Replicas)
+	// the replicas asked for by the spec (or the scaler), before any limits, used to decide which volume claims are excess
+	specReplicas := int(step.Spec.Replicas)
+	desiredReplicas := specReplicas
+	if x := settings.MaxReplicas; x != nil && desiredReplicas > *x {
+		log.Info("limiting replicas", "desiredReplicas", desiredReplicas, "maxReplicas", *x)
+		desiredReplicas = *x
+	}
+
+	policies, err := getPolicies(ctx, r.Client, step.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	policyViolations := policies.getViolations(step.Spec)
+	if len(policyViolations) > 0 {
+		log.Info("step does not comply with policies", "violations", policyViolations)
+		desiredReplicas = 0
+	}
+	desiredReplicas, policyLimits, err := r.limitReplicas(ctx, step, policies, currentReplicas, desiredReplicas)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(policyLimits) > 0 {
+		log.Info("limiting replicas by policy", "desiredReplicas", desiredReplicas, "limits", policyLimits)
+	}
 
 	if currentReplicas != desiredReplicas || step.Status.Selector == "" {
 		log.Info("replicas changed", "currentReplicas", currentReplicas, "desiredReplicas", desiredReplicas)
 		step.Status.Replicas = uint32(desiredReplicas)
 		step.Status.LastScaledAt = metav1.Time{Time: time.Now()}
+		if currentReplicas != desiredReplicas {
+			scaleEventsTotal.WithLabelValues(step.Namespace, pipelineName, stepName, eventReason(currentReplicas, desiredReplicas)).Inc()
+		}
 		r.Recorder.Eventf(step, "Normal", eventReason(currentReplicas, desiredReplicas), "Scaling from %d to %d", currentReplicas, desiredReplicas)
 	}
 
 	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=" + pipelineName + "," + dfv1.KeyStepName + "=" + stepName)
-	hash := util.MustHash(hash{runnerImage, step.Spec})
+	hash := util.MustHash(hash{cfg.podConfig(), step.Spec})
 	oldStatus := step.Status.DeepCopy()
 	step.Status.Phase, step.Status.Reason, step.Status.Message = dfv1.StepUnknown, "", ""
 	step.Status.Selector = selector.String()
 
+	if len(policyViolations) > 0 {
+		step.Status.Phase, step.Status.Reason, step.Status.Message = dfv1.StepFailed, dfv1.ConditionPolicyViolation, strings.Join(policyViolations, "; ")
+	}
+	if messages := append(policyViolations, policyLimits...); len(messages) > 0 {
+		meta.SetStatusCondition(&step.Status.Conditions, metav1.Condition{Type: dfv1.ConditionPolicyViolation, Status: metav1.ConditionTrue, Reason: dfv1.ConditionPolicyViolation, Message: strings.Join(messages, "; ")})
+	} else if len(step.Status.Conditions) > 0 { // guard only needed because RemoveStatusCondition panics on zero length conditions
+		meta.RemoveStatusCondition(&step.Status.Conditions, dfv1.ConditionPolicyViolation)
+	}
+
 	ownerReferences := []metav1.OwnerReference{*metav1.NewControllerRef(step.GetObjectMeta(), dfv1.StepGroupVersionKind)}
 
+	// the sidecars require this to get their status, so it must exist before the pods are created
+	statusAuthorization := ""
+	if cfg.AggregateStepStatus {
+		if statusAuthorization, err = r.ensureStatusAuthorization(ctx, step, ownerReferences); err != nil {
+			return ctrl.Result{}, util.IgnoreConflict(err)
+		}
+	}
+
 	for replica := 0; replica < desiredReplicas; replica++ {
 		podName := fmt.Sprintf("%s-%d", step.Name, replica)
 		_labels := map[string]string{}
@@ -152,6 +232,34 @@ func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		annotations[dfv1.KeyKillCmd(dfv1.CtrMain)] = util.MustJSON([]string{dfv1.PathKill, "1"})
 		annotations[dfv1.KeyKillCmd(dfv1.CtrSidecar)] = util.MustJSON([]string{dfv1.PathKill, "1"})
 
+		podSpec, err := step.GetPodSpec(
+			dfv1.GetPodSpecReq{
+				ClusterName:         clusterName,
+				PipelineName:        pipelineName,
+				Namespace:           step.Namespace,
+				Replica:             int32(replica),
+				ImageFormat:         cfg.ImageFormat,
+				RunnerImage:         cfg.RunnerImage,
+				PullPolicy:          cfg.PullPolicy,
+				UpdateInterval:      cfg.UpdateInterval,
+				StepStatus:          step.Status,
+				SidecarResources:    cfg.SidecarResources,
+				PodSecurityContext:  cfg.PodSecurityContext,
+				NodeSelector:        cfg.NodeSelector,
+				Tolerations:         cfg.Tolerations,
+				AggregateStepStatus: cfg.AggregateStepStatus,
+			},
+		)
+		if err != nil {
+			x := dfv1.MinStepPhaseMessage(dfv1.NewStepPhaseMessage(step.Status.Phase, step.Status.Reason, step.Status.Message), dfv1.NewStepPhaseMessage(dfv1.StepFailed, "", fmt.Sprintf("failed to get pod spec %s: %v", podName, err)))
+			step.Status.Phase, step.Status.Reason, step.Status.Message = x.GetPhase(), x.GetReason(), x.GetMessage()
+			continue
+		}
+		if err := r.createVolumeClaims(ctx, step, replica, ownerReferences); err != nil {
+			x := dfv1.MinStepPhaseMessage(dfv1.NewStepPhaseMessage(step.Status.Phase, step.Status.Reason, step.Status.Message), dfv1.NewStepPhaseMessage(dfv1.StepFailed, "", err.Error()))
+			step.Status.Phase, step.Status.Reason, step.Status.Message = x.GetPhase(), x.GetReason(), x.GetMessage()
+			continue
+		}
 		if err := r.Client.Create(
 			ctx,
 			&corev1.Pod{
@@ -162,19 +270,7 @@ func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 					Annotations:     annotations,
 					OwnerReferences: ownerReferences,
 				},
-				Spec: step.GetPodSpec(
-					dfv1.GetPodSpecReq{
-						ClusterName:    clusterName,
-						PipelineName:   pipelineName,
-						Namespace:      step.Namespace,
-						Replica:        int32(replica),
-						ImageFormat:    imageFormat,
-						RunnerImage:    runnerImage,
-						PullPolicy:     pullPolicy,
-						UpdateInterval: updateInterval,
-						StepStatus:     step.Status,
-					},
-				),
+				Spec: podSpec,
 			},
 		); apierr.IsAlreadyExists(err) {
 			// ignore
@@ -199,41 +295,58 @@ func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
+	// while the step is scaled to zero (or is not yet running) requests are sent to the activator, which scales it up
+	activate := r.canActivate(step) && (desiredReplicas == 0 || oldStatus.Phase != dfv1.StepRunning)
+
 	for serviceName := range serviceNames {
-		if err := r.Client.Create(
-			ctx,
-			&corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace:       step.Namespace,
-					Name:            serviceName,
-					OwnerReferences: ownerReferences,
-					// useful for auto-detecting the service as exporting Prometheus
-					Labels: map[string]string{
-						dfv1.KeyStepName:     stepName,
-						dfv1.KeyPipelineName: pipelineName,
-					},
+		obj := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       step.Namespace,
+				Name:            serviceName,
+				OwnerReferences: ownerReferences,
+				// useful for auto-detecting the service as exporting Prometheus
+				Labels: map[string]string{
+					dfv1.KeyStepName:     stepName,
+					dfv1.KeyPipelineName: pipelineName,
 				},
-				Spec: corev1.ServiceSpec{
-					Ports: []corev1.ServicePort{
-						{Port: 443, TargetPort: intstr.FromInt(3570)},
-					},
-					Selector: map[string]string{
-						dfv1.KeyPipelineName: pipelineName,
-						dfv1.KeyStepName:     stepName,
-					},
+			},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{
+					{Port: 443, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(3570), AppProtocol: &httpsAppProtocol},
+				},
+				Selector: map[string]string{
+					dfv1.KeyPipelineName: pipelineName,
+					dfv1.KeyStepName:     stepName,
 				},
 			},
-		); util.IgnoreAlreadyExists(err) != nil {
+		}
+		if activate {
+			obj.Spec.Ports = []corev1.ServicePort{{Port: 443, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromString("activator"), AppProtocol: &httpsAppProtocol}}
+			obj.Spec.Selector = activatorSelector
+		}
+		if err := r.createOrUpdateService(ctx, obj); err != nil {
 			x := dfv1.MinStepPhaseMessage(dfv1.NewStepPhaseMessage(step.Status.Phase, step.Status.Reason, step.Status.Message), dfv1.NewStepPhaseMessage(dfv1.StepFailed, "", fmt.Sprintf("failed to create service %s: %v", step.Name, err)))
 			step.Status.Phase, step.Status.Reason, step.Status.Message = x.GetPhase(), x.GetReason(), x.GetMessage()
 		}
 	}
 
+	if err := r.exposeSources(ctx, step, selector, ownerReferences); err != nil {
+		x := dfv1.MinStepPhaseMessage(dfv1.NewStepPhaseMessage(step.Status.Phase, step.Status.Reason, step.Status.Message), dfv1.NewStepPhaseMessage(dfv1.StepFailed, "", err.Error()))
+		step.Status.Phase, step.Status.Reason, step.Status.Message = x.GetPhase(), x.GetReason(), x.GetMessage()
+	}
+
+	// claims are only deleted when the step is scaled down, not when its replicas are limited (e.g. by a policy violation)
+	if err := r.deleteExcessVolumeClaims(ctx, step, selector, specReplicas); err != nil {
+		x := dfv1.MinStepPhaseMessage(dfv1.NewStepPhaseMessage(step.Status.Phase, step.Status.Reason, step.Status.Message), dfv1.NewStepPhaseMessage(dfv1.StepFailed, "", err.Error()))
+		step.Status.Phase, step.Status.Reason, step.Status.Message = x.GetPhase(), x.GetReason(), x.GetMessage()
+	}
+
 	pods := &corev1.PodList{}
 	if err := r.Client.List(ctx, pods, &client.ListOptions{Namespace: step.Namespace, LabelSelector: selector}); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to list pods: %w", err)
 	}
 
+	hungRequeueAfter := time.Duration(0) // when to check a killed hung main container has exited
 	for _, pod := range pods.Items {
 		replica, err := strconv.Atoi(pod.GetAnnotations()[dfv1.KeyReplica])
 		if err != nil {
@@ -256,18 +369,62 @@ func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 				mainCtrTerminated = mainCtrTerminated || (s.Name == dfv1.CtrMain && s.State.Terminated != nil && s.State.Terminated.ExitCode == 0)
 			}
 			log.Info("pod", "name", pod.Name, "mainCtrTerminated", mainCtrTerminated)
-			if mainCtrTerminated {
+			killedHungAt, killedHung := getKilledHungAt(pod)
+			watchdog, hung := getHungMainContainer(*step, pod, replica)
+			if pod.DeletionTimestamp != nil {
+				// the pod is being deleted (e.g. restarted by the watchdog), its main container exiting does not mean
+				// the step completed, and the kubelet stops the sidecars
+			} else if killedHung && mainCtrTerminated {
+				// we killed the hung main container, and it exited zero, which the restart policy does not restart, and
+				// does not mean the step completed
+				r.deleteHungPod(ctx, log, step, pod, "main container exited zero when killed")
+			} else if killedHung && hung {
+				// we killed the hung main container, but it ignored SIGTERM (and PID 1 cannot be sent SIGKILL from
+				// within the container), so we delete the pod once its grace period has passed
+				if d := getTerminationGracePeriod(pod) - time.Since(killedHungAt); d > 0 {
+					hungRequeueAfter = minRequeueAfter(hungRequeueAfter, d)
+				} else {
+					r.deleteHungPod(ctx, log, step, pod, "main container did not exit when killed")
+				}
+			} else if mainCtrTerminated {
 				for _, s := range pod.Status.ContainerStatuses {
 					if s.Name != dfv1.CtrMain {
-						if err := r.ContainerKiller.KillContainer(pod, s.Name); err != nil {
-							log.Error(err, "failed to kill container", "pod", pod.Name, "container", s.Name)
-						}
+						killContainer(log, r.ContainerKiller, pod, s.Name)
 					}
 				}
+			} else if step.IsExhausted() {
+				// the bounded sources are exhausted, so stop the main container, and then (above) the sidecars
+				killContainer(log, r.ContainerKiller, pod, dfv1.CtrMain)
+			} else if hung {
+				// the watchdog found the main container hung, so kill it, and the pod's restart policy restarts it, we
+				// record when, so we can delete the pod if it does not exit, or exits zero
+				log.Info("killing hung main container", "pod", pod.Name, "reason", watchdog.Reason, "message", watchdog.Message)
+				if !killContainer(log, r.ContainerKiller, pod, dfv1.CtrMain) {
+					r.deleteHungPod(ctx, log, step, pod, "failed to kill main container")
+				} else if err := r.annotateKilledHung(ctx, pod); err != nil {
+					log.Error(err, "failed to annotate killed hung pod", "pod", pod.Name)
+				} else {
+					r.Recorder.Eventf(step, "Warning", "KillingHung", "Killing hung main container of %s: %s", pod.Name, watchdog.Message)
+					hungRequeueAfter = minRequeueAfter(hungRequeueAfter, getTerminationGracePeriod(pod))
+				}
 			}
 		}
 	}
 
+	if cfg.AggregateStepStatus {
+		get := func(ctx context.Context, pod corev1.Pod) (dfv1.StepStatus, error) {
+			return getReplicaStatus(ctx, pod, statusAuthorization)
+		}
+		if err := aggregateStepStatus(ctx, &step.Status, pods.Items, get); err != nil {
+			log.Error(err, "failed to aggregate step status") // we'll try again next time
+		}
+	}
+
+	now := metav1.Now()
+	step.Status.SourceErrorWindows = step.Status.SourceErrorWindows.Roll(step.Status.SourceStatuses, now)
+	step.Status.SinkErrorWindows = step.Status.SinkErrorWindows.Roll(step.Status.SinkStatues, now)
+	stepErrorEvents.emit(r.Recorder, step, cfg.DegradedErrorRatio)
+
 	if notEqual, patch := util.NotEqual(oldStatus, step.Status); notEqual {
 		log.Info("updating step", "patch", patch)
 		if err := r.Status().Update(ctx, step); err != nil {
@@ -279,9 +436,40 @@ func (r *StepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
-	return ctrl.Result{
-		RequeueAfter: dfv1.RequeueAfter(currentReplicas, desiredReplicas, scalingDelay),
-	}, nil
+	requeueAfter := dfv1.RequeueAfter(currentReplicas, desiredReplicas, cfg.ScalingDelay)
+	// while aggregating status, or limited by policy (e.g. waiting for other steps to scale down), check again shortly
+	if (cfg.AggregateStepStatus && desiredReplicas > 0 || len(policyLimits) > 0) && (requeueAfter == 0 || cfg.UpdateInterval < requeueAfter) {
+		requeueAfter = cfg.UpdateInterval
+	}
+	if hungRequeueAfter > 0 {
+		requeueAfter = minRequeueAfter(requeueAfter, hungRequeueAfter)
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// canActivate returns true if the step's services can be routed to the activator, which is only possible in the
+// manager's namespace, because a service cannot select pods in another namespace
+func (r *StepReconciler) canActivate(step *dfv1.Step) bool {
+	return r.Activator && step.Spec.Scale != nil && step.Namespace == os.Getenv(dfv1.EnvNamespace)
+}
+
+// createOrUpdateService creates the service, or updates the existing service's selector and ports if they have changed
+func (r *StepReconciler) createOrUpdateService(ctx context.Context, obj *corev1.Service) error {
+	if err := r.Client.Create(ctx, obj); !apierr.IsAlreadyExists(err) {
+		return err
+	}
+	old := &corev1.Service{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), old); err != nil {
+		return err
+	}
+	if notEqual, patch := util.NotEqual(corev1.ServiceSpec{Selector: old.Spec.Selector, Ports: old.Spec.Ports}, corev1.ServiceSpec{Selector: obj.Spec.Selector, Ports: obj.Spec.Ports}); notEqual {
+		r.Log.Info("updating service", "service", obj.Name, "patch", patch)
+		old.Spec.Selector = obj.Spec.Selector
+		old.Spec.Ports = obj.Spec.Ports
+		return util.IgnoreConflict(r.Client.Update(ctx, old)) // ignore conflicts, we will be reconciling again shortly if this happens
+	}
+	return nil
 }
 
 func eventReason(currentReplicas, desiredReplicas int) string {
@@ -292,10 +480,44 @@ func eventReason(currentReplicas, desiredReplicas int) string {
 	return eventType
 }
 
+// annotateKilledHung records when we killed the pod's hung main container
+func (r *StepReconciler) annotateKilledHung(ctx context.Context, pod corev1.Pod) error {
+	patch := util.MustJSON(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{dfv1.KeyKilledHungAt: time.Now().Format(time.RFC3339)},
+		},
+	})
+	return r.Client.Patch(ctx, &pod, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
+
+// deleteHungPod deletes the pod, and the reconciler re-creates it, when killing its hung main container did not restart
+// it
+func (r *StepReconciler) deleteHungPod(ctx context.Context, log logr.Logger, step *dfv1.Step, pod corev1.Pod, reason string) {
+	log.Info("restarting hung pod", "pod", pod.Name, "reason", reason)
+	if err := r.Client.Delete(ctx, &pod); client.IgnoreNotFound(err) != nil {
+		log.Error(err, "failed to delete hung pod", "pod", pod.Name) // we'll try again next time
+	} else {
+		r.Recorder.Eventf(step, "Warning", "RestartingHung", "Restarting hung pod %s: %s", pod.Name, reason)
+	}
+}
+
+// minRequeueAfter returns the shorter of the durations, where zero means never
+func minRequeueAfter(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
 func (r *StepReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&dfv1.Step{}).
-		Owns(&corev1.Pod{}).
+		Owns(&corev1.Pod{}, builder.WithPredicates(podStartupPredicate)).
 		Owns(&corev1.Service{}).
-		Complete(r)
+		Owns(&networkingv1.Ingress{}).
+		Watches(&source.Kind{Type: &dfv1.DataflowPolicy{}}, handler.EnqueueRequestsFromMapFunc(r.stepsForPolicy))
+	return r.Namespaces.watchNamespaces(b, r.Client, func() client.ObjectList { return &dfv1.StepList{} }).
+		WithEventFilter(r.Namespaces.Predicate()).
+		WithOptions(r.Namespaces.controllerOptions()).
+		Complete(withErrorMetrics("step", r.Namespaces.withRateLimit(r)))
 }
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/containerkiller"
+)
+
+func TestStepReconciler_canActivate(t *testing.T) {
+	_ = os.Setenv(dfv1.EnvNamespace, "argo-dataflow-system")
+	defer func() { _ = os.Unsetenv(dfv1.EnvNamespace) }()
+	step := func(namespace string, scale *dfv1.Scale) *dfv1.Step {
+		return &dfv1.Step{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}, Spec: dfv1.StepSpec{Scale: scale}}
+	}
+	r := &StepReconciler{Activator: true}
+	assert.True(t, r.canActivate(step("argo-dataflow-system", &dfv1.Scale{})))
+	assert.False(t, r.canActivate(step("other-ns", &dfv1.Scale{})), "a service cannot select the manager's pods in another namespace")
+	assert.False(t, r.canActivate(step("argo-dataflow-system", nil)))
+	assert.False(t, (&StepReconciler{}).canActivate(step("argo-dataflow-system", &dfv1.Scale{})))
+}
+
+func TestStepReconciler_Reconcile_Watchdog(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	now := time.Now()
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-time.Hour))}}
+	// setup returns a reconciler with a running pod, whose main container the watchdog found hung
+	setup := func(t *testing.T, k containerkiller.Interface) (*StepReconciler, *record.FakeRecorder, func() ctrl.Result, func() []corev1.Pod) {
+		step := &dfv1.Step{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main", Labels: map[string]string{dfv1.KeyPipelineName: "my-pl"}},
+			Spec:       dfv1.StepSpec{Name: "main", Cat: &dfv1.Cat{}, Replicas: 1, Watchdog: &dfv1.Watchdog{}},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(step).Build()
+		recorder := record.NewFakeRecorder(10)
+		r := &StepReconciler{Client: c, Log: ctrl.Log, Recorder: recorder, ContainerKiller: k}
+		reconcile := func() ctrl.Result {
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(step)})
+			assert.NoError(t, err)
+			return result
+		}
+		list := func() []corev1.Pod {
+			pods := &corev1.PodList{}
+			assert.NoError(t, c.List(ctx, pods))
+			return pods.Items
+		}
+		reconcile()
+		assert.Contains(t, <-recorder.Events, "ScaleUp")
+		pod := list()[0]
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: dfv1.CtrMain, State: running}, {Name: dfv1.CtrSidecar, State: running}}
+		assert.NoError(t, c.Status().Update(ctx, &pod))
+		assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(step), step))
+		step.Status.Watchdog = dfv1.WatchdogStatuses{}
+		step.Status.Watchdog.Set(0, dfv1.WatchdogReasonStuck, "stuck", metav1.NewTime(now))
+		assert.NoError(t, c.Status().Update(ctx, step))
+		return r, recorder, reconcile, list
+	}
+	t.Run("Killed", func(t *testing.T) {
+		_, recorder, reconcile, list := setup(t, fakeContainerKiller{})
+		result := reconcile()
+		pods := list()
+		assert.Len(t, pods, 1, "the main container is killed, rather than the pod deleted")
+		assert.NotEmpty(t, pods[0].Annotations[dfv1.KeyKilledHungAt])
+		assert.Contains(t, <-recorder.Events, "KillingHung")
+		assert.Greater(t, result.RequeueAfter, time.Duration(0), "we check it exited")
+
+		reconcile()
+		assert.Len(t, list(), 1)
+		assert.Empty(t, recorder.Events, "it is not killed again within its grace period")
+	})
+	t.Run("ExitedZero", func(t *testing.T) {
+		r, recorder, reconcile, list := setup(t, fakeContainerKiller{})
+		reconcile()
+		assert.Contains(t, <-recorder.Events, "KillingHung")
+		// the main container exits zero when killed, which the restart policy does not restart
+		pod := list()[0]
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{StartedAt: running.Running.StartedAt}}
+		assert.NoError(t, r.Client.Status().Update(ctx, &pod))
+
+		reconcile()
+		assert.Empty(t, list(), "the pod is deleted, rather than the sidecars killed")
+		assert.Contains(t, <-recorder.Events, "RestartingHung")
+
+		reconcile()
+		assert.Len(t, list(), 1, "the pod is re-created")
+		assert.Empty(t, recorder.Events, "the new pod is not restarted")
+	})
+	t.Run("DidNotExit", func(t *testing.T) {
+		r, recorder, reconcile, list := setup(t, fakeContainerKiller{})
+		pod := list()[0]
+		pod.Annotations[dfv1.KeyKilledHungAt] = now.Add(-time.Minute).Format(time.RFC3339) // longer ago than the grace period
+		assert.NoError(t, r.Client.Update(ctx, &pod))
+
+		reconcile()
+		assert.Empty(t, list())
+		assert.Contains(t, <-recorder.Events, "RestartingHung")
+	})
+	t.Run("KillFailed", func(t *testing.T) {
+		_, recorder, reconcile, list := setup(t, fakeContainerKiller{err: fmt.Errorf("failed")})
+		reconcile()
+		assert.Empty(t, list(), "the pod is deleted instead")
+		assert.Contains(t, <-recorder.Events, "RestartingHung")
+	})
+}
+
+func TestStepReconciler_createOrUpdateService(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	old := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 443, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(3570)}}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(old).Build()
+	r := &StepReconciler{Client: c, Log: ctrl.Log}
+	obj := old.DeepCopy()
+	obj.ResourceVersion = ""
+	obj.Spec.Ports[0].AppProtocol = &httpsAppProtocol
+	assert.NoError(t, r.createOrUpdateService(ctx, obj))
+	x := &corev1.Service{}
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(old), x))
+	if assert.NotNil(t, x.Spec.Ports[0].AppProtocol, "existing services are updated, so gateways connect using HTTPS") {
+		assert.Equal(t, "https", *x.Spec.Ports[0].AppProtocol)
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// errorEvents emits a warning event for each recent error of a step's sources and sinks, once, while their error ratio
+// is above the threshold, i.e. when errors spike, rather than for the occasional error
+type errorEvents struct {
+	mu    sync.Mutex
+	since time.Time                          // errors before this time were reported by a previous controller
+	last  map[types.NamespacedName]time.Time // the time of the last error we emitted an event for
+}
+
+var stepErrorEvents = newErrorEvents()
+
+func newErrorEvents() *errorEvents {
+	return &errorEvents{since: time.Now(), last: map[types.NamespacedName]time.Time{}}
+}
+
+func (e *errorEvents) emit(recorder record.EventRecorder, step *dfv1.Step, threshold float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := types.NamespacedName{Namespace: step.Namespace, Name: step.Name}
+	last, ok := e.last[key]
+	if !ok {
+		last = e.since
+	}
+	newLast := last
+	for reason, statuses := range map[string]struct {
+		dfv1.SourceStatuses
+		dfv1.ErrorWindows
+	}{
+		"SourceError": {step.Status.SourceStatuses, step.Status.SourceErrorWindows},
+		"SinkError":   {step.Status.SinkStatues, step.Status.SinkErrorWindows},
+	} {
+		for name, s := range statuses.SourceStatuses {
+			spiked := statuses.ErrorWindows[name].GetErrorRatio() > threshold
+			for _, x := range s.RecentErrors {
+				if !x.Time.Time.After(last) {
+					continue
+				}
+				// identical messages are aggregated by the recorder, so a repeating error is one event with a count
+				if spiked {
+					recorder.Eventf(step, "Warning", reason, "%s (replica %d): %s", name, x.Replica, x.Message)
+				}
+				if x.Time.Time.After(newLast) {
+					newLast = x.Time.Time
+				}
+			}
+		}
+	}
+	e.last[key] = newLast
+}
+
+func (e *errorEvents) forget(key types.NamespacedName) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.last, key)
+}
+
+// getDegradedMessage returns a message describing the sources and sinks whose error ratio, over the last complete
+// window, is above the threshold, or empty if there are none
+func getDegradedMessage(steps []dfv1.Step, threshold float64) string {
+	var ss []string
+	for _, step := range steps {
+		for kind, windows := range map[string]dfv1.ErrorWindows{
+			"source": step.Status.SourceErrorWindows,
+			"sink":   step.Status.SinkErrorWindows,
+		} {
+			for name, s := range windows {
+				if ratio := s.GetErrorRatio(); ratio > threshold {
+					ss = append(ss, fmt.Sprintf("step %q %s %q error ratio %.2f", step.Spec.Name, kind, name, ratio))
+				}
+			}
+		}
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, ", ")
+}
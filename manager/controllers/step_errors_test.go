@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_errorEvents(t *testing.T) {
+	e := newErrorEvents()
+	recorder := record.NewFakeRecorder(10)
+	old := metav1.NewTime(e.since.Add(-time.Second))
+	now := metav1.NewTime(e.since.Add(time.Second))
+	step := &dfv1.Step{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pl-main"},
+		Status: dfv1.StepStatus{
+			SourceStatuses:     dfv1.SourceStatuses{"in": {RecentErrors: dfv1.RecentErrors{{Message: "before restart", Time: old}}}},
+			SinkStatues:        dfv1.SourceStatuses{"out": {RecentErrors: dfv1.RecentErrors{{Message: "failed", Time: now, Replica: 1}}}},
+			SourceErrorWindows: dfv1.ErrorWindows{"in": {LastTotal: 10, LastErrors: 5}},
+			SinkErrorWindows:   dfv1.ErrorWindows{"out": {LastTotal: 10, LastErrors: 5}},
+		},
+	}
+	e.emit(recorder, step, 0.1)
+	assert.Equal(t, "Warning SinkError out (replica 1): failed", <-recorder.Events)
+	e.emit(recorder, step, 0.1)
+	assert.Empty(t, recorder.Events, "errors are only emitted once")
+	step.Status.SinkStatues["out"] = dfv1.SourceStatus{RecentErrors: dfv1.RecentErrors{{Message: "occasional", Time: metav1.NewTime(now.Add(time.Second))}}}
+	step.Status.SinkErrorWindows["out"] = dfv1.ErrorWindow{LastTotal: 100, LastErrors: 1}
+	e.emit(recorder, step, 0.1)
+	assert.Empty(t, recorder.Events, "errors are only emitted when they spike")
+	e.forget(types.NamespacedName{Namespace: "ns", Name: "pl-main"})
+	assert.Empty(t, e.last)
+}
+
+func Test_getDegradedMessage(t *testing.T) {
+	steps := []dfv1.Step{
+		{
+			Spec: dfv1.StepSpec{Name: "main"},
+			Status: dfv1.StepStatus{
+				// lifetime ratios are not used, only the last window's
+				SourceStatuses:     dfv1.SourceStatuses{"in": {Metrics: map[string]dfv1.Metrics{"0": {Total: 10, Errors: 10}}}},
+				SourceErrorWindows: dfv1.ErrorWindows{"in": {LastTotal: 10, LastErrors: 1}},
+				SinkErrorWindows:   dfv1.ErrorWindows{"out": {LastTotal: 10, LastErrors: 5}},
+			},
+		},
+	}
+	assert.Equal(t, `step "main" sink "out" error ratio 0.50`, getDegradedMessage(steps, 0.1))
+	assert.Empty(t, getDegradedMessage(steps, 0.5))
+	assert.Empty(t, getDegradedMessage(nil, 0.1))
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+var (
+	httpRouteGroupVersionKind     = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+	httpRouteListGroupVersionKind = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRouteList"}
+)
+
+// exposeSources creates an ingress or HTTP route for each exposed HTTP source, deletes any that are no longer needed,
+// and records each source's external URL in the status
+func (r *StepReconciler) exposeSources(ctx context.Context, step *dfv1.Step, selector labels.Selector, ownerReferences []metav1.OwnerReference) error {
+	pipelineName := step.GetLabels()[dfv1.KeyPipelineName]
+	exposed := false // whether any source was exposed, so there may be exposures to delete
+	for name, s := range step.Status.SourceStatuses {
+		exposed = exposed || s.URL != ""
+		s.URL = ""
+		step.Status.SourceStatuses[name] = s
+	}
+	wanted := map[string]bool{} // name of the ingress or route
+	for _, s := range step.Spec.Sources {
+		if s.HTTP == nil || s.HTTP.Expose == nil {
+			continue
+		}
+		x, e := s.HTTP, s.HTTP.Expose
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("invalid expose of source %q: %w", s.Name, err)
+		}
+		serviceName := pipelineName + "-" + step.Spec.Name
+		if x.ServiceName != "" {
+			serviceName = x.ServiceName
+		}
+		name := step.Name + "-" + s.Name
+		objectMeta := metav1.ObjectMeta{
+			Namespace:       step.Namespace,
+			Name:            name,
+			Labels:          map[string]string{dfv1.KeyPipelineName: pipelineName, dfv1.KeyStepName: step.Spec.Name},
+			Annotations:     e.Annotations,
+			OwnerReferences: ownerReferences,
+		}
+		var err error
+		if e.GetKind() == dfv1.ExposeHTTPRoute {
+			err = r.createOrUpdateHTTPRoute(ctx, newHTTPRoute(objectMeta, *e, s.Name, serviceName))
+		} else {
+			err = r.createOrUpdateIngress(ctx, newIngress(objectMeta, *e, s.Name, serviceName))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to expose source %q: %w", s.Name, err)
+		}
+		wanted[name] = true
+		if step.Status.SourceStatuses == nil {
+			step.Status.SourceStatuses = dfv1.SourceStatuses{}
+		}
+		status := step.Status.SourceStatuses[s.Name]
+		status.URL = e.GetURL(s.Name)
+		step.Status.SourceStatuses[s.Name] = status
+	}
+	if !exposed && len(wanted) == 0 {
+		return nil
+	}
+	return r.deleteExcessExposures(ctx, step.Namespace, selector, wanted)
+}
+
+func newIngress(objectMeta metav1.ObjectMeta, e dfv1.Expose, sourceName, serviceName string) *networkingv1.Ingress {
+	annotations := map[string]string{"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS"} // the sidecar only serves HTTPS
+	for k, v := range objectMeta.Annotations {
+		annotations[k] = v
+	}
+	objectMeta.Annotations = annotations
+	pathType := networkingv1.PathTypePrefix
+	obj := &networkingv1.Ingress{
+		ObjectMeta: objectMeta,
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: e.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     e.GetPath(sourceName),
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: serviceName,
+									Port: networkingv1.ServiceBackendPort{Number: 443},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if e.IngressClassName != "" {
+		obj.Spec.IngressClassName = &e.IngressClassName
+	}
+	if x := e.TLS; x != nil && x.SecretName != "" {
+		obj.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{e.Host}, SecretName: x.SecretName}}
+	}
+	return obj
+}
+
+func newHTTPRoute(objectMeta metav1.ObjectMeta, e dfv1.Expose, sourceName, serviceName string) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{"name": e.Gateway.Name}
+	if e.Gateway.Namespace != "" {
+		parentRef["namespace"] = e.Gateway.Namespace
+	}
+	rule := map[string]interface{}{
+		"matches":     []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": e.GetPath(sourceName)}}},
+		"backendRefs": []interface{}{map[string]interface{}{"name": serviceName, "port": int64(443)}},
+	}
+	if sourcePath := (dfv1.Expose{}).GetPath(sourceName); e.GetPath(sourceName) != sourcePath {
+		rule["filters"] = []interface{}{map[string]interface{}{
+			"type":       "URLRewrite",
+			"urlRewrite": map[string]interface{}{"path": map[string]interface{}{"type": "ReplacePrefixMatch", "replacePrefixMatch": sourcePath}},
+		}}
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{parentRef},
+			"hostnames":  []interface{}{e.Host},
+			"rules":      []interface{}{rule},
+		},
+	}}
+	obj.SetGroupVersionKind(httpRouteGroupVersionKind)
+	obj.SetNamespace(objectMeta.Namespace)
+	obj.SetName(objectMeta.Name)
+	obj.SetLabels(objectMeta.Labels)
+	obj.SetAnnotations(objectMeta.Annotations)
+	obj.SetOwnerReferences(objectMeta.OwnerReferences)
+	return obj
+}
+
+// createOrUpdateIngress creates the ingress, or updates the existing ingress's annotations and spec if they have changed
+func (r *StepReconciler) createOrUpdateIngress(ctx context.Context, obj *networkingv1.Ingress) error {
+	if err := r.Client.Create(ctx, obj); !apierr.IsAlreadyExists(err) {
+		return err
+	}
+	old := &networkingv1.Ingress{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), old); err != nil {
+		return err
+	}
+	if notEqual, patch := util.NotEqual(networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: old.Annotations}, Spec: old.Spec}, networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: obj.Annotations}, Spec: obj.Spec}); notEqual {
+		r.Log.Info("updating ingress", "ingress", obj.Name, "patch", patch)
+		old.Annotations = obj.Annotations
+		old.Spec = obj.Spec
+		return util.IgnoreConflict(r.Client.Update(ctx, old)) // ignore conflicts, we will be reconciling again shortly if this happens
+	}
+	return nil
+}
+
+// createOrUpdateHTTPRoute creates the route, or updates the existing route's annotations and spec if they have changed
+func (r *StepReconciler) createOrUpdateHTTPRoute(ctx context.Context, obj *unstructured.Unstructured) error {
+	if err := r.Client.Create(ctx, obj.DeepCopy()); !apierr.IsAlreadyExists(err) {
+		return err
+	}
+	old := &unstructured.Unstructured{}
+	old.SetGroupVersionKind(httpRouteGroupVersionKind)
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), old); err != nil {
+		return err
+	}
+	if notEqual, patch := util.NotEqual([]interface{}{old.GetAnnotations(), old.Object["spec"]}, []interface{}{obj.GetAnnotations(), obj.Object["spec"]}); notEqual {
+		r.Log.Info("updating HTTP route", "route", obj.GetName(), "patch", patch)
+		old.SetAnnotations(obj.GetAnnotations())
+		old.Object["spec"] = obj.Object["spec"]
+		return util.IgnoreConflict(r.Client.Update(ctx, old)) // ignore conflicts, we will be reconciling again shortly if this happens
+	}
+	return nil
+}
+
+// deleteExcessExposures deletes the step's ingresses and HTTP routes that are not wanted, e.g. because a source is no
+// longer exposed
+func (r *StepReconciler) deleteExcessExposures(ctx context.Context, namespace string, selector labels.Selector, wanted map[string]bool) error {
+	ingresses := &networkingv1.IngressList{}
+	if err := r.Client.List(ctx, ingresses, &client.ListOptions{Namespace: namespace, LabelSelector: selector}); err != nil {
+		return fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	var objs []client.Object
+	for i := range ingresses.Items {
+		objs = append(objs, &ingresses.Items[i])
+	}
+	routes := &unstructured.UnstructuredList{}
+	routes.SetGroupVersionKind(httpRouteListGroupVersionKind)
+	if err := r.Client.List(ctx, routes, &client.ListOptions{Namespace: namespace, LabelSelector: selector}); err != nil {
+		if !meta.IsNoMatchError(err) { // the Gateway API is not installed
+			return fmt.Errorf("failed to list HTTP routes: %w", err)
+		}
+	}
+	for i := range routes.Items {
+		objs = append(objs, &routes.Items[i])
+	}
+	for _, obj := range objs {
+		if wanted[obj.GetName()] {
+			continue
+		}
+		r.Log.Info("deleting excess exposure", "name", obj.GetName())
+		if err := r.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete %s: %w", obj.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestStepReconciler_exposeSources(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	scheme.AddKnownTypeWithName(httpRouteGroupVersionKind, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(httpRouteListGroupVersionKind, &unstructured.UnstructuredList{})
+	r := &StepReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Log: ctrl.Log}
+	step := &dfv1.Step{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pl-main", Labels: map[string]string{dfv1.KeyPipelineName: "pl"}},
+		Spec: dfv1.StepSpec{
+			Name: "main",
+			Sources: dfv1.Sources{
+				{Name: "in", HTTP: &dfv1.HTTPSource{Expose: &dfv1.Expose{Host: "example.com", TLS: &dfv1.ExposeTLS{SecretName: "my-tls"}, IngressClassName: "nginx"}}},
+				{Name: "other", HTTP: &dfv1.HTTPSource{ServiceName: "other", Expose: &dfv1.Expose{Kind: dfv1.ExposeHTTPRoute, Host: "example.com", Path: "/other", Gateway: &dfv1.GatewayReference{Name: "my-gateway"}}}},
+				{Name: "internal", HTTP: &dfv1.HTTPSource{}},
+			},
+		},
+	}
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=pl," + dfv1.KeyStepName + "=main")
+	t.Run("Expose", func(t *testing.T) {
+		assert.NoError(t, r.exposeSources(ctx, step, selector, nil))
+		assert.Equal(t, "https://example.com/sources/in", step.Status.SourceStatuses["in"].URL)
+		assert.Equal(t, "http://example.com/other", step.Status.SourceStatuses["other"].URL)
+		assert.Empty(t, step.Status.SourceStatuses["internal"].URL)
+
+		ingress := &networkingv1.Ingress{}
+		if assert.NoError(t, r.Client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "pl-main-in"}, ingress)) {
+			assert.Equal(t, "nginx", *ingress.Spec.IngressClassName)
+			assert.Equal(t, "HTTPS", ingress.Annotations["nginx.ingress.kubernetes.io/backend-protocol"])
+			assert.Equal(t, []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "my-tls"}}, ingress.Spec.TLS)
+			path := ingress.Spec.Rules[0].HTTP.Paths[0]
+			assert.Equal(t, "/sources/in", path.Path)
+			assert.Equal(t, "pl-main", path.Backend.Service.Name)
+		}
+
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGroupVersionKind)
+		if assert.NoError(t, r.Client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "pl-main-other"}, route)) {
+			rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+			if assert.Len(t, rules, 1) {
+				filters, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "filters")
+				if assert.Len(t, filters, 1) {
+					replace, _, _ := unstructured.NestedString(filters[0].(map[string]interface{}), "urlRewrite", "path", "replacePrefixMatch")
+					assert.Equal(t, "/sources/other", replace)
+				}
+			}
+		}
+	})
+	t.Run("Update", func(t *testing.T) {
+		step.Spec.Sources[0].HTTP.Expose.Host = "new.example.com"
+		assert.NoError(t, r.exposeSources(ctx, step, selector, nil))
+		ingress := &networkingv1.Ingress{}
+		assert.NoError(t, r.Client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "pl-main-in"}, ingress))
+		assert.Equal(t, "new.example.com", ingress.Spec.Rules[0].Host)
+	})
+	t.Run("Unexpose", func(t *testing.T) {
+		step.Spec.Sources = step.Spec.Sources[2:]
+		assert.NoError(t, r.exposeSources(ctx, step, selector, nil))
+		assert.Empty(t, step.Status.SourceStatuses["in"].URL)
+		ingresses := &networkingv1.IngressList{}
+		assert.NoError(t, r.Client.List(ctx, ingresses))
+		assert.Empty(t, ingresses.Items)
+		routes := &unstructured.UnstructuredList{}
+		routes.SetGroupVersionKind(httpRouteListGroupVersionKind)
+		assert.NoError(t, r.Client.List(ctx, routes))
+		assert.Empty(t, routes.Items)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		step.Spec.Sources = dfv1.Sources{{Name: "in", HTTP: &dfv1.HTTPSource{Expose: &dfv1.Expose{}}}}
+		assert.EqualError(t, r.exposeSources(ctx, step, selector, nil), `invalid expose of source "in": expose must have a host`)
+	})
+}
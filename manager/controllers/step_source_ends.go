@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	sharedkafka "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/kafka"
+	sharedstan "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/stan"
+)
+
+// recordSourceEnds records where each of the step's bounded Kafka, S3 and STAN sources ends in its status, once, before
+// any pods are created, so that every replica, including ones that restart or start later, reads up to the same place.
+// It returns true if the status was updated.
+func (r *StepReconciler) recordSourceEnds(ctx context.Context, log logr.Logger, step *dfv1.Step) (bool, error) {
+	sources := step.GetSourcesWithoutEnd()
+	if len(sources) == 0 {
+		return false, nil
+	}
+	if step.Status.SourceStatuses == nil {
+		step.Status.SourceStatuses = dfv1.SourceStatuses{}
+	}
+	secretInterface := r.KubernetesInterface.CoreV1().Secrets(step.Namespace)
+	for _, s := range sources {
+		if x := s.Kafka; x != nil {
+			k := *x.Kafka.DeepCopy()
+			if err := sharedkafka.Enrich(ctx, secretInterface, &k); err != nil {
+				return false, err
+			}
+			endOffsets, err := sharedkafka.GetEndOffsets(ctx, secretInterface, k)
+			if err != nil {
+				return false, fmt.Errorf("failed to get end offsets of source %q: %w", s.Name, err)
+			}
+			log.Info("recording end offsets", "source", s.Name, "endOffsets", endOffsets)
+			step.Status.SourceStatuses.SetEndOffsets(s.Name, endOffsets)
+		} else if x := s.STAN; x != nil {
+			y := x.DeepCopy()
+			if err := sharedstan.Enrich(ctx, secretInterface, y, step.Namespace, step.GetLabels()[dfv1.KeyPipelineName]); err != nil {
+				return false, err
+			}
+			channel, err := sharedstan.GetChannel(ctx, y.NATSMonitoringURL, y.Subject)
+			if err != nil {
+				return false, fmt.Errorf("failed to get end sequence of source %q: %w", s.Name, err)
+			}
+			log.Info("recording end sequence", "source", s.Name, "endSequence", channel.LastSeq)
+			step.Status.SourceStatuses.SetEndSequence(s.Name, channel.LastSeq)
+		} else if s.S3 != nil {
+			now := metav1.Now()
+			log.Info("recording end time", "source", s.Name, "endTime", now)
+			step.Status.SourceStatuses.SetEndTime(s.Name, now)
+		}
+	}
+	return true, r.Status().Update(ctx, step)
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestStepReconciler_recordSourceEnds(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-ns.my-pl.my-subject", r.URL.Query().Get("channel"))
+		_, _ = w.Write([]byte(`{"last_seq": 7}`))
+	}))
+	defer server.Close()
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	step := &dfv1.Step{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main", Labels: map[string]string{dfv1.KeyPipelineName: "my-pl"}},
+		Spec: dfv1.StepSpec{Name: "main", Sources: []dfv1.Source{
+			{Name: "stan", Bounded: true, STAN: &dfv1.STAN{Name: "default", Subject: "my-subject"}},
+			{Name: "s3", Bounded: true, S3: &dfv1.S3Source{S3: dfv1.S3{Bucket: "my-bucket"}}},
+			{Name: "unbounded", STAN: &dfv1.STAN{Subject: "other-subject"}},
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(step).Build()
+	r := &StepReconciler{
+		Client: c,
+		Log:    ctrl.Log,
+		KubernetesInterface: kubefake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "dataflow-stan-default"},
+			Data:       map[string][]byte{"natsMonitoringUrl": []byte(server.URL), "subjectPrefix": []byte(dfv1.SubjectPrefixNamespacedPipelineName)},
+		}),
+	}
+	updated, err := r.recordSourceEnds(ctx, ctrl.Log, step)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	x := &dfv1.Step{}
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(step), x))
+	assert.Equal(t, uint64(7), *x.Status.SourceStatuses.Get("stan").EndSequence)
+	endTime := x.Status.SourceStatuses.Get("s3").EndTime
+	assert.NotNil(t, endTime)
+	assert.False(t, x.Status.SourceStatuses.Get("unbounded").HasEnd())
+	t.Run("AlreadyRecorded", func(t *testing.T) {
+		updated, err := r.recordSourceEnds(ctx, ctrl.Log, x)
+		assert.NoError(t, err)
+		assert.False(t, updated, "the end is only recorded once, so that it does not move")
+		assert.Equal(t, endTime, x.Status.SourceStatuses.Get("s3").EndTime)
+	})
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// the sidecars use self-signed certificates, so cannot be verified, instead they require the step's status authorization
+var replicaStatusClient = func() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Timeout: 5 * time.Second, Transport: t}
+}()
+
+// ensureStatusAuthorization returns the authorization header the sidecars require to get their status, adding a random
+// one to the step's secret if it does not have one, and creating the secret if it does not exist
+func (r *StepReconciler) ensureStatusAuthorization(ctx context.Context, step *dfv1.Step, ownerReferences []metav1.OwnerReference) (string, error) {
+	secrets := r.KubernetesInterface.CoreV1().Secrets(step.Namespace)
+	secret, err := secrets.Get(ctx, step.Name, metav1.GetOptions{})
+	if apierr.IsNotFound(err) {
+		secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: step.Namespace, Name: step.Name, OwnerReferences: ownerReferences}}
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get secret %q: %w", step.Name, err)
+	}
+	if x := secret.Data[dfv1.KeyStatusAuthorization]; len(x) > 0 {
+		return string(x), nil
+	}
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	authorization := "Bearer " + base64.RawURLEncoding.EncodeToString(token)
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[dfv1.KeyStatusAuthorization] = []byte(authorization)
+	if secret.ResourceVersion == "" {
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	} else {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to add the status authorization to secret %q: %w", step.Name, err)
+	}
+	return authorization, nil
+}
+
+// getReplicaStatus gets the status of the replica from the pod's sidecar
+func getReplicaStatus(ctx context.Context, pod corev1.Pod, authorization string) (dfv1.StepStatus, error) {
+	status := dfv1.StepStatus{}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s:3571/status", pod.Status.PodIP), nil)
+	if err != nil {
+		return status, err
+	}
+	req.Header.Set("Authorization", authorization)
+	resp, err := replicaStatusClient.Do(req)
+	if err != nil {
+		return status, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != 200 {
+		return status, fmt.Errorf("failed to get status: %s", resp.Status)
+	}
+	return status, json.NewDecoder(resp.Body).Decode(&status)
+}
+
+// aggregateStepStatus merges the status of each running replica into the step's status. Replicas that cannot be
+// reached keep their last reported metrics.
+func aggregateStepStatus(ctx context.Context, status *dfv1.StepStatus, pods []corev1.Pod, get func(context.Context, corev1.Pod) (dfv1.StepStatus, error)) error {
+	if status.SourceStatuses == nil {
+		status.SourceStatuses = dfv1.SourceStatuses{}
+	}
+	if status.SinkStatues == nil {
+		status.SinkStatues = dfv1.SourceStatuses{}
+	}
+	if status.Watchdog == nil {
+		status.Watchdog = dfv1.WatchdogStatuses{}
+	}
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	var errs []error
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		replica, err := strconv.Atoi(pod.GetAnnotations()[dfv1.KeyReplica])
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(pod corev1.Pod) {
+			defer wg.Done()
+			x, err := get(ctx, pod)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get status of pod %s: %w", pod.Name, err))
+				return
+			}
+			status.SourceStatuses.MergeReplica(replica, x.SourceStatuses)
+			status.SinkStatues.MergeReplica(replica, x.SinkStatues)
+			status.Watchdog.MergeReplica(replica, x.Watchdog)
+		}(pod)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
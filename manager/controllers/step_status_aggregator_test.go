@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_aggregateStepStatus(t *testing.T) {
+	pod := func(name, replica string, phase corev1.PodPhase) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{dfv1.KeyReplica: replica}},
+			Status:     corev1.PodStatus{Phase: phase, PodIP: "1.2.3.4"},
+		}
+	}
+	get := func(_ context.Context, pod corev1.Pod) (dfv1.StepStatus, error) {
+		switch pod.Name {
+		case "a":
+			return dfv1.StepStatus{
+				SourceStatuses: dfv1.SourceStatuses{"in": {Metrics: map[string]dfv1.Metrics{"0": {Total: 1}}}},
+				SinkStatues:    dfv1.SourceStatuses{"out": {Metrics: map[string]dfv1.Metrics{"0": {Total: 1}}}},
+				Watchdog:       dfv1.WatchdogStatuses{"0": {Reason: dfv1.WatchdogReasonStuck}},
+			}, nil
+		case "b":
+			return dfv1.StepStatus{SourceStatuses: dfv1.SourceStatuses{"in": {Metrics: map[string]dfv1.Metrics{"1": {Total: 2}}}}}, nil
+		default:
+			return dfv1.StepStatus{}, fmt.Errorf("unreachable")
+		}
+	}
+	t.Run("Running", func(t *testing.T) {
+		status := &dfv1.StepStatus{}
+		err := aggregateStepStatus(context.Background(), status, []corev1.Pod{
+			pod("a", "0", corev1.PodRunning),
+			pod("b", "1", corev1.PodRunning),
+			pod("c", "2", corev1.PodPending),
+		}, get)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(3), status.SourceStatuses.GetTotal())
+		assert.Equal(t, uint64(1), status.SinkStatues.GetTotal())
+		assert.Equal(t, dfv1.WatchdogStatuses{"0": {Reason: dfv1.WatchdogReasonStuck}}, status.Watchdog)
+	})
+	t.Run("Error", func(t *testing.T) {
+		status := &dfv1.StepStatus{SourceStatuses: dfv1.SourceStatuses{"in": {Metrics: map[string]dfv1.Metrics{"2": {Total: 5}}}}}
+		err := aggregateStepStatus(context.Background(), status, []corev1.Pod{pod("c", "2", corev1.PodRunning)}, get)
+		assert.EqualError(t, err, "failed to get status of pod c: unreachable")
+		assert.Equal(t, uint64(5), status.SourceStatuses.GetTotal(), "last reported metrics are kept")
+	})
+}
+
+func TestStepReconciler_ensureStatusAuthorization(t *testing.T) {
+	ctx := context.Background()
+	step := &dfv1.Step{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main"}}
+	get := func(r *StepReconciler) *corev1.Secret {
+		secret, err := r.KubernetesInterface.CoreV1().Secrets("my-ns").Get(ctx, "my-pl-main", metav1.GetOptions{})
+		assert.NoError(t, err)
+		return secret
+	}
+	t.Run("Created", func(t *testing.T) {
+		r := &StepReconciler{KubernetesInterface: kubefake.NewSimpleClientset()}
+		ownerReferences := []metav1.OwnerReference{{Name: "my-pl-main"}}
+		authorization, err := r.ensureStatusAuthorization(ctx, step, ownerReferences)
+		assert.NoError(t, err)
+		assert.Regexp(t, "^Bearer .{43}$", authorization)
+		secret := get(r)
+		assert.Equal(t, authorization, string(secret.Data[dfv1.KeyStatusAuthorization]))
+		assert.Equal(t, ownerReferences, secret.OwnerReferences)
+		again, err := r.ensureStatusAuthorization(ctx, step, ownerReferences)
+		assert.NoError(t, err)
+		assert.Equal(t, authorization, again, "unchanged")
+	})
+	t.Run("Added", func(t *testing.T) {
+		r := &StepReconciler{KubernetesInterface: kubefake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main", ResourceVersion: "1"},
+			Data:       map[string][]byte{"sources.default.http.authorization": []byte("Bearer my-bearer-token")},
+		})}
+		authorization, err := r.ensureStatusAuthorization(ctx, step, nil)
+		assert.NoError(t, err)
+		secret := get(r)
+		assert.Equal(t, authorization, string(secret.Data[dfv1.KeyStatusAuthorization]))
+		assert.Equal(t, "Bearer my-bearer-token", string(secret.Data["sources.default.http.authorization"]), "kept")
+	})
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// createVolumeClaims creates the replica's claims, if they do not already exist. Existing claims are not updated, as
+// most of a claim's spec is immutable.
+func (r *StepReconciler) createVolumeClaims(ctx context.Context, step *dfv1.Step, replica int, ownerReferences []metav1.OwnerReference) error {
+	if !step.Spec.PersistentVolumeClaimRetentionPolicy.DeleteWhenDeleted() {
+		ownerReferences = nil // retained claims must not be garbage collected with the step
+	}
+	for _, t := range step.Spec.VolumeClaimTemplates {
+		_labels := map[string]string{}
+		annotations := map[string]string{}
+		if x := t.Metadata; x != nil {
+			for k, v := range x.Annotations {
+				annotations[k] = v
+			}
+			for k, v := range x.Labels {
+				_labels[k] = v
+			}
+		}
+		_labels[dfv1.KeyPipelineName] = step.GetLabels()[dfv1.KeyPipelineName]
+		_labels[dfv1.KeyStepName] = step.Spec.Name
+		annotations[dfv1.KeyReplica] = strconv.Itoa(replica)
+		obj := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       step.Namespace,
+				Name:            step.GetClaimName(t.Name, replica),
+				Labels:          _labels,
+				Annotations:     annotations,
+				OwnerReferences: ownerReferences,
+			},
+			Spec: t.Spec,
+		}
+		if err := r.Client.Create(ctx, obj); err != nil && !apierr.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create volume claim %s: %w", obj.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteExcessVolumeClaims deletes the claims of replicas the step has been scaled down from, if the retention policy
+// says so. specReplicas must be the spec's replicas, before any limits, so that claims are not deleted just because the
+// replicas were limited. A claim still used by a terminating pod is only removed once that pod is gone.
+func (r *StepReconciler) deleteExcessVolumeClaims(ctx context.Context, step *dfv1.Step, selector labels.Selector, specReplicas int) error {
+	if !step.Spec.PersistentVolumeClaimRetentionPolicy.DeleteWhenScaled() {
+		return nil
+	}
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := r.Client.List(ctx, claims, &client.ListOptions{Namespace: step.Namespace, LabelSelector: selector}); err != nil {
+		return fmt.Errorf("failed to list volume claims: %w", err)
+	}
+	for _, claim := range claims.Items {
+		replica, err := strconv.Atoi(claim.GetAnnotations()[dfv1.KeyReplica])
+		if err != nil || replica < specReplicas || claim.GetDeletionTimestamp() != nil {
+			continue
+		}
+		r.Log.Info("deleting excess volume claim", "claimName", claim.Name)
+		if err := r.Client.Delete(ctx, &claim); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete excess volume claim %s: %w", claim.Name, err)
+		}
+	}
+	return nil
+}
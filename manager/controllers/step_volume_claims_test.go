@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func TestStepReconciler_volumeClaims(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	r := &StepReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Log: ctrl.Log}
+	step := &dfv1.Step{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pl-main", Labels: map[string]string{dfv1.KeyPipelineName: "pl"}},
+		Spec: dfv1.StepSpec{
+			Name:                 "main",
+			VolumeClaimTemplates: []dfv1.VolumeClaimTemplate{{Name: "state", Metadata: &dfv1.Metadata{Labels: map[string]string{"foo": "bar"}}}},
+		},
+	}
+	ownerReferences := []metav1.OwnerReference{{Name: "pl-main"}}
+	selector, _ := labels.Parse(dfv1.KeyPipelineName + "=pl," + dfv1.KeyStepName + "=main")
+	list := func() []corev1.PersistentVolumeClaim {
+		claims := &corev1.PersistentVolumeClaimList{}
+		assert.NoError(t, r.Client.List(ctx, claims, &client.ListOptions{LabelSelector: selector}))
+		return claims.Items
+	}
+	t.Run("Create", func(t *testing.T) {
+		for replica := 0; replica < 2; replica++ {
+			assert.NoError(t, r.createVolumeClaims(ctx, step, replica, ownerReferences))
+		}
+		assert.NoError(t, r.createVolumeClaims(ctx, step, 0, ownerReferences), "existing claims are ignored")
+		claims := list()
+		if assert.Len(t, claims, 2) {
+			assert.Equal(t, "state-pl-main-0", claims[0].Name)
+			assert.Equal(t, "bar", claims[0].Labels["foo"])
+			assert.Equal(t, "0", claims[0].Annotations[dfv1.KeyReplica])
+			assert.Empty(t, claims[0].OwnerReferences, "retained by default")
+		}
+	})
+	t.Run("Retain", func(t *testing.T) {
+		assert.NoError(t, r.deleteExcessVolumeClaims(ctx, step, selector, 1))
+		assert.Len(t, list(), 2)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		step.Spec.PersistentVolumeClaimRetentionPolicy = &dfv1.PersistentVolumeClaimRetentionPolicy{
+			WhenDeleted: dfv1.DeletePersistentVolumeClaimRetentionPolicyType,
+			WhenScaled:  dfv1.DeletePersistentVolumeClaimRetentionPolicyType,
+		}
+		assert.NoError(t, r.deleteExcessVolumeClaims(ctx, step, selector, 1))
+		claims := list()
+		if assert.Len(t, claims, 1) {
+			assert.Equal(t, "state-pl-main-0", claims[0].Name)
+		}
+		assert.NoError(t, r.createVolumeClaims(ctx, step, 1, ownerReferences))
+		claim := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, r.Client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "state-pl-main-1"}, claim))
+		assert.Equal(t, ownerReferences, claim.OwnerReferences)
+	})
+}
+
+func TestStepReconciler_Reconcile_volumeClaimsPolicyViolation(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	step := &dfv1.Step{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-pl-main", Labels: map[string]string{dfv1.KeyPipelineName: "my-pl"}},
+		Spec: dfv1.StepSpec{
+			Name:                 "main",
+			Cat:                  &dfv1.Cat{},
+			Replicas:             2,
+			Sinks:                []dfv1.Sink{{Name: "default", Kafka: &dfv1.Kafka{}}},
+			VolumeClaimTemplates: []dfv1.VolumeClaimTemplate{{Name: "state"}},
+			PersistentVolumeClaimRetentionPolicy: &dfv1.PersistentVolumeClaimRetentionPolicy{
+				WhenScaled: dfv1.DeletePersistentVolumeClaimRetentionPolicyType,
+			},
+		},
+		Status: dfv1.StepStatus{Replicas: 2},
+	}
+	claim := func(replica string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "my-ns",
+			Name:        "state-my-pl-main-" + replica,
+			Labels:      map[string]string{dfv1.KeyPipelineName: "my-pl", dfv1.KeyStepName: "main"},
+			Annotations: map[string]string{dfv1.KeyReplica: replica},
+		}}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		step,
+		claim("0"),
+		claim("1"),
+		newTestPolicy("my-policy", dfv1.DataflowPolicySpec{AllowedSinks: []string{"log"}}),
+	).Build()
+	r := &StepReconciler{Client: c, Log: ctrl.Log, Recorder: record.NewFakeRecorder(10)}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(step)})
+	assert.NoError(t, err)
+	x := &dfv1.Step{}
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(step), x))
+	assert.Equal(t, uint32(0), x.Status.Replicas, "the policy violation scales the step to zero")
+	claims := &corev1.PersistentVolumeClaimList{}
+	assert.NoError(t, c.List(ctx, claims))
+	assert.Len(t, claims.Items, 2, "claims are not deleted because the replicas were limited")
+}
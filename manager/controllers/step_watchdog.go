@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+// getHungMainContainer returns the replica's watchdog status, and true, if the sidecar found the pod's main container
+// hung since it was last started, and the pod is not already being deleted, i.e. it needs to be restarted
+func getHungMainContainer(step dfv1.Step, pod corev1.Pod, replica int) (dfv1.WatchdogStatus, bool) {
+	x, ok := step.Status.Watchdog.Get(replica)
+	if step.Spec.Watchdog == nil || !ok || pod.DeletionTimestamp != nil {
+		return x, false
+	}
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name == dfv1.CtrMain && s.State.Running != nil {
+			return x, x.DetectedAt.After(s.State.Running.StartedAt.Time)
+		}
+	}
+	return x, false
+}
+
+// getKilledHungAt returns when the controller killed the pod's hung main container, and true, if it killed the one that
+// is running, or has terminated, rather than one that has since been restarted
+func getKilledHungAt(pod corev1.Pod) (time.Time, bool) {
+	killedAt, err := time.Parse(time.RFC3339, pod.GetAnnotations()[dfv1.KeyKilledHungAt])
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name != dfv1.CtrMain {
+			continue
+		}
+		if x := s.State.Running; x != nil {
+			return killedAt, !x.StartedAt.After(killedAt)
+		}
+		if x := s.State.Terminated; x != nil {
+			return killedAt, !x.StartedAt.After(killedAt)
+		}
+	}
+	return killedAt, false
+}
+
+// getTerminationGracePeriod returns how long a killed main container has to exit
+func getTerminationGracePeriod(pod corev1.Pod) time.Duration {
+	if x := pod.Spec.TerminationGracePeriodSeconds; x != nil {
+		return time.Duration(*x) * time.Second
+	}
+	return corev1.DefaultTerminationGracePeriodSeconds * time.Second
+}
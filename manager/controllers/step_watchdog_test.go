@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+)
+
+func Test_getHungMainContainer(t *testing.T) {
+	now := time.Now()
+	step := dfv1.Step{
+		Spec: dfv1.StepSpec{Watchdog: &dfv1.Watchdog{}},
+		Status: dfv1.StepStatus{Watchdog: dfv1.WatchdogStatuses{
+			"0": {Reason: dfv1.WatchdogReasonStuck, DetectedAt: metav1.NewTime(now)},
+		}},
+	}
+	pod := func(startedAt time.Time) corev1.Pod {
+		return corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: dfv1.CtrMain, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt)}}},
+		}}}
+	}
+	t.Run("Hung", func(t *testing.T) {
+		x, hung := getHungMainContainer(step, pod(now.Add(-time.Minute)), 0)
+		assert.True(t, hung)
+		assert.Equal(t, dfv1.WatchdogReasonStuck, x.Reason)
+	})
+	t.Run("Restarted", func(t *testing.T) {
+		_, hung := getHungMainContainer(step, pod(now.Add(time.Minute)), 0)
+		assert.False(t, hung)
+	})
+	t.Run("Deleting", func(t *testing.T) {
+		p := pod(now.Add(-time.Minute))
+		p.DeletionTimestamp = &metav1.Time{Time: now}
+		_, hung := getHungMainContainer(step, p, 0)
+		assert.False(t, hung, "not restarted again")
+	})
+	t.Run("NotRunning", func(t *testing.T) {
+		_, hung := getHungMainContainer(step, corev1.Pod{}, 0)
+		assert.False(t, hung)
+	})
+	t.Run("OtherReplica", func(t *testing.T) {
+		_, hung := getHungMainContainer(step, pod(now.Add(-time.Minute)), 1)
+		assert.False(t, hung)
+	})
+	t.Run("NoWatchdog", func(t *testing.T) {
+		_, hung := getHungMainContainer(dfv1.Step{Status: step.Status}, pod(now.Add(-time.Minute)), 0)
+		assert.False(t, hung)
+	})
+}
+
+func Test_getKilledHungAt(t *testing.T) {
+	killedAt := time.Now().Truncate(time.Second)
+	pod := func(state corev1.ContainerState) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{dfv1.KeyKilledHungAt: killedAt.Format(time.RFC3339)}},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: dfv1.CtrMain, State: state}}},
+		}
+	}
+	before, after := metav1.NewTime(killedAt.Add(-time.Minute)), metav1.NewTime(killedAt.Add(time.Minute))
+	t.Run("Running", func(t *testing.T) {
+		x, killed := getKilledHungAt(pod(corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: before}}))
+		assert.True(t, killed)
+		assert.True(t, killedAt.Equal(x))
+	})
+	t.Run("Terminated", func(t *testing.T) {
+		_, killed := getKilledHungAt(pod(corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{StartedAt: before}}))
+		assert.True(t, killed)
+	})
+	t.Run("Restarted", func(t *testing.T) {
+		_, killed := getKilledHungAt(pod(corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: after}}))
+		assert.False(t, killed, "we killed a previous main container")
+	})
+	t.Run("NotKilled", func(t *testing.T) {
+		_, killed := getKilledHungAt(corev1.Pod{})
+		assert.False(t, killed)
+	})
+}
+
+func Test_getTerminationGracePeriod(t *testing.T) {
+	assert.Equal(t, 30*time.Second, getTerminationGracePeriod(corev1.Pod{}))
+	grace := int64(5)
+	assert.Equal(t, 5*time.Second, getTerminationGracePeriod(corev1.Pod{Spec: corev1.PodSpec{TerminationGracePeriodSeconds: &grace}}))
+}
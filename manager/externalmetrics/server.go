@@ -0,0 +1,230 @@
+package externalmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+const prefix = "/apis/" + v1beta1.GroupName + "/v1beta1"
+
+// the config map the API server publishes the CA of its front-proxy client certificate in, so that aggregated API
+// servers can authenticate the requests it proxies to them
+const (
+	authenticationNamespace = "kube-system"
+	authenticationName      = "extension-apiserver-authentication"
+)
+
+var logger = util.NewLogger()
+
+// the metrics we serve, keyed by name, these are named after the sidecar metrics they mirror
+var metrics = map[string]func(step dfv1.Step) resource.Quantity{
+	"sources_pending": func(step dfv1.Step) resource.Quantity {
+		return *resource.NewQuantity(int64(step.Status.SourceStatuses.GetPending()), resource.DecimalSI)
+	},
+	"sources_total": func(step dfv1.Step) resource.Quantity {
+		return step.Status.SourceStatuses.GetRate() // messages per second
+	},
+}
+
+// Server serves the subset of the Kubernetes external metrics API that a HorizontalPodAutoscaler needs to scale steps
+// using their `scale` sub-resource. Only the API server may call it, using its front-proxy client certificate.
+type Server struct {
+	client.Reader
+	KubernetesInterface kubernetes.Interface
+	Addr                string
+	CertDir             string // contains the serving certificate, tls.crt and tls.key, a self-signed one is generated if empty
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	clientCAs, allowedNames, err := s.getClientCAs(ctx)
+	if err != nil {
+		return err
+	}
+	certDir := s.CertDir
+	if certDir == "" {
+		dir, err := os.MkdirTemp("", "external-metrics")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+		if err := util.GenerateCert(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")); err != nil {
+			return fmt.Errorf("failed to generate cert: %w", err)
+		}
+		certDir = dir
+	}
+	certFile, keyFile := filepath.Join(certDir, "tls.crt"), filepath.Join(certDir, "tls.key")
+	server := &http.Server{Addr: s.Addr, Handler: authenticate(allowedNames, s.Handler()), TLSConfig: &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		// loaded for each connection, so that a renewed certificate is used without a restart
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			return &cert, err
+		},
+	}}
+	go func() {
+		defer runtimeutil.HandleCrash()
+		<-ctx.Done()
+		logger.Info("closing external metrics server")
+		_ = server.Shutdown(context.Background())
+	}()
+	logger.Info("starting external metrics server", "addr", s.Addr)
+	if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// getClientCAs returns the CAs of the API server's front-proxy client certificate, and the names that certificate may
+// have, any name is allowed if there are none
+func (s *Server) getClientCAs(ctx context.Context) (*x509.CertPool, []string, error) {
+	cm, err := s.KubernetesInterface.CoreV1().ConfigMaps(authenticationNamespace).Get(ctx, authenticationName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get config map %q: %w", authenticationName, err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM([]byte(cm.Data["requestheader-client-ca-file"])) {
+		return nil, nil, fmt.Errorf("config map %q has no requestheader-client-ca-file", authenticationName)
+	}
+	var allowedNames []string
+	if v, ok := cm.Data["requestheader-allowed-names"]; ok {
+		if err := json.Unmarshal([]byte(v), &allowedNames); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse requestheader-allowed-names: %w", err)
+		}
+	}
+	return clientCAs, allowedNames, nil
+}
+
+// authenticate only allows requests with a verified client certificate with one of the allowed names
+func authenticate(allowedNames []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			writeError(w, 401, fmt.Errorf("unauthorized"))
+			return
+		}
+		if !allowed(allowedNames, r.TLS.VerifiedChains[0][0].Subject.CommonName) {
+			writeError(w, 403, fmt.Errorf("forbidden"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func allowed(allowedNames []string, name string) bool {
+	if len(allowedNames) == 0 {
+		return true
+	}
+	for _, n := range allowedNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedLeaderElection is false because every replica of the manager can serve metrics.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix, s.resources)
+	mux.HandleFunc(prefix+"/namespaces/", s.values)
+	return mux
+}
+
+func (s *Server) resources(w http.ResponseWriter, _ *http.Request) {
+	list := metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: v1beta1.SchemeGroupVersion.String(),
+	}
+	for name := range metrics {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       name,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      metav1.Verbs{"get"},
+		})
+	}
+	writeJSON(w, 200, list)
+}
+
+// values serves `/namespaces/{namespace}/{metricName}?labelSelector={selector}`, returning one value per matching step
+func (s *Server) values(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix+"/namespaces/"), "/")
+	if len(parts) != 2 {
+		writeError(w, 404, fmt.Errorf("not found"))
+		return
+	}
+	namespace, metricName := parts[0], parts[1]
+	f, ok := metrics[metricName]
+	if !ok {
+		writeError(w, 404, fmt.Errorf("metric %q not found", metricName))
+		return
+	}
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeError(w, 400, fmt.Errorf("failed to parse label selector: %w", err))
+		return
+	}
+	steps := &dfv1.StepList{}
+	if err := s.List(r.Context(), steps, &client.ListOptions{Namespace: namespace, LabelSelector: selector}); err != nil {
+		writeError(w, 500, fmt.Errorf("failed to list steps: %w", err))
+		return
+	}
+	list := v1beta1.ExternalMetricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "ExternalMetricValueList", APIVersion: v1beta1.SchemeGroupVersion.String()},
+		Items:    []v1beta1.ExternalMetricValue{},
+	}
+	now := metav1.NewTime(time.Now())
+	for _, step := range steps.Items {
+		list.Items = append(list.Items, v1beta1.ExternalMetricValue{
+			MetricName: metricName,
+			MetricLabels: map[string]string{
+				dfv1.KeyPipelineName: step.GetLabels()[dfv1.KeyPipelineName],
+				dfv1.KeyStepName:     step.Spec.Name,
+			},
+			Timestamp: now,
+			Value:     f(step),
+		})
+	}
+	writeJSON(w, 200, list)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  err.Error(),
+		Code:     int32(code),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error(err, "failed to write response")
+	}
+}
@@ -0,0 +1,140 @@
+package externalmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/shared/util"
+)
+
+func TestServer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = dfv1.AddToScheme(scheme)
+	pending := uint64(12)
+	s := &Server{Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&dfv1.Step{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "my-ns",
+			Name:      "my-pl-main",
+			Labels:    map[string]string{dfv1.KeyPipelineName: "my-pl", dfv1.KeyStepName: "main"},
+		},
+		Spec: dfv1.StepSpec{Name: "main"},
+		Status: dfv1.StepStatus{
+			SourceStatuses: dfv1.SourceStatuses{
+				"default": {
+					Pending: &pending,
+					Metrics: map[string]dfv1.Metrics{"0": {Rate: resource.MustParse("3")}},
+				},
+			},
+		},
+	}).Build()}
+	get := func(path string) (int, v1beta1.ExternalMetricValueList) {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		list := v1beta1.ExternalMetricValueList{}
+		_ = json.Unmarshal(w.Body.Bytes(), &list)
+		return w.Code, list
+	}
+	t.Run("Resources", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest("GET", prefix, nil))
+		assert.Equal(t, 200, w.Code)
+		list := metav1.APIResourceList{}
+		_ = json.Unmarshal(w.Body.Bytes(), &list)
+		assert.Len(t, list.APIResources, 2)
+	})
+	t.Run("Pending", func(t *testing.T) {
+		code, list := get(prefix + "/namespaces/my-ns/sources_pending?labelSelector=dataflow.argoproj.io/pipeline-name%3Dmy-pl")
+		assert.Equal(t, 200, code)
+		if assert.Len(t, list.Items, 1) {
+			assert.Equal(t, int64(12), list.Items[0].Value.Value())
+			assert.Equal(t, "main", list.Items[0].MetricLabels[dfv1.KeyStepName])
+		}
+	})
+	t.Run("Total", func(t *testing.T) {
+		code, list := get(prefix + "/namespaces/my-ns/sources_total")
+		assert.Equal(t, 200, code)
+		if assert.Len(t, list.Items, 1) {
+			assert.Equal(t, int64(3), list.Items[0].Value.Value())
+		}
+	})
+	t.Run("NoMatch", func(t *testing.T) {
+		code, list := get(prefix + "/namespaces/my-ns/sources_pending?labelSelector=dataflow.argoproj.io/pipeline-name%3Dother")
+		assert.Equal(t, 200, code)
+		assert.Empty(t, list.Items)
+	})
+	t.Run("UnknownMetric", func(t *testing.T) {
+		code, _ := get(prefix + "/namespaces/my-ns/foo")
+		assert.Equal(t, 404, code)
+	})
+}
+
+func TestServer_getClientCAs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	assert.NoError(t, util.GenerateCert(certFile, filepath.Join(dir, "tls.key")))
+	ca, err := os.ReadFile(certFile)
+	assert.NoError(t, err)
+	newServer := func(data map[string]string) *Server {
+		return &Server{KubernetesInterface: kubefake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "extension-apiserver-authentication"},
+			Data:       data,
+		})}
+	}
+	t.Run("Found", func(t *testing.T) {
+		s := newServer(map[string]string{"requestheader-client-ca-file": string(ca), "requestheader-allowed-names": `["front-proxy-client"]`})
+		clientCAs, allowedNames, err := s.getClientCAs(ctx)
+		assert.NoError(t, err)
+		assert.NotNil(t, clientCAs)
+		assert.Equal(t, []string{"front-proxy-client"}, allowedNames)
+	})
+	t.Run("NoCA", func(t *testing.T) {
+		_, _, err := newServer(map[string]string{}).getClientCAs(ctx)
+		assert.Error(t, err)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		_, _, err := (&Server{KubernetesInterface: kubefake.NewSimpleClientset()}).getClientCAs(ctx)
+		assert.Error(t, err)
+	})
+}
+
+func Test_authenticate(t *testing.T) {
+	h := authenticate([]string{"front-proxy-client"}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(200)
+	}))
+	serve := func(state *tls.ConnectionState) int {
+		r := httptest.NewRequest("GET", prefix, nil)
+		r.TLS = state
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	verified := func(name string) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: name}}}}}
+	}
+	assert.Equal(t, 401, serve(nil))
+	assert.Equal(t, 401, serve(&tls.ConnectionState{}), "no verified client certificate")
+	assert.Equal(t, 403, serve(verified("other")))
+	assert.Equal(t, 200, serve(verified("front-proxy-client")))
+	t.Run("AnyName", func(t *testing.T) {
+		assert.True(t, allowed(nil, "other"))
+	})
+}
@@ -17,22 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"k8s.io/client-go/dynamic"
 
 	"github.com/argoproj-labs/argo-dataflow/shared/util"
 
 	dataflowv1alpha1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/argoproj-labs/argo-dataflow/manager/activator"
 	"github.com/argoproj-labs/argo-dataflow/manager/controllers"
+	"github.com/argoproj-labs/argo-dataflow/manager/externalmetrics"
 	"github.com/argoproj-labs/argo-dataflow/shared/containerkiller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var (
@@ -49,52 +59,152 @@ func init() {
 
 func main() {
 	var metricsAddr string
+	var externalMetricsAddr string
+	var externalMetricsCertDir string
+	var activatorAddr string
 	var enableLeaderElection bool
+	var namespaces string
+	var namespaceSelector string
+	var maxConcurrentReconciles int
 	flag.StringVar(&metricsAddr, "metrics-addr", ":9090", "The address the metric endpoint binds to.")
+	flag.StringVar(&externalMetricsAddr, "external-metrics-addr", "", "The address the external metrics API binds to, e.g. \":6443\". Disabled if empty.")
+	flag.StringVar(&externalMetricsCertDir, "external-metrics-cert-dir", "", "The directory containing the external metrics API's serving certificate, tls.crt and tls.key. A self-signed certificate is generated if empty.")
+	flag.StringVar(&activatorAddr, "activator-addr", "", "The address the HTTP source activator binds to, e.g. \":3571\". Disabled if empty.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&namespaces, "namespaces", "", "Comma-separated namespaces to watch, or \"*\" for all namespaces. Defaults to the controller's namespace.")
+	flag.StringVar(&namespaceSelector, "namespace-selector", "", "Only watch namespaces with labels matching this selector, e.g. \"dataflow=true\". Implies all namespaces.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1, "How many pipelines, and how many steps, may be reconciled at once when watching more than one namespace.")
 	flag.Parse()
 
 	ctrl.SetLogger(util.NewLogger())
 
 	restConfig := ctrl.GetConfigOrDie()
-	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+	opts := ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "1c03be80.my.domain",
 		Namespace:          os.Getenv(dataflowv1alpha1.EnvNamespace),
-	})
+	}
+	var selector labels.Selector
+	if namespaceSelector != "" {
+		var err error
+		if selector, err = labels.Parse(namespaceSelector); err != nil {
+			panic(fmt.Errorf("invalid namespace selector: %w", err))
+		}
+		namespaces = "*"
+	}
+	switch namespaces {
+	case "":
+	case "*":
+		opts.Namespace = ""
+	default:
+		// the controller's namespace is always watched, as it has the controller's config map
+		opts.Namespace = ""
+		opts.NewCache = cache.MultiNamespacedCacheBuilder(append(strings.Split(namespaces, ","), os.Getenv(dataflowv1alpha1.EnvNamespace)))
+		// the multi-namespaced cache cannot get cluster-scoped objects
+		opts.ClientDisableCacheFor = []client.Object{&corev1.Namespace{}, &dataflowv1alpha1.ClusterConnection{}}
+	}
+	setupLog.Info("watching namespaces", "namespaces", namespaces, "namespaceSelector", namespaceSelector)
+	mgr, err := ctrl.NewManager(restConfig, opts)
 	if err != nil {
 		panic(fmt.Errorf("unable to start manager: %w", err))
 	}
 
+	metrics.Registry.MustRegister(controllers.NewMetricsCollector(mgr.GetClient()))
+
+	var ns *controllers.Namespaces
+	if namespaces != "" {
+		var namespaceReader client.Reader = mgr.GetClient()
+		if opts.NewCache != nil {
+			// the multi-namespaced cache cannot get namespaces, so they have their own cache, rather than every reconcile
+			// getting its namespace from the API
+			namespaceCache, err := cache.New(restConfig, cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+			if err != nil {
+				panic(fmt.Errorf("unable to create namespace cache: %w", err))
+			}
+			// start the informer with the cache, rather than on the first get
+			if _, err := namespaceCache.GetInformer(context.Background(), &corev1.Namespace{}); err != nil {
+				panic(fmt.Errorf("unable to create namespace informer: %w", err))
+			}
+			if err := mgr.Add(namespaceCache); err != nil {
+				panic(fmt.Errorf("unable to add namespace cache: %w", err))
+			}
+			namespaceReader = namespaceCache
+		}
+		ns = &controllers.Namespaces{Reader: namespaceReader, Selector: selector, MaxConcurrentReconciles: maxConcurrentReconciles}
+	}
+
 	clientset := kubernetes.NewForConfigOrDie(restConfig)
 	dynamicInterface := dynamic.NewForConfigOrDie(restConfig)
 	containerKiller := containerkiller.New(clientset, restConfig)
 	if err = (&controllers.PipelineReconciler{
-		Client:          mgr.GetClient(),
-		Log:             ctrl.Log.WithName("controllers").WithName("Pipeline"),
-		Scheme:          mgr.GetScheme(),
-		ContainerKiller: containerKiller,
+		Client:              mgr.GetClient(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("Pipeline"),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("pipeline-reconciler"),
+		ContainerKiller:     containerKiller,
+		KubernetesInterface: clientset,
+		Namespaces:          ns,
+	}).SetupWithManager(mgr); err != nil {
+		panic(fmt.Errorf("unable to create controller manager: %w", err))
+	}
+
+	if err = (&controllers.ConfigMapReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ConfigMap"),
+	}).SetupWithManager(mgr); err != nil {
+		panic(fmt.Errorf("unable to create controller manager: %w", err))
+	}
+
+	if err = (&controllers.CronPipelineReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("CronPipeline"),
+		Recorder:   mgr.GetEventRecorderFor("cron-pipeline-reconciler"),
+		Namespaces: ns,
+	}).SetupWithManager(mgr); err != nil {
+		panic(fmt.Errorf("unable to create controller manager: %w", err))
+	}
+
+	if err = (&controllers.ConnectionReconciler{
+		Client:              mgr.GetClient(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("Connection"),
+		KubernetesInterface: clientset,
+		Namespaces:          ns,
 	}).SetupWithManager(mgr); err != nil {
 		panic(fmt.Errorf("unable to create controller manager: %w", err))
 	}
 
 	if err = (&controllers.StepReconciler{
-		Client:           mgr.GetClient(),
-		Log:              ctrl.Log.WithName("controllers").WithName("Step"),
-		Scheme:           mgr.GetScheme(),
-		Recorder:         mgr.GetEventRecorderFor("step-reconciler"),
-		ContainerKiller:  containerKiller,
-		DynamicInterface: dynamicInterface,
+		Client:              mgr.GetClient(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("Step"),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("step-reconciler"),
+		ContainerKiller:     containerKiller,
+		DynamicInterface:    dynamicInterface,
+		KubernetesInterface: clientset,
+		Activator:           activatorAddr != "",
+		Namespaces:          ns,
 	}).SetupWithManager(mgr); err != nil {
 		panic(fmt.Errorf("unable to create controller manager: %w", err))
 	}
 	// +kubebuilder:scaffold:builder
 
+	if activatorAddr != "" {
+		if err := mgr.Add(activator.New(mgr.GetClient(), activatorAddr, os.Getenv(dataflowv1alpha1.EnvNamespace), 100, 2*time.Minute)); err != nil {
+			panic(fmt.Errorf("unable to add activator: %w", err))
+		}
+	}
+
+	if externalMetricsAddr != "" {
+		if err := mgr.Add(&externalmetrics.Server{Reader: mgr.GetClient(), KubernetesInterface: clientset, Addr: externalMetricsAddr, CertDir: externalMetricsCertDir}); err != nil {
+			panic(fmt.Errorf("unable to add external metrics server: %w", err))
+		}
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		panic(fmt.Errorf("problem running manager: %w", err))
@@ -47,6 +47,8 @@ func connectIn(ctx context.Context, sink func([]byte) error) (func(context.Conte
 		return func(ctx context.Context, data []byte) error {
 			inFlight.Inc()
 			defer inFlight.Dec()
+			mainWatchdog.messageStarted(time.Now())
+			defer func() { mainWatchdog.messageDone(time.Now()) }()
 			if _, err := fifo.Write(data); err != nil {
 				return fmt.Errorf("failed to send to main: %w", err)
 			}
@@ -70,6 +72,8 @@ func connectIn(ctx context.Context, sink func([]byte) error) (func(context.Conte
 		return func(ctx context.Context, data []byte) error {
 			inFlight.Inc()
 			defer inFlight.Dec()
+			mainWatchdog.messageStarted(time.Now())
+			defer func() { mainWatchdog.messageDone(time.Now()) }()
 			start := time.Now()
 			defer func() { messageTimeSeconds.Observe(time.Since(start).Seconds()) }()
 			if resp, err := httpClient.Post("http://localhost:8080/messages", "application/octet-stream", bytes.NewBuffer(data)); err != nil {
@@ -98,7 +102,7 @@ func waitReady(ctx context.Context) error {
 			return fmt.Errorf("failed to wait for ready: %w", ctx.Err())
 		default:
 			logger.Info("waiting for HTTP in interface to be ready")
-			if resp, err := http.Get("http://localhost:8080/ready"); err == nil && resp.StatusCode < 300 {
+			if mainReady() {
 				logger.Info("HTTP in interface ready")
 				return nil
 			}
@@ -114,7 +118,7 @@ func waitUnready(ctx context.Context) error {
 			return fmt.Errorf("failed to wait for un-ready: %w", ctx.Err())
 		default:
 			logger.Info("waiting for HTTP in interface to be unready")
-			if resp, err := http.Get("http://localhost:8080/ready"); err != nil || resp.StatusCode >= 300 {
+			if !mainReady() {
 				logger.Info("HTTP in interface unready")
 				return nil
 			}
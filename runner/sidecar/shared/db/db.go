@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// GetDataSource returns the data source, reading it from the secret if needed
+func GetDataSource(ctx context.Context, secretInterface corev1.SecretInterface, x dfv1.Database) (string, error) {
+	if x.DataSource == nil {
+		return "", fmt.Errorf("data source not specified")
+	}
+	if x.DataSource.Value != "" {
+		return x.DataSource.Value, nil
+	}
+	if x.DataSource.ValueFrom != nil && x.DataSource.ValueFrom.SecretKeyRef != nil {
+		secret, err := secretInterface.Get(ctx, x.DataSource.ValueFrom.SecretKeyRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get secret %q: %w", x.DataSource.ValueFrom.SecretKeyRef.Name, err)
+		}
+		if d, ok := secret.Data[x.DataSource.ValueFrom.SecretKeyRef.Key]; !ok {
+			return "", fmt.Errorf("can not find key %q in secret %q", x.DataSource.ValueFrom.SecretKeyRef.Key, x.DataSource.ValueFrom.SecretKeyRef.Name)
+		} else {
+			return string(d), nil
+		}
+	}
+	return "", fmt.Errorf("invalid data source config")
+}
@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// DeleteConsumerGroup deletes the consumer group, it is not an error if the group does not exist
+func DeleteConsumerGroup(ctx context.Context, secretInterface corev1.SecretInterface, k dfv1.Kafka, groupID string) error {
+	config, err := GetConfig(ctx, secretInterface, k.KafkaConfig)
+	if err != nil {
+		return err
+	}
+	adminClient, err := sarama.NewClusterAdmin(k.Brokers, config)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka admin client: %w", err)
+	}
+	defer func() { _ = adminClient.Close() }()
+	if err := adminClient.DeleteConsumerGroup(groupID); err != nil && !errors.Is(err, sarama.ErrGroupIDNotFound) {
+		return fmt.Errorf("failed to delete consumer group %q: %w", groupID, err)
+	}
+	return nil
+}
+
+// GetEndOffsets returns the offset of the next message to be written to each of the topic's partitions
+func GetEndOffsets(ctx context.Context, secretInterface corev1.SecretInterface, k dfv1.Kafka) (map[int32]int64, error) {
+	config, err := GetConfig(ctx, secretInterface, k.KafkaConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := sarama.NewClient(k.Brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	return GetOffsets(client, k.Topic, sarama.OffsetNewest)
+}
+
+// GetOffsets returns the offset of each of the topic's partitions at the time, e.g. `sarama.OffsetNewest`
+func GetOffsets(client sarama.Client, topic string, time int64) (map[int32]int64, error) {
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions: %w", err)
+	}
+	offsets := map[int32]int64{}
+	for _, p := range partitions {
+		if offsets[p], err = client.GetOffset(topic, p, time); err != nil {
+			return nil, fmt.Errorf("failed to get offset of partition %d: %w", p, err)
+		}
+	}
+	return offsets, nil
+}
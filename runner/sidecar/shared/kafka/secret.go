@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// FromSecret fills in any unset fields of the Kafka config from the secret
+func FromSecret(k *dfv1.Kafka, secret *corev1.Secret) error {
+	k.Brokers = dfv1.StringsOr(k.Brokers, strings.Split(string(secret.Data["brokers"]), ","))
+	k.Version = dfv1.StringOr(k.Version, string(secret.Data["version"]))
+
+	tls := tlsFromSecret(secret)
+	sasl := saslFromSecret(secret)
+	if tls == nil && sasl == nil {
+		return nil
+	}
+	if k.NET == nil {
+		k.NET = &dfv1.KafkaNET{}
+	}
+	if k.NET.TLS == nil {
+		k.NET.TLS = tls
+	}
+	if k.NET.SASL == nil {
+		k.NET.SASL = sasl
+	}
+	return nil
+}
+
+func tlsFromSecret(secret *corev1.Secret) *dfv1.TLS {
+	caCertExisting, certExisting, keyExisting := false, false, false
+	_, netTLS := secret.Data["net.tls"]
+	if _, ok := secret.Data["net.tls.caCert"]; ok {
+		caCertExisting = true
+	}
+	if _, ok := secret.Data["net.tls.cert"]; ok {
+		certExisting = true
+	}
+	if _, ok := secret.Data["net.tls.key"]; ok {
+		keyExisting = true
+	}
+
+	if !(netTLS || caCertExisting || certExisting || keyExisting) {
+		return nil
+	}
+
+	t := &dfv1.TLS{}
+	if caCertExisting {
+		t.CACertSecret = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: secret.Name,
+			},
+			Key: "net.tls.caCert",
+		}
+	}
+	if certExisting && keyExisting {
+		t.CertSecret = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: secret.Name,
+			},
+			Key: "net.tls.cert",
+		}
+		t.KeySecret = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: secret.Name,
+			},
+			Key: "net.tls.key",
+		}
+	}
+	return t
+}
+
+func saslFromSecret(secret *corev1.Secret) *dfv1.SASL {
+	userExisting, passwordExisting := false, false
+	if _, ok := secret.Data["net.sasl.user"]; ok {
+		userExisting = true
+	}
+	if _, ok := secret.Data["net.sasl.password"]; ok {
+		passwordExisting = true
+	}
+	if !userExisting || !passwordExisting {
+		return nil
+	}
+	sasl := &dfv1.SASL{
+		UserSecret: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: secret.Name,
+			},
+			Key: "net.sasl.user",
+		},
+		PasswordSecret: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: secret.Name,
+			},
+			Key: "net.sasl.password",
+		},
+	}
+	if d, ok := secret.Data["net.sasl.mechanism"]; ok {
+		sasl.Mechanism = dfv1.SASLMechanism(d)
+	}
+	return sasl
+}
+
+// Enrich fills in the Kafka config from the `dataflow-kafka-${name}` secret, if it exists
+func Enrich(ctx context.Context, secretInterface corev1client.SecretInterface, x *dfv1.Kafka) error {
+	secret, err := secretInterface.Get(ctx, "dataflow-kafka-"+x.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierr.IsNotFound(err) {
+			return err
+		}
+	} else if err := FromSecret(x, secret); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,4 +1,4 @@
-package sidecar
+package kafka
 
 import (
 	"context"
@@ -11,14 +11,14 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 )
 
-func Test_kafkaFromSecret(t *testing.T) {
+func TestFromSecret(t *testing.T) {
 	t.Run("Empty", func(t *testing.T) {
-		err := kafkaFromSecret(&dfv1.Kafka{}, &corev1.Secret{})
+		err := FromSecret(&dfv1.Kafka{}, &corev1.Secret{})
 		assert.NoError(t, err)
 	})
 	t.Run("Brokers", func(t *testing.T) {
 		x := &dfv1.Kafka{}
-		err := kafkaFromSecret(x, &corev1.Secret{
+		err := FromSecret(x, &corev1.Secret{
 			Data: map[string][]byte{
 				"brokers": []byte("a,b"),
 			},
@@ -28,7 +28,7 @@ func Test_kafkaFromSecret(t *testing.T) {
 	})
 	t.Run("Version", func(t *testing.T) {
 		x := &dfv1.Kafka{}
-		err := kafkaFromSecret(x, &corev1.Secret{
+		err := FromSecret(x, &corev1.Secret{
 			Data: map[string][]byte{
 				"version": []byte("v1.2.3"),
 			},
@@ -38,7 +38,7 @@ func Test_kafkaFromSecret(t *testing.T) {
 	})
 	t.Run("NetTLS", func(t *testing.T) {
 		x := &dfv1.Kafka{}
-		err := kafkaFromSecret(x, &corev1.Secret{
+		err := FromSecret(x, &corev1.Secret{
 			Data: map[string][]byte{
 				"net.tls.caCert": []byte(""),
 			},
@@ -50,7 +50,7 @@ func Test_kafkaFromSecret(t *testing.T) {
 	})
 	t.Run("NetSASL", func(t *testing.T) {
 		x := &dfv1.Kafka{}
-		err := kafkaFromSecret(x, &corev1.Secret{
+		err := FromSecret(x, &corev1.Secret{
 			Data: map[string][]byte{
 				"net.sasl.user":     []byte(""),
 				"net.sasl.password": []byte(""),
@@ -61,14 +61,39 @@ func Test_kafkaFromSecret(t *testing.T) {
 			assert.NotNil(t, x.NET.SASL)
 		}
 	})
+	t.Run("NetTLSAndSASL", func(t *testing.T) {
+		x := &dfv1.Kafka{}
+		err := FromSecret(x, &corev1.Secret{
+			Data: map[string][]byte{
+				"net.tls":           []byte(""),
+				"net.sasl.user":     []byte(""),
+				"net.sasl.password": []byte(""),
+			},
+		})
+		assert.NoError(t, err)
+		if assert.NotNil(t, x.NET) {
+			assert.NotNil(t, x.NET.TLS)
+			assert.NotNil(t, x.NET.SASL)
+		}
+	})
+	t.Run("NetUnchanged", func(t *testing.T) {
+		tls := &dfv1.TLS{}
+		x := &dfv1.Kafka{KafkaConfig: dfv1.KafkaConfig{NET: &dfv1.KafkaNET{TLS: tls}}}
+		err := FromSecret(x, &corev1.Secret{
+			Data: map[string][]byte{
+				"net.tls.caCert": []byte(""),
+			},
+		})
+		assert.NoError(t, err)
+		assert.Same(t, tls, x.NET.TLS)
+	})
 }
 
-func Test_enrichKafka(t *testing.T) {
+func TestEnrich(t *testing.T) {
 	t.Run("NotFound", func(t *testing.T) {
 		k := fake.NewSimpleClientset()
-		secretInterface = k.CoreV1().Secrets("")
 		x := &dfv1.Kafka{}
-		err := enrichKafka(context.Background(), x)
+		err := Enrich(context.Background(), k.CoreV1().Secrets(""), x)
 		assert.NoError(t, err)
 	})
 	t.Run("Found", func(t *testing.T) {
@@ -80,9 +105,8 @@ func Test_enrichKafka(t *testing.T) {
 				"commitN": []byte("123"),
 			},
 		})
-		secretInterface = k.CoreV1().Secrets("")
 		x := &dfv1.Kafka{Name: "foo"}
-		err := enrichKafka(context.Background(), x)
+		err := Enrich(context.Background(), k.CoreV1().Secrets(""), x)
 		assert.NoError(t, err)
 	})
 }
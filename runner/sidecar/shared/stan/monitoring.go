@@ -0,0 +1,63 @@
+package stan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Channel is the state of a channel, from the `/streaming/channelsz` monitoring endpoint
+type Channel struct {
+	LastSeq       uint64         `json:"last_seq"`
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+type Subscription struct {
+	QueueName string `json:"queue_name"`
+	LastSent  uint64 `json:"last_sent"`
+	// PendingCount is the number of messages sent to the subscriber that it has not acknowledged
+	PendingCount uint64 `json:"pending_count"`
+}
+
+var httpClient = http.Client{Timeout: 3 * time.Second}
+
+// GetChannel returns the state of the channel, including its subscriptions
+func GetChannel(ctx context.Context, natsMonitoringURL, channel string) (*Channel, error) {
+	monitoringEndpoint := fmt.Sprintf("%s/streaming/channelsz?channel=%s&subs=1", natsMonitoringURL, url.QueryEscape(channel))
+	req, err := http.NewRequestWithContext(ctx, "GET", monitoringEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("invalid response: %s", resp.Status)
+	}
+	x := &Channel{}
+	if err := json.NewDecoder(resp.Body).Decode(x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// GetQueue returns the last message sent to the members of the queue, and how many messages they have not acknowledged
+func (in Channel) GetQueue(queueName string) (lastSent, pending uint64) {
+	// the queue name of a durable queue subscription is {durableName}:{queueGroup}
+	queueNameCombo := queueName + ":" + queueName
+	for _, s := range in.Subscriptions {
+		if s.QueueName != queueNameCombo {
+			continue
+		}
+		if s.LastSent > lastSent {
+			lastSent = s.LastSent
+		}
+		pending += s.PendingCount
+	}
+	return lastSent, pending
+}
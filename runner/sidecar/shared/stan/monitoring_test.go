@@ -0,0 +1,29 @@
+package stan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/streaming/channelsz", r.URL.Path)
+		assert.Equal(t, "my-ns.my-pl.a-b", r.URL.Query().Get("channel"))
+		_, _ = w.Write([]byte(`{"last_seq": 10, "subscriptions": [
+	{"queue_name": "my-queue:my-queue", "last_sent": 8, "pending_count": 2},
+	{"queue_name": "my-queue:my-queue", "last_sent": 9, "pending_count": 1},
+	{"queue_name": "other:other", "last_sent": 10, "pending_count": 0}
+]}`))
+	}))
+	defer server.Close()
+	channel, err := GetChannel(context.Background(), server.URL, "my-ns.my-pl.a-b")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), channel.LastSeq)
+	lastSent, pending := channel.GetQueue("my-queue")
+	assert.Equal(t, uint64(9), lastSent)
+	assert.Equal(t, uint64(3), pending)
+}
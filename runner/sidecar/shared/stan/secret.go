@@ -1,4 +1,4 @@
-package sidecar
+package stan
 
 import (
 	"context"
@@ -9,9 +9,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
-func subjectiveStan(x *dfv1.STAN) {
+func subjectiveStan(x *dfv1.STAN, namespace, pipelineName string) {
 	switch x.SubjectPrefix {
 	case dfv1.SubjectPrefixNamespaceName:
 		x.Subject = fmt.Sprintf("%s.%s", namespace, x.Subject)
@@ -20,7 +21,8 @@ func subjectiveStan(x *dfv1.STAN) {
 	}
 }
 
-func stanFromSecret(s *dfv1.STAN, secret *corev1.Secret) error {
+// FromSecret fills in any unset fields of the STAN config from the secret
+func FromSecret(s *dfv1.STAN, secret *corev1.Secret) error {
 	s.NATSURL = dfv1.StringOr(s.NATSURL, string(secret.Data["natsUrl"]))
 	s.NATSMonitoringURL = dfv1.StringOr(s.NATSMonitoringURL, string(secret.Data["natsMonitoringUrl"]))
 	s.ClusterID = dfv1.StringOr(s.ClusterID, string(secret.Data["clusterId"]))
@@ -45,17 +47,18 @@ func stanFromSecret(s *dfv1.STAN, secret *corev1.Secret) error {
 	return nil
 }
 
-func enrichSTAN(ctx context.Context, x *dfv1.STAN) error {
+// Enrich fills in the STAN config from the `dataflow-stan-${name}` secret, if it exists, and then prefixes the subject
+func Enrich(ctx context.Context, secretInterface corev1client.SecretInterface, x *dfv1.STAN, namespace, pipelineName string) error {
 	secret, err := secretInterface.Get(ctx, "dataflow-stan-"+x.Name, metav1.GetOptions{})
 	if err != nil {
 		if !apierr.IsNotFound(err) {
 			return err
 		}
 	} else {
-		if err = stanFromSecret(x, secret); err != nil {
+		if err = FromSecret(x, secret); err != nil {
 			return err
 		}
 	}
-	subjectiveStan(x)
+	subjectiveStan(x, namespace, pipelineName)
 	return nil
 }
@@ -3,6 +3,7 @@ package stan
 import (
 	"context"
 	"fmt"
+	"time"
 
 	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
 	"github.com/argoproj-labs/argo-dataflow/shared/util"
@@ -78,6 +79,13 @@ func ConnectSTAN(ctx context.Context, secretInterface corev1.SecretInterface, x
 	default:
 	}
 	logger.Info("nats auth strategy: " + string(x.AuthStrategy()))
+	stanOpts := []stan.Option{stan.Pings(5, 60)}
+	if deadline, ok := ctx.Deadline(); ok {
+		// give up by the deadline, e.g. when sending a notification
+		d := time.Until(deadline)
+		opts = append(opts, nats.Timeout(d))
+		stanOpts = append(stanOpts, stan.ConnectWait(d), stan.PubAckWait(d))
+	}
 	nc, err := nats.Connect(x.NATSURL, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to nats url=%s subject=%s: %w", x.NATSURL, x.Subject, err)
@@ -86,7 +94,7 @@ func ConnectSTAN(ctx context.Context, secretInterface corev1.SecretInterface, x
 	conn.nc = nc
 	conn.natsConnected = true
 
-	sc, err := stan.Connect(x.ClusterID, clientID, stan.NatsConn(nc), stan.Pings(5, 60),
+	sc, err := stan.Connect(x.ClusterID, clientID, append(stanOpts, stan.NatsConn(nc),
 		stan.SetConnectionLostHandler(func(_ stan.Conn, reason error) {
 			conn.stanConnected = false
 			if reason != nil {
@@ -94,7 +102,7 @@ func ConnectSTAN(ctx context.Context, secretInterface corev1.SecretInterface, x
 			} else {
 				logger.Info("stan disconnected", "clientID", clientID)
 			}
-		}))
+		}))...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to stan url=%s clusterID=%s clientID=%s subject=%s: %w", x.NATSURL, x.ClusterID, clientID, x.Subject, err)
 	}
@@ -120,3 +128,21 @@ func getSTANAuthToken(ctx context.Context, secretInterface corev1.SecretInterfac
 	}
 	return "", fmt.Errorf("key %s not found in secret %s", x.Auth.Token.Key, x.Auth.Token.Name)
 }
+
+// DeleteDurable deletes the durable queue subscription by re-joining the queue and then unsubscribing. This must only
+// be done once all other members of the queue have closed their subscriptions.
+func DeleteDurable(ctx context.Context, secretInterface corev1.SecretInterface, x dfv1.STAN, clientID, queueName string) error {
+	conn, err := ConnectSTAN(ctx, secretInterface, x, clientID)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	sub, err := conn.QueueSubscribe(x.Subject, queueName, func(*stan.Msg) {}, stan.DurableName(queueName), stan.SetManualAckMode())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to queue %q: %w", queueName, err)
+	}
+	if err := sub.Unsubscribe(); err != nil {
+		return fmt.Errorf("failed to unsubscribe from queue %q: %w", queueName, err)
+	}
+	return nil
+}
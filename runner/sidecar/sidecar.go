@@ -2,6 +2,7 @@ package sidecar
 
 import (
 	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"fmt"
 	"net/http"
@@ -13,6 +14,8 @@ import (
 	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
 
 	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	sharedkafka "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/kafka"
+	sharedstan "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/stan"
 	sharedutil "github.com/argoproj-labs/argo-dataflow/shared/util"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -35,6 +38,7 @@ var (
 	patchMu             = sync.Mutex{}
 	pipelineName        = os.Getenv(dfv1.EnvPipelineName)
 	ready               = false // we are ready to serve HTTP requests, also updates pod status condition
+	aggregateStepStatus = os.Getenv(dfv1.EnvAggregateStepStatus) == "true"
 	dynamicInterface    dynamic.Interface
 	lastStep            dfv1.Step
 	kubernetesInterface kubernetes.Interface
@@ -73,6 +77,9 @@ func Exec(ctx context.Context) error {
 	if step.Status.SinkStatues == nil {
 		step.Status.SinkStatues = dfv1.SourceStatuses{}
 	}
+	if step.Status.Watchdog == nil {
+		step.Status.Watchdog = dfv1.WatchdogStatuses{}
+	}
 	lastStep = *step.DeepCopy()
 
 	if v, err := strconv.Atoi(os.Getenv(dfv1.EnvReplica)); err != nil {
@@ -92,11 +99,11 @@ func Exec(ctx context.Context) error {
 
 	logger.Info("generating self-signed certificate")
 	const certFile, keyFile = "/tmp/runner.crt", "/tmp/runner.key"
-	if err := generateCert(certFile, keyFile); err != nil {
+	if err := sharedutil.GenerateCert(certFile, keyFile); err != nil {
 		return fmt.Errorf("failed to generate cert: %w", err)
 	}
 
-	logger.Info("sidecar config", "stepName", stepName, "pipelineName", pipelineName, "replica", replica, "updateInterval", updateInterval.String())
+	logger.Info("sidecar config", "stepName", stepName, "pipelineName", pipelineName, "replica", replica, "updateInterval", updateInterval.String(), "aggregateStepStatus", aggregateStepStatus)
 
 	defer logger.Info("done")
 	defer stop()
@@ -174,6 +181,53 @@ func Exec(ctx context.Context) error {
 		logger.Info("HTTPS server shutdown")
 	}()
 
+	if aggregateStepStatus {
+		// the status of this replica, used by the controller to update the step's status, this is served on its own port,
+		// rather than 3570, which services, ingresses and HTTP routes expose, so network policies can limit it to the
+		// controller, which must also send the authorization header it added to the step's secret before creating the pods
+		secret, err := secretInterface.Get(ctx, step.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get secret %q: %w", step.Name, err)
+		}
+		authorization := secret.Data[dfv1.KeyStatusAuthorization]
+		if len(authorization) == 0 {
+			return fmt.Errorf("secret %q does not have %q", step.Name, dfv1.KeyStatusAuthorization)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), authorization) != 1 {
+				w.WriteHeader(403)
+				return
+			}
+			var data []byte
+			withLock(func() { data = []byte(sharedutil.MustJSON(step.Status)) })
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(data)
+		})
+		statusServer := &http.Server{Addr: ":3571", Handler: mux, TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+		addStopHook(func(ctx context.Context) error {
+			logger.Info("closing status server")
+			return statusServer.Shutdown(context.Background())
+		})
+		go func() {
+			defer runtimeutil.HandleCrash()
+			logger.Info("starting status server")
+			if err := statusServer.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
+				logger.Error(err, "failed to listen-and-server on HTTPS")
+			}
+			logger.Info("status server shutdown")
+		}()
+	}
+
+	if x := step.Spec.Watchdog; x != nil {
+		timeout := x.GetTimeout()
+		in := step.Spec.GetIn()
+		checkReady := in != nil && in.HTTP != nil // FIFO interfaces have no `/ready` endpoint
+		logger.Info("starting watchdog", "timeout", timeout.String(), "checkReady", checkReady)
+		addPreStopHook(stopWatchdogHook)
+		go wait.JitterUntil(func() { defer runtimeutil.HandleCrash(); checkWatchdog(timeout, checkReady) }, timeout/5, 1.2, true, ctx.Done())
+	}
+
 	toMain, err := connectIn(ctx, toSinks)
 	if err != nil {
 		return err
@@ -209,6 +263,9 @@ func patchStepStatus() {
 			}
 		}
 	}
+	if aggregateStepStatus { // the controller gets the status from the `/status` endpoint
+		return
+	}
 	if notEqual, patch := sharedutil.NotEqual(dfv1.Step{Status: lastStep.Status}, dfv1.Step{Status: step.Status}); notEqual {
 		logger.Info("patching step status", "patch", patch)
 		if un, err := dynamicInterface.
@@ -238,25 +295,14 @@ func patchStepStatus() {
 					if v.Status.SinkStatues == nil {
 						v.Status.SinkStatues = dfv1.SourceStatuses{}
 					}
+					if v.Status.Watchdog == nil {
+						v.Status.Watchdog = dfv1.WatchdogStatuses{}
+					}
 					// the step with change while this goroutine is running, so we must copy the data for this
 					// replica back to the status
-					r := strconv.Itoa(replica)
-					for name, s := range step.Status.SourceStatuses {
-						if v.Status.SourceStatuses[name].Metrics == nil {
-							x := v.Status.SourceStatuses[name]
-							x.Metrics = map[string]dfv1.Metrics{}
-							v.Status.SourceStatuses[name] = x
-						}
-						v.Status.SourceStatuses[name].Metrics[r] = s.Metrics[r]
-					}
-					for name, s := range step.Status.SinkStatues {
-						if v.Status.SinkStatues[name].Metrics == nil {
-							x := v.Status.SinkStatues[name]
-							x.Metrics = map[string]dfv1.Metrics{}
-							v.Status.SinkStatues[name] = x
-						}
-						v.Status.SinkStatues[name].Metrics[r] = s.Metrics[r]
-					}
+					v.Status.SourceStatuses.MergeReplica(replica, step.Status.SourceStatuses)
+					v.Status.SinkStatues.MergeReplica(replica, step.Status.SinkStatues)
+					v.Status.Watchdog.MergeReplica(replica, step.Status.Watchdog)
 					step = v
 				})
 			}
@@ -274,12 +320,12 @@ func enrichSpec(ctx context.Context) error {
 func enrichSources(ctx context.Context) error {
 	for i, source := range step.Spec.Sources {
 		if x := source.STAN; x != nil {
-			if err := enrichSTAN(ctx, x); err != nil {
+			if err := sharedstan.Enrich(ctx, secretInterface, x, namespace, pipelineName); err != nil {
 				return err
 			}
 			source.STAN = x
 		} else if x := source.Kafka; x != nil {
-			if err := enrichKafka(ctx, &x.Kafka); err != nil {
+			if err := sharedkafka.Enrich(ctx, secretInterface, &x.Kafka); err != nil {
 				return err
 			}
 			source.Kafka = x
@@ -297,12 +343,12 @@ func enrichSources(ctx context.Context) error {
 func enrichSinks(ctx context.Context) error {
 	for i, sink := range step.Spec.Sinks {
 		if x := sink.STAN; x != nil {
-			if err := enrichSTAN(ctx, x); err != nil {
+			if err := sharedstan.Enrich(ctx, secretInterface, x, namespace, pipelineName); err != nil {
 				return err
 			}
 			sink.STAN = x
 		} else if x := sink.Kafka; x != nil {
-			if err := enrichKafka(ctx, x); err != nil {
+			if err := sharedkafka.Enrich(ctx, secretInterface, x); err != nil {
 				return err
 			}
 			sink.Kafka = x
@@ -11,11 +11,11 @@ import (
 	"github.com/antonmedv/expr"
 	"github.com/antonmedv/expr/vm"
 	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	shareddb "github.com/argoproj-labs/argo-dataflow/runner/sidecar/shared/db"
 	"github.com/argoproj-labs/argo-dataflow/runner/sidecar/sink"
 	"github.com/argoproj-labs/argo-dataflow/runner/util"
 	sharedutil "github.com/argoproj-labs/argo-dataflow/shared/util"
 	_ "github.com/go-sql-driver/mysql"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var logger = sharedutil.NewLogger()
@@ -27,7 +27,7 @@ type dbSink struct {
 }
 
 func New(ctx context.Context, secretInterface corev1.SecretInterface, x dfv1.DBSink) (sink.Interface, error) {
-	dataSource, err := getDataSource(ctx, secretInterface, x)
+	dataSource, err := shareddb.GetDataSource(ctx, secretInterface, x.Database)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find data source: %w", err)
 	}
@@ -123,24 +123,6 @@ func (d dbSink) execStatement(tx *sql.Tx, sql string, args []string, msg []byte)
 	return rs, nil
 }
 
-func getDataSource(ctx context.Context, secretInterface corev1.SecretInterface, x dfv1.DBSink) (string, error) {
-	if x.DataSource.Value != "" {
-		return x.DataSource.Value, nil
-	}
-	if x.DataSource.ValueFrom != nil && x.DataSource.ValueFrom.SecretKeyRef != nil {
-		secret, err := secretInterface.Get(ctx, x.DataSource.ValueFrom.SecretKeyRef.Name, metav1.GetOptions{})
-		if err != nil {
-			return "", fmt.Errorf("failed to get secret %q: %w", x.DataSource.ValueFrom.SecretKeyRef.Name, err)
-		}
-		if d, ok := secret.Data[x.DataSource.ValueFrom.SecretKeyRef.Key]; !ok {
-			return "", fmt.Errorf("can not find key %q in secret %q", x.DataSource.ValueFrom.SecretKeyRef.Key, x.DataSource.ValueFrom.SecretKeyRef.Name)
-		} else {
-			return string(d), nil
-		}
-	}
-	return "", fmt.Errorf("invalid data source config")
-}
-
 func (d dbSink) Close() error {
 	return d.db.Close()
 }
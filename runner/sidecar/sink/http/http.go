@@ -36,11 +36,16 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, x dfv1.HTT
 			header.Add(h.Name, string(secret.Data[r.Key]))
 		}
 	}
+	timeout := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		// give up by the deadline, e.g. when sending a notification
+		timeout = time.Until(deadline)
+	}
 	return httpSink{
 		url:    x.URL,
 		header: header,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: timeout,
 		},
 	}, nil
 }
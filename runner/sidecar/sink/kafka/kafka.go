@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"context"
+	"time"
 
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
@@ -22,6 +23,14 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, x dfv1.Kaf
 		return nil, err
 	}
 	config.Producer.Return.Successes = true
+	if deadline, ok := ctx.Deadline(); ok {
+		// give up by the deadline rather than retrying, e.g. when sending a notification
+		d := time.Until(deadline)
+		config.Net.DialTimeout, config.Net.ReadTimeout, config.Net.WriteTimeout = d, d, d
+		config.Metadata.Retry.Max = 0
+		config.Producer.Retry.Max = 0
+		config.Producer.Timeout = d
+	}
 	producer, err := sarama.NewSyncProducer(x.Brokers, config)
 	if err != nil {
 		return nil, err
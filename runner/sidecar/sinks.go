@@ -79,7 +79,10 @@ func connectSinks(ctx context.Context) (func([]byte) error, error) {
 				step.Status.SinkStatues.IncrTotal(sinkName, replica, rateToResourceQuantity(counter))
 			})
 			if err := f.Sink(msg); err != nil {
-				withLock(func() { step.Status.SinkStatues.IncrErrors(sinkName, replica) })
+				withLock(func() {
+					step.Status.SinkStatues.IncrErrors(sinkName, replica)
+					step.Status.SinkStatues.RecordError(sinkName, replica, err)
+				})
 				return err
 			}
 		}
@@ -9,6 +9,8 @@ import (
 type handler struct {
 	f source.Func
 	i int
+	// endOffsets, if not nil, are the offsets of each partition to read up to, because the source is bounded
+	endOffsets map[int32]int64
 }
 
 func (handler) Setup(_ sarama.ConsumerGroupSession) error {
@@ -24,14 +26,23 @@ func (handler) Cleanup(_ sarama.ConsumerGroupSession) error {
 func (h handler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	logger.Info("starting consuming claim", "partition", claim.Partition())
 	for msg := range claim.Messages() {
+		if h.endOffsets != nil && msg.Offset >= h.endOffsets[msg.Partition] { // written after the bounded source started
+			continue
+		}
 		if err := h.f(sess.Context(), msg.Value); err != nil {
 		} else {
 			sess.MarkMessage(msg, "")
 		}
 		h.i++
-		if h.i%dfv1.CommitN == 0 {
+		// a bounded source commits when it has caught up, so that the consumer group's offsets show it is done
+		if h.i%dfv1.CommitN == 0 || h.caughtUp(msg) {
 			sess.Commit()
 		}
 	}
 	return nil
 }
+
+// caughtUp returns true if the source is bounded, and the message is the last one to read from its partition
+func (h handler) caughtUp(msg *sarama.ConsumerMessage) bool {
+	return h.endOffsets != nil && msg.Offset+1 >= h.endOffsets[msg.Partition]
+}
@@ -0,0 +1,15 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_handler_caughtUp(t *testing.T) {
+	msg := &sarama.ConsumerMessage{Partition: 0, Offset: 9}
+	assert.False(t, handler{}.caughtUp(msg), "unbounded sources only commit every N messages")
+	assert.True(t, handler{endOffsets: map[int32]int64{0: 10}}.caughtUp(msg))
+	assert.False(t, handler{endOffsets: map[int32]int64{0: 11}}.caughtUp(msg))
+}
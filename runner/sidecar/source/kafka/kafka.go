@@ -24,9 +24,13 @@ type kafkaSource struct {
 	adminClient   sarama.ClusterAdmin
 	groupID       string
 	topic         string
+	initialOffset int64
+	endOffsets    map[int32]int64 // nil unless bounded
 }
 
-func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterName, namespace, pipelineName, stepName, sourceName string, x dfv1.KafkaSource, f source.Func) (source.Interface, error) {
+// New creates the source. If endOffsets is not nil, the source is bounded, and reads up to them. They are recorded once
+// for the step, rather than by each replica when it starts, so that restarts do not move them.
+func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterName, namespace, pipelineName, stepName, sourceName string, x dfv1.KafkaSource, endOffsets map[int32]int64, f source.Func) (source.Interface, error) {
 	config, err := kafka.GetConfig(ctx, secretInterface, x.Kafka.KafkaConfig)
 	if err != nil {
 		return nil, err
@@ -37,14 +41,24 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterNam
 	if x.StartOffset == "First" {
 		config.Consumer.Offsets.Initial = sarama.OffsetOldest
 	}
-	// This ID can be up to 255 characters in length, and can include the following characters: a-z, A-Z, 0-9, . (dot), _ (underscore), and - (dash).
-	groupID := sharedutil.MustHash(fmt.Sprintf("%s.%s.%s.%s.sources.%s", clusterName, namespace, pipelineName, stepName, sourceName))
+	client, err := sarama.NewClient(x.Brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	adminClient, err := sarama.NewClusterAdmin(x.Brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka admin client: %w", err)
+	}
+	if endOffsets != nil {
+		logger.Info("bounded Kafka source", "source", sourceName, "endOffsets", endOffsets)
+	}
+	groupID := source.GroupID(clusterName, namespace, pipelineName, stepName, sourceName)
 	logger.Info("Kafka consumer group ID", "groupID", groupID)
 	consumerGroup, err := sarama.NewConsumerGroup(x.Brokers, groupID, config)
 	if err != nil {
 		return nil, err
 	}
-	h := handler{f, 0}
+	h := handler{f, 0, endOffsets}
 	go wait.JitterUntil(func() {
 		defer runtime.HandleCrash()
 		ctx := context.Background()
@@ -59,20 +73,14 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterNam
 			}
 		}
 	}, 3*time.Second, 1.2, true, ctx.Done())
-	client, err := sarama.NewClient(x.Brokers, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
-	}
-	adminClient, err := sarama.NewClusterAdmin(x.Brokers, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka admin client: %w", err)
-	}
 	return kafkaSource{
 		client,
 		consumerGroup,
 		adminClient,
 		groupID,
 		x.Topic,
+		config.Consumer.Offsets.Initial,
+		endOffsets,
 	}, nil
 }
 
@@ -109,3 +117,45 @@ func (s kafkaSource) GetPending(context.Context) (uint64, error) {
 	}
 	return uint64(totalLags), nil
 }
+
+// IsExhausted returns true once the consumer group has committed the end offsets of every partition
+func (s kafkaSource) IsExhausted(context.Context) (bool, error) {
+	if s.endOffsets == nil {
+		return false, nil
+	}
+	var partitions []int32
+	for p := range s.endOffsets {
+		partitions = append(partitions, p)
+	}
+	rep, err := s.adminClient.ListConsumerGroupOffsets(s.groupID, map[string][]int32{s.topic: partitions})
+	if err != nil {
+		return false, fmt.Errorf("failed to list consumer group offsets: %w", err)
+	}
+	committedOffsets := map[int32]int64{}
+	for _, p := range partitions {
+		if block := rep.GetBlock(s.topic, p); block != nil {
+			committedOffsets[p] = block.Offset
+		}
+	}
+	// where the consumer group starts partitions it has not committed to
+	startOffsets := s.endOffsets
+	if s.initialOffset == sarama.OffsetOldest {
+		if startOffsets, err = kafka.GetOffsets(s.client, s.topic, sarama.OffsetOldest); err != nil {
+			return false, err
+		}
+	}
+	return isExhausted(s.endOffsets, committedOffsets, startOffsets), nil
+}
+
+func isExhausted(endOffsets, committedOffsets, startOffsets map[int32]int64) bool {
+	for p, end := range endOffsets {
+		offset, ok := committedOffsets[p]
+		if !ok || offset < 0 {
+			offset = startOffsets[p]
+		}
+		if offset < end {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,16 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isExhausted(t *testing.T) {
+	end := map[int32]int64{0: 10, 1: 5}
+	assert.False(t, isExhausted(end, map[int32]int64{0: 10, 1: 4}, end))
+	assert.True(t, isExhausted(end, map[int32]int64{0: 10, 1: 5}, end))
+	assert.True(t, isExhausted(end, map[int32]int64{0: 10, 1: -1}, end), "uncommitted partitions start at the end offset")
+	assert.False(t, isExhausted(end, map[int32]int64{0: 10}, map[int32]int64{0: 0, 1: 0}), "uncommitted partitions start at the oldest offset")
+	assert.True(t, isExhausted(end, nil, map[int32]int64{0: 10, 1: 5}), "empty partitions")
+}
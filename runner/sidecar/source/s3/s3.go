@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	sharedutil "github.com/argoproj-labs/argo-dataflow/shared/util"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
@@ -32,6 +34,9 @@ var logger = sharedutil.NewLogger()
 type s3Source struct {
 	httpSource source.Interface
 	jobs       workqueue.Interface
+	bounded    bool
+	listed     int32 // atomic, set to 1 once a bounded source has listed the bucket
+	remaining  int64 // atomic, the number of listed objects that a bounded source has yet to process
 }
 
 type message struct {
@@ -39,7 +44,7 @@ type message struct {
 	Path string `json:"path"`
 }
 
-func New(ctx context.Context, secretInterface corev1.SecretInterface, pipelineName, stepName, sourceName string, x dfv1.S3Source, f source.Func, leadReplica bool) (source.Interface, error) {
+func New(ctx context.Context, secretInterface corev1.SecretInterface, pipelineName, stepName, sourceName string, x dfv1.S3Source, endTime *metav1.Time, f source.Func, leadReplica bool) (source.Interface, error) {
 	var accessKeyID string
 	{
 		secretName := x.Credentials.AccessKeyID.Name
@@ -78,6 +83,8 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, pipelineNa
 	bucket := x.Bucket
 	jobs := workqueue.New()
 	authorization := sharedutil.RandString()
+	bounded := endTime != nil
+	s3s := &s3Source{jobs: jobs, bounded: bounded}
 	if leadReplica {
 		endpoint := "https://" + pipelineName + "-" + stepName + "/sources/" + sourceName
 		logger.Info("starting lead workers", "source", sourceName, "endpoint", endpoint)
@@ -105,20 +112,26 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, pipelineNa
 						}
 						req.Header.Set("Authorization", authorization)
 						resp, err := httpClient.Do(req)
-						if err != nil {
-							logger.Error(err, "failed to process object", "key", key)
-						} else {
+						if err == nil {
 							body, _ := io.ReadAll(resp.Body)
 							_ = resp.Body.Close()
 							if resp.StatusCode >= 300 {
-								err := fmt.Errorf("%q: %q", resp.Status, body)
-								logger.Error(err, "failed to process object", "bucket", bucket, "key", key)
-							} else {
-								logger.Info("deleting object", "bucket", bucket, "key", key)
-								_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &bucket, Key: &key})
-								if err != nil {
-									logger.Error(err, "failed to delete object", "bucket", bucket, "key", key)
-								}
+								err = fmt.Errorf("%q: %q", resp.Status, body)
+							}
+						}
+						if err != nil {
+							logger.Error(err, "failed to process object", "bucket", bucket, "key", key)
+							if bounded { // a bounded source does not list the bucket again, so we must retry
+								time.AfterFunc(x.PollPeriod.Duration, func() { jobs.Add(key) })
+							}
+						} else {
+							logger.Info("deleting object", "bucket", bucket, "key", key)
+							_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &bucket, Key: &key})
+							if err != nil {
+								logger.Error(err, "failed to delete object", "bucket", bucket, "key", key)
+							}
+							if bounded {
+								atomic.AddInt64(&s3s.remaining, -1)
 							}
 						}
 					}()
@@ -135,6 +148,22 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, pipelineNa
 				case <-ctx.Done():
 					return
 				case <-ticker.C:
+					if bounded {
+						// only process the objects last modified before the step started, so list every page once, processed
+						// objects are deleted, so a restarted replica lists just those it has yet to process
+						keys, err := listKeys(ctx, client, bucket, endTime.Time)
+						if err != nil {
+							logger.Error(err, "failed to list bucket", "bucket", bucket)
+							continue
+						}
+						logger.Info("bounded S3 source", "source", sourceName, "objects", len(keys))
+						atomic.StoreInt64(&s3s.remaining, int64(len(keys)))
+						atomic.StoreInt32(&s3s.listed, 1)
+						for _, key := range keys {
+							jobs.Add(key)
+						}
+						return
+					}
 					list, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: &bucket})
 					if err != nil {
 						logger.Error(err, "failed to list bucket", "bucket", bucket)
@@ -147,38 +176,67 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, pipelineNa
 			}
 		}()
 	}
-	return &s3Source{
-		httpsource.New(sourceName, authorization, func(ctx context.Context, msg []byte) error {
-			key := string(msg)
-			output, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key})
+	s3s.httpSource = httpsource.New(sourceName, authorization, func(ctx context.Context, msg []byte) error {
+		key := string(msg)
+		output, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key})
+		if err != nil {
+			return fmt.Errorf("failed to get object %q %q: %w", bucket, key, err)
+		}
+		defer output.Body.Close()
+		path := filepath.Join(dir, key)
+		if err := syscall.Mkfifo(path, 0o600); sharedutil.IgnoreExist(err) != nil {
+			return fmt.Errorf("failed to create fifo %q: %w", path, err)
+		}
+		defer os.Remove(path)
+		go func() {
+			defer runtime.HandleCrash()
+			logger.Info("opening file", "key", key)
+			file, err := os.OpenFile(path, os.O_WRONLY, os.ModeNamedPipe)
 			if err != nil {
-				return fmt.Errorf("failed to get object %q %q: %w", bucket, key, err)
+				logger.Error(err, "failed to open file", "path", path)
 			}
-			defer output.Body.Close()
-			path := filepath.Join(dir, key)
-			if err := syscall.Mkfifo(path, 0o600); sharedutil.IgnoreExist(err) != nil {
-				return fmt.Errorf("failed to create fifo %q: %w", path, err)
+			defer file.Close()
+			if _, err := io.Copy(file, output.Body); err != nil {
+				logger.Error(err, "failed to copy object to FIFO", "path", path)
 			}
-			defer os.Remove(path)
-			go func() {
-				defer runtime.HandleCrash()
-				logger.Info("opening file", "key", key)
-				file, err := os.OpenFile(path, os.O_WRONLY, os.ModeNamedPipe)
-				if err != nil {
-					logger.Error(err, "failed to open file", "path", path)
-				}
-				defer file.Close()
-				if _, err := io.Copy(file, output.Body); err != nil {
-					logger.Error(err, "failed to copy object to FIFO", "path", path)
-				}
-			}()
-			return f(ctx, []byte(sharedutil.MustJSON(message{Key: key, Path: path})))
-		}),
-		jobs,
-	}, nil
+		}()
+		return f(ctx, []byte(sharedutil.MustJSON(message{Key: key, Path: path})))
+	})
+	return s3s, nil
+}
+
+func listKeys(ctx context.Context, client *s3.Client, bucket string, end time.Time) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: &bucket})
+	for paginator.HasMorePages() {
+		list, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, keysBefore(list.Contents, end)...)
+	}
+	return keys, nil
+}
+
+// keysBefore returns the keys of the objects last modified no later than end
+func keysBefore(objects []types.Object, end time.Time) []string {
+	var keys []string
+	for _, obj := range objects {
+		if obj.LastModified != nil && obj.LastModified.After(end) {
+			continue
+		}
+		keys = append(keys, *obj.Key)
+	}
+	return keys
 }
 
 func (s *s3Source) Close() error {
 	s.jobs.ShutDown()
 	return s.httpSource.Close()
 }
+
+// IsExhausted returns true once a bounded source has processed every object that was in the bucket when the step started.
+// Only the lead replica lists the bucket, so only it knows this.
+func (s *s3Source) IsExhausted(context.Context) (bool, error) {
+	return s.bounded && atomic.LoadInt32(&s.listed) == 1 && atomic.LoadInt64(&s.remaining) <= 0, nil
+}
@@ -0,0 +1,30 @@
+package s3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_keysBefore(t *testing.T) {
+	end := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	object := func(key string, lastModified time.Time) types.Object {
+		return types.Object{Key: aws.String(key), LastModified: aws.Time(lastModified)}
+	}
+	assert.Empty(t, keysBefore(nil, end))
+	assert.Equal(t, []string{"a", "b"}, keysBefore([]types.Object{
+		object("a", end.Add(-time.Minute)),
+		object("b", end),
+		object("c", end.Add(time.Minute)),
+	}, end))
+	t.Run("Restart", func(t *testing.T) {
+		// "a" was processed and deleted before the replica restarted, and "c" was added after the step started
+		assert.Equal(t, []string{"b"}, keysBefore([]types.Object{
+			object("b", end.Add(-time.Minute)),
+			object("c", end.Add(time.Second)),
+		}, end))
+	})
+}
@@ -2,7 +2,10 @@ package source
 
 import (
 	"context"
+	"fmt"
 	"io"
+
+	sharedutil "github.com/argoproj-labs/argo-dataflow/shared/util"
 )
 
 type Interface interface {
@@ -14,3 +17,16 @@ type Func func(ctx context.Context, msg []byte) error
 type HasPending interface {
 	GetPending(ctx context.Context) (uint64, error)
 }
+
+// Exhaustible is implemented by sources that can be bounded
+type Exhaustible interface {
+	// IsExhausted returns true once the bounded source has processed all of its messages
+	IsExhausted(ctx context.Context) (bool, error)
+}
+
+// GroupID returns the ID of the source's Kafka consumer group or STAN durable queue. It is shared by every replica of
+// the step, and is used by the controller to delete them when the pipeline is deleted.
+func GroupID(clusterName, namespace, pipelineName, stepName, sourceName string) string {
+	// This ID can be up to 255 characters in length, and can include the following characters: a-z, A-Z, 0-9, . (dot), _ (underscore), and - (dash).
+	return sharedutil.MustHash(fmt.Sprintf("%s.%s.%s.%s.sources.%s", clusterName, namespace, pipelineName, stepName, sourceName))
+}
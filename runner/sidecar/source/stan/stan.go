@@ -2,10 +2,8 @@ package stan
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math/rand"
-	"net/http"
 	"time"
 
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -27,9 +25,12 @@ type stanSource struct {
 	subject           string
 	natsMonitoringURL string
 	queueName         string
+	endSequence       *uint64 // nil unless bounded
 }
 
-func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterName, namespace, pipelineName, stepName string, replica int, sourceName string, x dfv1.STAN, f source.Func) (source.Interface, error) {
+// New creates the source. If endSequence is not nil, the source is bounded, and reads up to that message. It is recorded
+// once for the step, rather than by each replica when it starts, so that restarts do not move it.
+func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterName, namespace, pipelineName, stepName string, replica int, sourceName string, x dfv1.STAN, endSequence *uint64, f source.Func) (source.Interface, error) {
 	genClientID := func() string {
 		// In a particular situation, the stan connection status is inconsistent between stan server and client,
 		// the connection is lost from client side, but the server still thinks it's alive. In this case, use
@@ -49,18 +50,28 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterNam
 
 	// https://docs.nats.io/developing-with-nats-streaming/queues
 	var sub stan.Subscription
-	queueName := sharedutil.MustHash(fmt.Sprintf("%s.%s.%s.%s.sources.%s", clusterName, namespace, pipelineName, stepName, sourceName))
+	queueName := source.GroupID(clusterName, namespace, pipelineName, stepName, sourceName)
+	// a bounded source reads the messages already in the channel, rather than only new ones
+	startAt := stan.StartAt(pb.StartPosition_NewOnly)
+	if endSequence != nil {
+		logger.Info("bounded STAN source", "source", sourceName, "endSequence", *endSequence)
+		startAt = stan.DeliverAllAvailable()
+	}
 	subFunc := func() (stan.Subscription, error) {
 		logger.Info("subscribing to STAN queue", "source", sourceName, "queueName", queueName)
 		sub, err := conn.QueueSubscribe(x.Subject, queueName, func(msg *stan.Msg) {
-			if err := f(context.Background(), msg.Data); err != nil {
-				// noop
-			} else if err := msg.Ack(); err != nil {
+			if endSequence != nil && msg.Sequence > *endSequence {
+				// published after the bounded source started, so we do not process it, but we must ack it, or it is
+				// redelivered and counted as unacknowledged
+			} else if err := f(context.Background(), msg.Data); err != nil {
+				return
+			}
+			if err := msg.Ack(); err != nil {
 				logger.Error(err, "failed to ack message", "source", sourceName)
 			}
 		}, stan.DurableName(queueName),
 			stan.SetManualAckMode(),
-			stan.StartAt(pb.StartPosition_NewOnly),
+			startAt,
 			stan.AckWait(30*time.Second),
 			stan.MaxInflight(x.GetMaxInflight()))
 		if err != nil {
@@ -110,6 +121,7 @@ func New(ctx context.Context, secretInterface corev1.SecretInterface, clusterNam
 		subject:           x.Subject,
 		natsMonitoringURL: x.NATSMonitoringURL,
 		queueName:         queueName,
+		endSequence:       endSequence,
 	}, nil
 }
 
@@ -123,62 +135,33 @@ func (s stanSource) Close() error {
 }
 
 func (s stanSource) GetPending(ctx context.Context) (uint64, error) {
-	httpClient := http.Client{
-		Timeout: time.Second * 3,
-	}
-
-	type obj = map[string]interface{}
-
-	pendingMessages := func(ctx context.Context, channel, queueNameCombo string) (int64, error) {
-		monitoringEndpoint := fmt.Sprintf("%s/streaming/channelsz?channel=%s&subs=1", s.natsMonitoringURL, channel)
-		req, err := http.NewRequestWithContext(ctx, "GET", monitoringEndpoint, nil)
-		if err != nil {
-			return 0, err
-		}
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			return 0, err
-		}
-		if resp.StatusCode != 200 {
-			return 0, fmt.Errorf("invalid response: %s", resp.Status)
-		}
-		defer func() { _ = resp.Body.Close() }()
-		o := make(obj)
-		if err := json.NewDecoder(resp.Body).Decode(&o); err != nil {
-			return 0, err
-		}
-		lastSeq, ok := o["last_seq"].(float64)
-		if !ok {
-			return 0, fmt.Errorf("unrecognized last_seq: %v", o["last_seq"])
-		}
-		subs, ok := o["subscriptions"]
-		if !ok {
-			return 0, fmt.Errorf("no suscriptions field found in the monitoring endpoint response")
-		}
-		maxLastSent := float64(0)
-		for _, i := range subs.([]interface{}) {
-			s := i.(obj)
-			if fmt.Sprintf("%v", s["queue_name"]) != queueNameCombo {
-				continue
-			}
-			lastSent, ok := s["last_sent"].(float64)
-			if !ok {
-				return 0, fmt.Errorf("unrecognized last_sent: %v", s["last_sent"])
-			}
-			if lastSent > maxLastSent {
-				maxLastSent = lastSent
-			}
-		}
-		return int64(lastSeq) - int64(maxLastSent), nil
-	}
-
-	// queueNameCombo := {durableName}:{queueGroup}
-	queueNameCombo := s.queueName + ":" + s.queueName
-	if pending, err := pendingMessages(ctx, s.subject, queueNameCombo); err != nil {
+	channel, err := sharedstan.GetChannel(ctx, s.natsMonitoringURL, s.subject)
+	if err != nil {
 		return 0, fmt.Errorf("failed to get STAN pending for: %w", err)
-	} else if pending >= 0 {
+	}
+	lastSent, _ := channel.GetQueue(s.queueName)
+	if channel.LastSeq > lastSent {
+		pending := channel.LastSeq - lastSent
 		logger.Info("setting STAN pending", "pending", pending)
-		return uint64(pending), nil
+		return pending, nil
 	}
 	return 0, nil
 }
+
+// IsExhausted returns true once a bounded source's queue has been sent every message up to the end sequence, and has
+// acknowledged all of them, so that none are still being processed
+func (s stanSource) IsExhausted(ctx context.Context) (bool, error) {
+	if s.endSequence == nil {
+		return false, nil
+	}
+	channel, err := sharedstan.GetChannel(ctx, s.natsMonitoringURL, s.subject)
+	if err != nil {
+		return false, fmt.Errorf("failed to get STAN channel: %w", err)
+	}
+	lastSent, unacknowledged := channel.GetQueue(s.queueName)
+	return isExhausted(*s.endSequence, lastSent, unacknowledged), nil
+}
+
+func isExhausted(endSequence, lastSent, unacknowledged uint64) bool {
+	return lastSent >= endSequence && unacknowledged == 0
+}
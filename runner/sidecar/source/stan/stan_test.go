@@ -0,0 +1,15 @@
+package stan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isExhausted(t *testing.T) {
+	assert.True(t, isExhausted(0, 0, 0), "the channel was empty when the step started")
+	assert.False(t, isExhausted(10, 0, 0), "nothing has been sent")
+	assert.False(t, isExhausted(10, 10, 2), "sent, but not acknowledged")
+	assert.True(t, isExhausted(10, 10, 0))
+	assert.True(t, isExhausted(10, 12, 0), "messages published later were acknowledged without being processed")
+}
@@ -56,13 +56,23 @@ func connectSources(ctx context.Context, toMain func(context.Context, []byte) er
 					}
 					logger.Error(err, "⚠ →", "source", sourceName, "backoffSteps", backoff.Steps)
 					if backoff.Steps <= 0 {
-						withLock(func() { step.Status.SourceStatuses.IncrErrors(sourceName, replica) })
+						withLock(func() {
+							step.Status.SourceStatuses.IncrErrors(sourceName, replica)
+							step.Status.SourceStatuses.RecordError(sourceName, replica, err)
+						})
 						return err
 					}
 					time.Sleep(backoff.Step())
 				}
 			}
 		}
+		// where a bounded source ends is recorded in the status by the controller before the pods are created
+		end := dfv1.SourceStatus{}
+		if s.Bounded && (s.Kafka != nil || s.S3 != nil || s.STAN != nil) {
+			if end = step.Status.SourceStatuses.Get(sourceName); !end.HasEnd() {
+				return fmt.Errorf("the end of bounded source %q has not been recorded", sourceName)
+			}
+		}
 		if x := s.Cron; x != nil {
 			if y, err := cron.New(*x, f); err != nil {
 				return err
@@ -70,13 +80,13 @@ func connectSources(ctx context.Context, toMain func(context.Context, []byte) er
 				sources[sourceName] = y
 			}
 		} else if x := s.STAN; x != nil {
-			if y, err := stan.New(ctx, secretInterface, clusterName, namespace, pipelineName, stepName, replica, sourceName, *x, f); err != nil {
+			if y, err := stan.New(ctx, secretInterface, clusterName, namespace, pipelineName, stepName, replica, sourceName, *x, end.EndSequence, f); err != nil {
 				return err
 			} else {
 				sources[sourceName] = y
 			}
 		} else if x := s.Kafka; x != nil {
-			if y, err := kafkasource.New(ctx, secretInterface, clusterName, namespace, pipelineName, stepName, sourceName, *x, f); err != nil {
+			if y, err := kafkasource.New(ctx, secretInterface, clusterName, namespace, pipelineName, stepName, sourceName, *x, end.EndOffsets, f); err != nil {
 				return err
 			} else {
 				sources[sourceName] = y
@@ -89,7 +99,7 @@ func connectSources(ctx context.Context, toMain func(context.Context, []byte) er
 			}
 			sources[sourceName] = httpsource.New(sourceName, string(secret.Data[fmt.Sprintf("sources.%s.http.authorization", sourceName)]), f)
 		} else if x := s.S3; x != nil {
-			if y, err := s3source.New(ctx, secretInterface, pipelineName, stepName, sourceName, *x, f, leadReplica()); err != nil {
+			if y, err := s3source.New(ctx, secretInterface, pipelineName, stepName, sourceName, *x, end.EndTime, f, leadReplica()); err != nil {
 				return err
 			} else {
 				sources[sourceName] = y
@@ -117,6 +127,18 @@ func connectSources(ctx context.Context, toMain func(context.Context, []byte) er
 				return nil
 			})
 		}
+		if x, ok := sources[sourceName].(source.Exhaustible); ok && s.Bounded && leadReplica() {
+			logger.Info("adding exhausted pre-patch hook", "source", sourceName)
+			prePatchHooks = append(prePatchHooks, func(ctx context.Context) error {
+				if exhausted, err := x.IsExhausted(ctx); err != nil {
+					return err
+				} else if exhausted {
+					logger.Info("source exhausted", "source", sourceName)
+					withLock(func() { step.Status.SourceStatuses.SetExhausted(sourceName) })
+				}
+				return nil
+			})
+		}
 	}
 	return nil
 }
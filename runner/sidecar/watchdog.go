@@ -0,0 +1,113 @@
+package sidecar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// we use a client with a timeout, as a hung main container may accept connections, but never respond
+var readyClient = &http.Client{Timeout: 3 * time.Second}
+
+var mainWatchdog = newWatchdog(time.Now())
+
+// watchdog detects a hung main container, i.e. one that is not ready, or that has messages in-flight but is not
+// completing any of them
+type watchdog struct {
+	mu           sync.Mutex
+	stopped      bool
+	inFlight     int
+	lastProgress time.Time // when a message last completed, or was started when none were in-flight
+	lastReady    time.Time
+}
+
+func newWatchdog(now time.Time) *watchdog {
+	return &watchdog{lastProgress: now, lastReady: now}
+}
+
+func (w *watchdog) messageStarted(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.inFlight == 0 {
+		w.lastProgress = now
+	}
+	w.inFlight++
+}
+
+func (w *watchdog) messageDone(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.inFlight--
+	w.lastProgress = now
+}
+
+func (w *watchdog) ready(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastReady = now
+}
+
+// stop stops the watchdog, the main container is expected to become un-ready while it shuts down
+func (w *watchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+}
+
+// check returns the reason and message if the main container has been hung for longer than the timeout, and resets
+// the watchdog so the restarted container has the same timeout to recover in
+func (w *watchdog) check(now time.Time, timeout time.Duration, checkReady bool) (string, string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return "", ""
+	}
+	var reason, message string
+	if d := now.Sub(w.lastReady); checkReady && d > timeout {
+		reason, message = dfv1.WatchdogReasonUnready, fmt.Sprintf("main container has not been ready for %v", d.Truncate(time.Second))
+	} else if d := now.Sub(w.lastProgress); w.inFlight > 0 && d > timeout {
+		reason, message = dfv1.WatchdogReasonStuck, fmt.Sprintf("main container has had %d message(s) in-flight, and completed none, for %v", w.inFlight, d.Truncate(time.Second))
+	} else {
+		return "", ""
+	}
+	w.lastReady, w.lastProgress = now, now
+	return reason, message
+}
+
+func mainReady() bool {
+	resp, err := readyClient.Get("http://localhost:8080/ready")
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode < 300
+}
+
+// checkWatchdog records in the step status if the main container is hung, so that the controller restarts it
+func checkWatchdog(timeout time.Duration, checkReady bool) {
+	if checkReady && mainReady() {
+		mainWatchdog.ready(time.Now())
+	}
+	reason, message := mainWatchdog.check(time.Now(), timeout, checkReady)
+	if reason == "" {
+		return
+	}
+	logger.Info("main container hung", "reason", reason, "message", message)
+	withLock(func() {
+		if step.Status.Watchdog == nil {
+			step.Status.Watchdog = dfv1.WatchdogStatuses{}
+		}
+		step.Status.Watchdog.Set(replica, reason, message, metav1.Now())
+	})
+	patchStepStatus()
+}
+
+func stopWatchdogHook(context.Context) error {
+	mainWatchdog.stop()
+	return nil
+}
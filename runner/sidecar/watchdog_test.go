@@ -0,0 +1,58 @@
+package sidecar
+
+import (
+	"testing"
+	"time"
+
+	dfv1 "github.com/argoproj-labs/argo-dataflow/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_watchdog(t *testing.T) {
+	start := time.Now()
+	timeout := time.Minute
+	t.Run("Healthy", func(t *testing.T) {
+		w := newWatchdog(start)
+		w.ready(start.Add(time.Minute))
+		reason, _ := w.check(start.Add(90*time.Second), timeout, true)
+		assert.Empty(t, reason)
+	})
+	t.Run("Unready", func(t *testing.T) {
+		w := newWatchdog(start)
+		reason, message := w.check(start.Add(2*time.Minute), timeout, true)
+		assert.Equal(t, dfv1.WatchdogReasonUnready, reason)
+		assert.Equal(t, "main container has not been ready for 2m0s", message)
+		// the restarted container gets the timeout to recover
+		reason, _ = w.check(start.Add(150*time.Second), timeout, true)
+		assert.Empty(t, reason)
+	})
+	t.Run("UnreadyNotChecked", func(t *testing.T) {
+		w := newWatchdog(start)
+		reason, _ := w.check(start.Add(2*time.Minute), timeout, false)
+		assert.Empty(t, reason)
+	})
+	t.Run("Stuck", func(t *testing.T) {
+		w := newWatchdog(start)
+		w.messageStarted(start.Add(time.Minute)) // idle until now, so this is not stuck
+		w.messageStarted(start.Add(80 * time.Second))
+		reason, _ := w.check(start.Add(100*time.Second), timeout, false)
+		assert.Empty(t, reason)
+		reason, message := w.check(start.Add(150*time.Second), timeout, false)
+		assert.Equal(t, dfv1.WatchdogReasonStuck, reason)
+		assert.Equal(t, "main container has had 2 message(s) in-flight, and completed none, for 1m30s", message)
+	})
+	t.Run("Progressing", func(t *testing.T) {
+		w := newWatchdog(start)
+		w.messageStarted(start)
+		w.messageStarted(start)
+		w.messageDone(start.Add(time.Minute))
+		reason, _ := w.check(start.Add(90*time.Second), timeout, false)
+		assert.Empty(t, reason)
+	})
+	t.Run("Stopped", func(t *testing.T) {
+		w := newWatchdog(start)
+		w.stop()
+		reason, _ := w.check(start.Add(2*time.Minute), timeout, true)
+		assert.Empty(t, reason)
+	})
+}
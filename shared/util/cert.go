@@ -1,4 +1,4 @@
-package sidecar
+package util
 
 import (
 	"crypto/rand"
@@ -12,7 +12,7 @@ import (
 )
 
 // based on https://golang.org/src/crypto/tls/generate_cert.go
-func generateCert(certFile, keyFile string) error {
+func GenerateCert(certFile, keyFile string) error {
 	priv, err := rsa.GenerateKey(rand.Reader, 4096)
 	if err != nil {
 		return err